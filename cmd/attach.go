@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/masgari/ollama-cli/pkg/attach"
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/output"
+)
+
+// bytesPerToken is the rough size of a token, used to turn token counts into sizes
+const bytesPerToken = 4
+
+// defaultContextLength is assumed for models that do not report their context length
+const defaultContextLength = 4096
+
+// attachmentBudget returns the size in bytes that the files attached to a message may
+// take, which is half of the context window of the model, leaving room for the history
+// and the answer. A smaller context window set with the num_ctx option applies instead.
+func attachmentBudget(ollamaClient client.Client, status *output.ColorWriter, model string, options map[string]interface{}) int {
+	contextLength := 0
+	if details, err := ollamaClient.GetModelDetails(context.Background(), model); err == nil {
+		contextLength = modelContextLength(details)
+	}
+
+	numCtx := numCtxOption(options)
+	if numCtx > 0 && (contextLength == 0 || numCtx < contextLength) {
+		contextLength = numCtx
+	}
+
+	if contextLength == 0 {
+		status.WarningPrintf("The context length of model '%s' is unknown, assuming %d tokens.\n", model, defaultContextLength)
+		contextLength = defaultContextLength
+	}
+	return contextLength / 2 * bytesPerToken
+}
+
+// attachFiles adds the files matching patterns to a collector. The files are checked
+// for prompt injection, and those that were skipped are reported.
+func attachFiles(collector *attach.Collector, status *output.ColorWriter, patterns []string, strictSecurity bool) error {
+	files, skipped := len(collector.Files), len(collector.Skipped)
+	for _, pattern := range patterns {
+		if err := collector.Add(pattern); err != nil {
+			return err
+		}
+	}
+
+	for i := files; i < len(collector.Files); i++ {
+		file := &collector.Files[i]
+		file.Content = checkInput(status, fmt.Sprintf("File '%s'", file.Path), file.Content, strictSecurity)
+	}
+	for _, skip := range collector.Skipped[skipped:] {
+		status.WarningPrintf("Skipped '%s': %s\n", skip.Path, skip.Reason)
+	}
+	return nil
+}
+
+// attachmentSummary describes the files of a collector and the share of the budget
+// they take
+func attachmentSummary(collector *attach.Collector) string {
+	files := "files"
+	if len(collector.Files) == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s, %s of %s", len(collector.Files), files,
+		formatSize(int64(collector.Size())), formatSize(int64(collector.Budget())))
+}
+
+// withAttachments appends the files of a collector to the content of a message
+func withAttachments(content string, collector *attach.Collector) string {
+	if collector == nil || len(collector.Files) == 0 {
+		return content
+	}
+	if content == "" {
+		return collector.Format()
+	}
+	return content + "\n\n" + collector.Format()
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentBudget(t *testing.T) {
+	details := &api.ShowResponse{
+		ModelInfo: map[string]interface{}{
+			"general.architecture": "llama",
+			"llama.context_length": float64(8192),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		details     *api.ShowResponse
+		err         error
+		options     map[string]interface{}
+		want        int
+		wantWarning bool
+	}{
+		{name: "Half of the context length", details: details, want: 4096 * bytesPerToken},
+		{name: "Smaller num_ctx", details: details, options: map[string]interface{}{"num_ctx": 2048}, want: 1024 * bytesPerToken},
+		{name: "Larger num_ctx", details: details, options: map[string]interface{}{"num_ctx": float64(32768)}, want: 4096 * bytesPerToken},
+		{name: "Unknown context length", err: errors.New("model not found"), want: defaultContextLength / 2 * bytesPerToken, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := client.NewMockClient()
+			mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(tt.details, tt.err)
+
+			var status bytes.Buffer
+			got := attachmentBudget(mockClient, output.NewColorWriter(&status), "test-model", tt.options)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantWarning, strings.Contains(status.String(), "context length of model 'test-model' is unknown"))
+		})
+	}
+}
+
+// writeAttachments creates files to attach in a temporary directory
+func writeAttachments(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "logo.png"), []byte("\x89PNG\x00"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("Ship on Friday\n"), 0644))
+	return dir
+}
+
+func TestChatFiles(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+	isStdinPiped = func() bool { return false }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	dir := writeAttachments(t)
+	src := filepath.ToSlash(filepath.Join(dir, "src"))
+	notes := filepath.ToSlash(filepath.Join(dir, "notes.txt"))
+
+	mockClient := client.NewMockClient()
+	client.SetClientFactory(func() (client.Client, error) {
+		return mockClient, nil
+	})
+	defer client.ResetClientFactory()
+
+	mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+		Details: api.ModelDetails{ContextLength: 4096},
+	}, nil)
+	want := "Summarize\n\n" +
+		"File: " + src + "/main.go\n```go\npackage main\n```\n\n" +
+		"File: " + notes + "\n```txt\nShip on Friday\n```"
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return req.Messages[len(req.Messages)-1].Content == want
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "A Go program to ship on Friday"},
+	}, nil).Once()
+
+	resetFlags(chatCmd)
+	defer resetFlags(chatCmd)
+	var out bytes.Buffer
+	root := &cobra.Command{Use: "ollama-cli"}
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.AddCommand(chatCmd)
+	root.SetArgs([]string{"chat", "test-model", "-p", "Summarize", "--no-stream", "-f", src, "--file", notes})
+
+	require.NoError(t, root.Execute())
+	mockClient.AssertExpectations(t)
+
+	assert.Equal(t, "A Go program to ship on Friday\n", out.String())
+	assert.Contains(t, status.String(), "User: Summarize\n")
+	assert.Contains(t, status.String(), "Files: 2 files, 28 B of 8.0 KB\n")
+	assert.Contains(t, status.String(), "Skipped '"+src+"/logo.png': binary file")
+
+	// Files that do not exist are an error
+	resetFlags(chatCmd)
+	root.SetArgs([]string{"chat", "test-model", "-p", "Summarize", "-f", filepath.Join(dir, "missing.go")})
+	assert.ErrorContains(t, root.Execute(), "failed to attach")
+}
+
+func TestInteractiveChatAttach(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	dir := writeAttachments(t)
+	notes := filepath.ToSlash(filepath.Join(dir, "notes.txt"))
+
+	mockClient := client.NewMockClient()
+	mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+		Details: api.ModelDetails{ContextLength: 4096},
+	}, nil)
+
+	// Only the message after /attach has the files
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 2 &&
+			req.Messages[1].Content == "When?\n\nFile: "+notes+"\n```txt\nShip on Friday\n```"
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "Friday"},
+	}, nil).Once()
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 4 && req.Messages[3].Content == "Thanks"
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "You're welcome"},
+	}, nil).Once()
+
+	chat, out := newTestInteractiveChat(mockClient, strings.Join([]string{
+		"/attach",
+		"/attach " + filepath.Join(dir, "src"),
+		"/detach",
+		"/attach " + notes,
+		"/attach",
+		"When?",
+		"Thanks",
+		"/exit",
+	}, "\n"))
+
+	require.NoError(t, chat.run())
+	mockClient.AssertExpectations(t)
+
+	assert.Nil(t, chat.attachments)
+	assert.Contains(t, status.String(), "No files attached.")
+	assert.Contains(t, status.String(), "Removed the attached files.")
+	assert.Contains(t, status.String(), "Attached 1 file, 15 B of 8.0 KB, sent with the next message.")
+	assert.Contains(t, out.String(), "  "+notes+" (15 B)\n")
+}
@@ -1,19 +1,28 @@
 package cmd
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/masgari/ollama-cli/pkg/attach"
 	"github.com/masgari/ollama-cli/pkg/client"
 	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/history"
+	"github.com/masgari/ollama-cli/pkg/images"
+	"github.com/masgari/ollama-cli/pkg/markdown"
 	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/readline"
 	"github.com/masgari/ollama-cli/pkg/security"
+	"github.com/masgari/ollama-cli/pkg/session"
+	"github.com/masgari/ollama-cli/pkg/tools"
 	"github.com/ollama/ollama/api"
 	"github.com/spf13/cobra"
 )
@@ -42,15 +51,11 @@ func enableChatCommand() error {
 	output.Default.InfoPrintf("This command allows direct interaction with AI models and could potentially be misused.\n")
 	output.Default.InfoPrintf("If you understand the risks and want to enable it, please confirm below.\n\n")
 
-	fmt.Print(output.Highlight("Do you want to enable the chat command? (y/n): "))
-	reader := bufio.NewReader(os.Stdin)
-	confirmInput, err := reader.ReadString('\n')
+	confirmed, err := confirm(stdinEditor(), "Do you want to enable the chat command? (y/n): ")
 	if err != nil {
-		return fmt.Errorf("failed to read confirmation: %w", err)
+		return err
 	}
-	confirmInput = strings.TrimSpace(confirmInput)
-
-	if strings.ToLower(confirmInput) != "y" && strings.ToLower(confirmInput) != "yes" {
+	if !confirmed {
 		output.Default.InfoPrintf("Chat command remains disabled. Use --help for more information.\n")
 		return fmt.Errorf("chat command not enabled")
 	}
@@ -81,6 +86,76 @@ but no protection is perfect. Be cautious when using this command with untrusted
 or in security-sensitive environments. The command will warn you about potentially
 suspicious inputs and outputs.
 
+INTERACTIVE MODE:
+In interactive mode, lines starting with a slash are commands, such as /model, /set,
+/system, /attach, /undo, /retry, /history, /save, /load, /stats and /context. Type
+/help for the full list of commands, and press Tab to complete their names and paths.
+Start a message with """ to write it over several lines, up to a line ending with """,
+or use /edit to write it in $EDITOR. The input history is kept across chats. Ctrl-C
+stops the current answer and keeps the partial answer marked as truncated, a second
+Ctrl-C leaves the chat after saving it to --output-file and --session.
+
+/edit N edits message N of /history in $EDITOR and answers it on a new branch, keeping
+the original messages on the branch they were on. /branch starts a branch, /branches
+lists them and /checkout switches to another one. With several branches, /save,
+--output-file and --session keep them all in a transcript that /load restores, while
+--input-file reads the current branch and /export saves it as an array of messages.
+
+When the conversation nears the context window of the model, older messages are left
+out of the requests with --context-strategy: sliding-window keeps the latest messages
+that fit in half of the window, drop-oldest drops as few messages as needed, summarize
+replaces them with a summary written by the model, and none sends everything. The
+transcript keeps all messages, and /context shows the use of the window.
+
+PIPELINES:
+Piped stdin is the prompt, or is attached to --prompt as input, using a template that
+refers to them as {{.Prompt}} and {{.Input}}. The template is set with --stdin-template
+or 'ollama-cli config set stdin-template', and defaults to the prompt followed by the
+input between <input> tags. --input-file and --image read stdin when set to -, and
+--output-file - writes the history to stdout. --quiet leaves out the status lines
+around the answer, so only the answer is written to stdout.
+
+FILES:
+--file attaches text files, directories or glob patterns such as 'cmd/**/*.go' to the
+message. Files ignored by .gitignore and binary files are skipped, and the files may
+take half of the context window of the model, files beyond this budget are skipped.
+
+IMAGES:
+--image can be repeated and accepts files, http and https URLs downloaded by the CLI,
+and data URIs. Images must be PNG, JPEG, GIF or WebP, as detected from their content,
+and are downscaled to --max-image-size pixels when set. A warning is shown when the
+capabilities of the model do not include vision.
+
+MARKDOWN:
+When stdout is a terminal, answers are rendered as Markdown as they are streamed, with
+headings, emphasis, lists, aligned tables and highlighted code blocks. Colors are left
+out with --no-color. When stdout is not a terminal, answers are written as raw text.
+
+TOOL CALLING:
+With --tools, the model can call tools declared in a YAML or JSON file. Each tool has a
+name, a description, a JSON schema for its parameters and either a local command, which
+receives the arguments as JSON on stdin, or an HTTP endpoint. You are asked to confirm
+every call unless --approve-tools is set. For example:
+
+  tools:
+    - name: get_weather
+      description: Get the current weather for a city
+      parameters:
+        type: object
+        properties:
+          city: {type: string, description: Name of the city}
+        required: [city]
+      command: ["./weather.sh"]
+    - name: search_docs
+      description: Search the documentation
+      parameters:
+        type: object
+        properties:
+          query: {type: string}
+      http:
+        url: http://localhost:8080/search
+        method: GET
+
 Examples:
   # Simple chat with a model
   ollama-cli chat llama3.2
@@ -93,6 +168,12 @@ Examples:
   ollama-cli chat llama3.2 --prompt "What's in this image?" --image /path/to/image.jpg
   ollama-cli chat llama3.2 -p "What's in this image?" -i /path/to/image.jpg
 
+  # Compare images from files and URLs, downscaled to at most 1024 pixels
+  ollama-cli chat llava -p "What changed?" -i before.png -i https://example.com/after.png --max-image-size 1024
+
+  # Ask about files attached to the message
+  ollama-cli chat llama3.2 -p "Explain this project" -f README.md -f 'cmd/**/*.go'
+
   # Chat with a model using an input file
   ollama-cli chat llama3.2 --input-file chat_history.json
 
@@ -112,7 +193,24 @@ Examples:
 
   # Display statistics about the chat
   ollama-cli chat llama3.2 --prompt "Hello" --stats --no-stream
-  ollama-cli chat llama3.2 -p "Hello" --stats --no-stream`,
+  ollama-cli chat llama3.2 -p "Hello" --stats --no-stream
+
+  # Let the model call local tools, asking before each call
+  ollama-cli chat llama3.2 --tools tools.yaml -p "What's the weather in Paris?"
+
+  # Write only a JSON reply matching a schema, retrying twice if it does not match
+  ollama-cli chat llama3.2 -p "Describe Paris" --schema city.json --retries 2 > paris.json
+
+  # Let a reasoning model think, showing the full thinking trace
+  ollama-cli chat qwen3 -p "How many r's are in strawberry?" --think --thinking-display show
+  ollama-cli chat gpt-oss -p "Plan a three day trip to Rome" --think=high
+
+  # Resume the named session 'work', or start it, saving the conversation as it goes
+  ollama-cli chat llama3.2 --session work -I
+
+  # Review piped input, writing only the answer
+  cat main.go | ollama-cli chat llama3.2 -p "Review this code" --quiet > review.md
+  git diff | ollama-cli chat llama3.2 -p "Write a commit message" -q --stdin-template "{{.Prompt}}:\n{{.Input}}"`,
 	Args: cobra.ExactArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		// Skip completion if chat is not enabled
@@ -141,7 +239,8 @@ Examples:
 
 		modelName := args[0]
 		promptText, _ := cmd.Flags().GetString("prompt")
-		imagePath, _ := cmd.Flags().GetString("image")
+		imageSources, _ := cmd.Flags().GetStringArray("image")
+		maxImageSize, _ := cmd.Flags().GetInt("max-image-size")
 		inputFile, _ := cmd.Flags().GetString("input-file")
 		outputFile, _ := cmd.Flags().GetString("output-file")
 		noStream, _ := cmd.Flags().GetBool("no-stream")
@@ -150,7 +249,55 @@ Examples:
 		systemPrompt, _ := cmd.Flags().GetString("system")
 		showStats, _ := cmd.Flags().GetBool("stats")
 		strictSecurity, _ := cmd.Flags().GetBool("strict-security")
-		stream := !noStream
+		toolsFile, _ := cmd.Flags().GetString("tools")
+		maxToolRounds, _ := cmd.Flags().GetInt("max-tool-rounds")
+		approveTools, _ := cmd.Flags().GetBool("approve-tools")
+		thinkValue, _ := cmd.Flags().GetString("think")
+		thinkingDisplay, _ := cmd.Flags().GetString("thinking-display")
+		keepThinking, _ := cmd.Flags().GetBool("keep-thinking")
+		sessionName, _ := cmd.Flags().GetString("session")
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		filePatterns, _ := cmd.Flags().GetStringArray("file")
+		stdinTemplate, _ := cmd.Flags().GetString("stdin-template")
+		contextStrategy, _ := cmd.Flags().GetString("context-strategy")
+
+		// In interactive mode, the chat itself reads stdin and writes to stdout
+		stdinImages := 0
+		for _, source := range imageSources {
+			if source == stdinArg {
+				stdinImages++
+			}
+		}
+		imageFromStdin := stdinImages > 0
+		if interactive {
+			if inputFile == stdinArg || imageFromStdin || outputFile == stdinArg {
+				return fmt.Errorf("'-' cannot be used as a file in interactive mode")
+			}
+			if quiet {
+				return fmt.Errorf("--quiet cannot be used with --interactive")
+			}
+		}
+		if inputFile == stdinArg && imageFromStdin {
+			return fmt.Errorf("--input-file and --image cannot both read stdin")
+		}
+		if stdinImages > 1 {
+			return fmt.Errorf("--image can read stdin only once")
+		}
+		if maxImageSize < 0 {
+			return fmt.Errorf("--max-image-size must not be negative")
+		}
+		if stdinTemplate == "" {
+			stdinTemplate = stdinTemplateText(config.Current)
+		}
+		if _, err := parseStdinTemplate(stdinTemplate); err != nil {
+			return err
+		}
+		if contextStrategy == "" {
+			contextStrategy = contextStrategyText(config.Current)
+		}
+		if err := validateContextStrategy(contextStrategy); err != nil {
+			return err
+		}
 
 		// Prepare model options
 		options := make(map[string]interface{})
@@ -158,29 +305,101 @@ Examples:
 			options["temperature"] = temperature
 		}
 
+		settings := &chatSettings{
+			model:         modelName,
+			stream:        !noStream,
+			options:       options,
+			maxToolRounds: maxToolRounds,
+			approveTools:  approveTools,
+			quiet:         quiet,
+		}
+
+		structured, err := structuredOutputFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		settings.structured = structured
+
+		if settings.think, err = parseThinkFlag(thinkValue); err != nil {
+			return err
+		}
+		if thinkingDisplay == "" {
+			thinkingDisplay = thinkingCollapse
+		}
+		if err := validateThinkingDisplay(thinkingDisplay); err != nil {
+			return err
+		}
+		settings.thinkingDisplay = thinkingDisplay
+		settings.keepThinking = keepThinking
+		settings.markdown = isStdoutTerminal() && structured == nil
+		status := settings.status()
+
+		// Resume the session, its options and system prompt apply unless set by flags
+		var chatSess *chatSession
+		if sessionName != "" {
+			chatSess, err = openChatSession(session.DefaultStore(), status, sessionName, modelName)
+			if err != nil {
+				return err
+			}
+			for key, value := range chatSess.session.Options {
+				if _, ok := options[key]; !ok {
+					options[key] = value
+				}
+			}
+			if systemPrompt == "" {
+				systemPrompt = chatSess.session.System
+			}
+			chatSess.session.System = systemPrompt
+		}
+
+		// Load the tools the model may call
+		if toolsFile != "" {
+			registry, err := tools.LoadFile(toolsFile)
+			if err != nil {
+				return err
+			}
+			settings.tools = registry
+		}
+
 		ollamaClient, err := createOllamaClient()
 		if err != nil {
 			return err
 		}
 
+		// Collect the files to attach to the message, within the budget of the model
+		var attachments *attach.Collector
+		if len(filePatterns) > 0 {
+			attachments = attach.NewCollector(attachmentBudget(ollamaClient, status, modelName, options))
+			if err := attachFiles(attachments, status, filePatterns, strictSecurity); err != nil {
+				return err
+			}
+		}
+
 		// Initialize messages array
 		var messages []api.Message
 
-		// Always add the security system prompt as the first message
+		// Always add the security system prompt as the first message, followed by
+		// the user system prompt if provided
 		messages = append(messages, api.Message{
 			Role:    "system",
-			Content: securitySystemPrompt,
+			Content: chatSystemPrompt(systemPrompt),
 		})
 
-		// Add user system message if provided (appended to security prompt)
-		if systemPrompt != "" {
-			// If user provided a system prompt, append it to the security prompt
-			messages[0].Content += "\n\nAdditional instructions: " + systemPrompt
-		}
+		// Add the history of the session, the new messages are added to its current
+		// branch
+		conversation := chatSess.history()
+		messages = append(messages, conversation.Messages()...)
+		resumed := len(messages)
 
 		// Load messages from input file if provided
 		if inputFile != "" {
-			loadedMessages, err := loadMessagesFromFile(inputFile)
+			var loadedMessages []api.Message
+			var err error
+			if inputFile == stdinArg {
+				loadedMessages, err = decodeMessages(cmd.InOrStdin())
+			} else {
+				loadedMessages, err = loadMessagesFromFile(inputFile)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to load messages from file: %w", err)
 			}
@@ -193,16 +412,34 @@ Examples:
 			}
 		}
 
-		// Process image if provided
-		var imageData []byte
-		if imagePath != "" {
-			var err error
-			imageData, err = os.ReadFile(imagePath)
+		// Load and check the images, downscaling them if requested
+		loadedImages, err := loadImages(context.Background(), &images.Loader{MaxSize: maxImageSize}, imageSources, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		if len(loadedImages) > 0 {
+			checkVision(ollamaClient, status, modelName)
+		}
+		imageData := messageImages(loadedImages)
+
+		// Piped stdin is the prompt, or input attached to --prompt, unless a file
+		// argument reads it
+		var pipedInput string
+		if !interactive && inputFile != stdinArg && !imageFromStdin && isStdinPiped() {
+			data, err := io.ReadAll(cmd.InOrStdin())
 			if err != nil {
-				return fmt.Errorf("failed to read image file: %w", err)
+				return fmt.Errorf("failed to read stdin: %w", err)
+			}
+			if promptText == "" {
+				promptText = strings.TrimSpace(string(data))
+			} else {
+				pipedInput = checkInput(status, "Piped input", string(data), strictSecurity)
 			}
 		}
 
+		// userText is the text of the new message, without its attachments
+		var userText string
+
 		// Add new user message if provided via --prompt flag
 		if promptText != "" {
 			// Apply sanitization based on security mode
@@ -215,58 +452,102 @@ Examples:
 
 			// Display warnings if any
 			for _, warning := range sanitizeResult.Warnings {
-				output.Default.WarningPrintf("%s\n", warning)
+				status.WarningPrintf("%s\n", warning)
 			}
 
 			// If suspicious, display a warning and ask for confirmation
 			if sanitizeResult.IsSuspicious {
-				output.Default.WarningPrintf("%s\n", security.GetWarningMessage())
+				status.WarningPrintf("%s\n", security.GetWarningMessage())
+
+				// There is no one to confirm when stdin is piped
+				if isStdinPiped() {
+					return fmt.Errorf("input contains suspicious patterns and cannot be confirmed when stdin is piped")
+				}
 
 				// In non-interactive mode with a suspicious input, ask for confirmation
-				fmt.Print(output.Highlight("Your input contains suspicious patterns. Continue anyway? (y/n): "))
-				reader := bufio.NewReader(os.Stdin)
-				confirmInput, err := reader.ReadString('\n')
+				confirmed, err := confirm(stdinEditor(), "Your input contains suspicious patterns. Continue anyway? (y/n): ")
 				if err != nil {
-					return fmt.Errorf("failed to read confirmation: %w", err)
+					return err
 				}
-				confirmInput = strings.TrimSpace(confirmInput)
-				if strings.ToLower(confirmInput) != "y" && strings.ToLower(confirmInput) != "yes" {
+				if !confirmed {
 					output.Default.InfoPrintf("Operation cancelled.\n")
 					return nil
 				}
 			}
 
+			userText = sanitizeResult.SanitizedInput
+			content := userText
+			if pipedInput != "" {
+				var err error
+				if content, err = composeStdinPrompt(stdinTemplate, content, pipedInput); err != nil {
+					return err
+				}
+			}
+			content = withAttachments(content, attachments)
+
 			userMessage := api.Message{
 				Role:    "user",
-				Content: sanitizeResult.SanitizedInput,
+				Content: content,
 			}
 
-			// Add image to the message if provided
+			// Add images to the message if provided
 			if len(imageData) > 0 {
-				userMessage.Images = []api.ImageData{imageData}
+				userMessage.Images = imageData
 			}
 
 			messages = append(messages, userMessage)
 		} else if len(imageData) > 0 {
-			// If only image is provided without prompt text
+			// If only images are provided without prompt text
+			userText = defaultImagePrompt(len(imageData))
 			userMessage := api.Message{
 				Role:    "user",
-				Content: "What's in this image?", // Default prompt for image-only input
-				Images:  []api.ImageData{imageData},
+				Content: withAttachments(userText, attachments),
+				Images:  imageData,
 			}
 			messages = append(messages, userMessage)
 		}
 
 		// If interactive mode is enabled, start an interactive chat session
 		if interactive {
-			return runInteractiveChat(ollamaClient, modelName, messages, stream, outputFile, options, showStats, strictSecurity)
+			// The input history is shared by all chats
+			editor := stdinEditor()
+			if err := editor.SetHistoryFile(filepath.Join(config.GetConfigDir(), "history")); err != nil {
+				output.Default.WarningPrintf("%s\n", err)
+			}
+
+			// Files not attached to a message given with the flags go with the first one
+			if userText != "" {
+				attachments = nil
+			}
+
+			conversation.Append(messages[resumed:]...)
+			chat := &interactiveChat{
+				client:         ollamaClient,
+				out:            cmd.OutOrStdout(),
+				editor:         editor,
+				settings:       settings,
+				history:        conversation,
+				systemPrompt:   systemPrompt,
+				outputFile:     outputFile,
+				session:        chatSess,
+				showStats:      showStats,
+				strictSecurity: strictSecurity,
+				attachments:    attachments,
+				maxImageSize:   maxImageSize,
+				contextManager: newContextManager(ollamaClient, contextStrategy),
+			}
+			return chat.run()
 		}
 
-		// If no input provided via flag or file, prompt the user
-		if promptText == "" && len(imageData) == 0 && len(messages) == 0 || (len(messages) == 1 && messages[0].Role == "system") {
-			fmt.Print(output.Highlight("Enter your message (type 'exit' to quit): "))
-			reader := bufio.NewReader(os.Stdin)
-			input, err := reader.ReadString('\n')
+		// If no input provided via flag or file, prompt the user. A resumed session always
+		// needs a new message, since its history ends with the previous answer.
+		if promptText == "" && len(imageData) == 0 && len(messages) == 0 || (len(messages) == 1 && messages[0].Role == "system") ||
+			(chatSess != nil && promptText == "" && len(imageData) == 0) {
+			// There is no one to ask when stdin is piped
+			if isStdinPiped() {
+				return fmt.Errorf("no user message provided")
+			}
+			input, err := stdinEditor().ReadLine(output.Highlight("Enter your message (type 'exit' to quit): "))
 			if err != nil {
 				return fmt.Errorf("failed to read input: %w", err)
 			}
@@ -293,22 +574,20 @@ Examples:
 				output.Default.WarningPrintf("%s\n", security.GetWarningMessage())
 
 				// In non-interactive mode with a suspicious input, ask for confirmation
-				fmt.Print(output.Highlight("Your input contains suspicious patterns. Continue anyway? (y/n): "))
-				reader := bufio.NewReader(os.Stdin)
-				confirmInput, err := reader.ReadString('\n')
+				confirmed, err := confirm(stdinEditor(), "Your input contains suspicious patterns. Continue anyway? (y/n): ")
 				if err != nil {
-					return fmt.Errorf("failed to read confirmation: %w", err)
+					return err
 				}
-				confirmInput = strings.TrimSpace(confirmInput)
-				if strings.ToLower(confirmInput) != "y" && strings.ToLower(confirmInput) != "yes" {
+				if !confirmed {
 					output.Default.InfoPrintf("Operation cancelled.\n")
 					return nil
 				}
 			}
 
+			userText = sanitizeResult.SanitizedInput
 			messages = append(messages, api.Message{
 				Role:    "user",
-				Content: sanitizeResult.SanitizedInput,
+				Content: withAttachments(userText, attachments),
 			})
 		}
 
@@ -333,60 +612,369 @@ Examples:
 				break
 			}
 		}
-		if lastUserMsg != "" {
-			output.Default.InfoPrintf("Chatting with model '%s'\n", output.Highlight(modelName))
-			output.Default.InfoPrintf("User: %s\n", output.Info(lastUserMsg))
-			if len(imageData) > 0 {
-				output.Default.InfoPrintf("Image: %s\n", output.Info(imagePath))
+		if lastUserMsg != "" && !quiet {
+			status.InfoPrintf("Chatting with model '%s'\n", output.Highlight(modelName))
+			// Piped input and files are summarized rather than repeated
+			if userText == "" {
+				userText = lastUserMsg
 			}
-			output.Default.InfoPrintf("Assistant: ")
-		}
-
-		// Send the chat request
-		response, err := ollamaClient.ChatWithModel(context.Background(), modelName, messages, stream, options)
-		if err != nil {
-			return fmt.Errorf("chat error: %w", err)
+			status.InfoPrintf("User: %s\n", output.Info(userText))
+			if pipedInput != "" {
+				status.InfoPrintf("Input: %s\n", output.Info(fmt.Sprintf("%d lines from stdin", strings.Count(strings.TrimRight(pipedInput, "\n"), "\n")+1)))
+			}
+			if attachments != nil {
+				status.InfoPrintf("Files: %s\n", attachmentSummary(attachments))
+			}
+			for _, img := range loadedImages {
+				status.InfoPrintf("Image: %s\n", output.Info(describeImage(img)))
+			}
+			status.InfoPrintf(assistantPrompt)
 		}
 
-		// If not streaming, print the response
-		if !stream && response != nil {
-			fmt.Println(response.Message.Content)
-			// Ensure stdout is flushed
-			os.Stdout.Sync()
+		// Send the chat request, the first Ctrl-C interrupts the response and keeps
+		// the partial answer, a second one exits once it is saved
+		ctx, stop := interruptibleContext()
+		response, added, sendErr := sendChatMessage(ctx, ollamaClient, cmd.OutOrStdout(), settings, messages)
+		forced := stop()
+		if sendErr != nil && !errors.Is(sendErr, errInterrupted) {
+			return fmt.Errorf("chat error: %w", sendErr)
 		}
 
-		// Add the assistant's response to the messages
-		if response != nil {
-			messages = append(messages, response.Message)
-		}
+		// Add the assistant's response and any tool results to the messages
+		messages = append(messages, added...)
 
 		// Display statistics if requested
 		if showStats && response != nil {
-			displayStats(response)
+			displayStats(response.Metrics)
+			displayThinkingStats(added)
 		}
 
-		// Save messages to output file if provided
-		if outputFile != "" {
+		conversation.Append(messages[resumed:]...)
+		if err := chatSess.save(conversation, settings, response); err != nil {
+			return err
+		}
+
+		// Save messages to output file if provided, or write them to stdout
+		if outputFile == stdinArg {
+			if err := encodeMessages(cmd.OutOrStdout(), messages); err != nil {
+				return fmt.Errorf("failed to write messages: %w", err)
+			}
+		} else if outputFile != "" {
 			if err := saveMessagesToFile(messages, outputFile); err != nil {
 				return fmt.Errorf("failed to save messages to file: %w", err)
 			}
-			output.Default.SuccessPrintf("\nChat history saved to '%s'\n", output.Highlight(outputFile))
+			status.SuccessPrintf("\nChat history saved to '%s'\n", output.Highlight(outputFile))
 		}
 
+		if forced {
+			exitProcess(130)
+		}
+		if sendErr != nil {
+			return fmt.Errorf("chat error: %w", sendErr)
+		}
 		return nil
 	},
 }
 
-// displayStats displays the statistics from the chat response
-func displayStats(response *api.ChatResponse) {
+// chatSettings holds the settings that apply to every request of a chat
+type chatSettings struct {
+	model   string
+	stream  bool
+	options map[string]interface{}
+	// tools are offered to the model, nil disables tool calling
+	tools *tools.Registry
+	// maxToolRounds limits the rounds of tool calls executed for a single message
+	maxToolRounds int
+	// approveTools runs tool calls without asking for confirmation
+	approveTools bool
+	// structured constrains replies to JSON, nil for free text
+	structured *structuredOutput
+	// think enables thinking for reasoning models, nil leaves the server default
+	think *api.ThinkValue
+	// thinkingDisplay is how thinking traces are displayed (collapse, show or hide)
+	thinkingDisplay string
+	// keepThinking sends previous thinking traces back to the model
+	keepThinking bool
+	// markdown renders answers as Markdown, on a terminal
+	markdown bool
+	// quiet leaves out the prompts around answers and writes status messages to stderr
+	quiet bool
+}
+
+// status returns the writer for status messages, which go to stderr when stdout is
+// reserved for structured replies or the answer alone
+func (s *chatSettings) status() *output.ColorWriter {
+	if s.structured != nil || s.quiet {
+		return output.GetStdErr()
+	}
+	return output.Default
+}
+
+// confirmToolCall asks the user whether a tool call may be executed, it can be overridden in tests
+var confirmToolCall = func(call api.ToolCall) (bool, error) {
+	return confirm(stdinEditor(), fmt.Sprintf("Run tool '%s'? (y/n): ", call.Function.Name))
+}
+
+// stdinEditor returns the editor reading what the user types. Every prompt of the
+// chat reads stdin through it, so a line it has buffered is not lost to the next
+// prompt, such as a confirmation asked during an interactive chat.
+var stdinEditor = sync.OnceValue(func() *readline.Editor {
+	return readline.New(os.Stdin, os.Stdout)
+})
+
+// confirm asks a yes or no question with an editor, Ctrl-C answers no
+func confirm(editor *readline.Editor, prompt string) (bool, error) {
+	confirmInput, err := editor.ReadLine(output.Highlight(prompt))
+	if err != nil && err != readline.ErrInterrupt {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	confirmInput = strings.ToLower(strings.TrimSpace(confirmInput))
+	return confirmInput == "y" || confirmInput == "yes", nil
+}
+
+// sendChatMessage sends the conversation to the model and writes the response to out,
+// as it is streamed or at once when streaming is disabled. Each response is validated
+// for security issues. When the model calls tools, they are executed and their results
+// sent back until the model answers or the tool round limit is reached. Structured
+// replies are only written once they are valid, retrying with the validation errors
+// if allowed. It returns the final response and the messages to add to the conversation.
+// When ctx is canceled, it returns errInterrupted with the messages added so far,
+// including the partial answer marked as truncated.
+func sendChatMessage(ctx context.Context, ollamaClient client.Client, out io.Writer, settings *chatSettings, messages []api.Message) (*api.ChatResponse, []api.Message, error) {
+	status := settings.status()
+
+	// Thinking is never written to stdout reserved for structured replies
+	thinkingDisplay := settings.thinkingDisplay
+	if settings.structured != nil {
+		thinkingDisplay = thinkingHide
+	}
+	var thinking *thinkingRenderer
+	answer := out
+	var renderer *answerRenderer
+
+	// Structured replies are held back until they are validated. The streamed answer
+	// is kept, so it is not lost if the response is interrupted.
+	var fn client.ChatChunkFunc
+	var partial, partialThinking strings.Builder
+	if settings.stream {
+		fn = func(chunk client.ChatChunk) error {
+			partial.WriteString(chunk.Content)
+			partialThinking.WriteString(chunk.Thinking)
+			thinking.Thinking(chunk.Thinking)
+			if chunk.Content == "" || settings.structured != nil {
+				return nil
+			}
+			thinking.Done()
+			_, err := fmt.Fprint(answer, chunk.Content)
+			return err
+		}
+	}
+
+	var format json.RawMessage
+	if settings.structured != nil {
+		format = settings.structured.format
+	}
+
+	var added []api.Message
+	toolRounds, retries := 0, 0
+	for {
+		// Thinking traces stay in the transcript but are not sent back by default
+		requestMessages := append(messages[:len(messages):len(messages)], added...)
+		if !settings.keepThinking {
+			requestMessages = withoutThinking(requestMessages)
+		}
+
+		stream := settings.stream
+		req := &api.ChatRequest{
+			Model:    settings.model,
+			Messages: requestMessages,
+			Stream:   &stream,
+			Format:   format,
+			Options:  settings.options,
+			Tools:    settings.tools.Tools(),
+			Think:    settings.think,
+		}
+
+		thinking = newThinkingRenderer(out, thinkingDisplay, isStdoutTerminal())
+		if settings.markdown {
+			renderer = newAnswerRenderer(out, len(added) == 0 && !settings.quiet, thinking)
+			answer = renderer
+		}
+		partial.Reset()
+		partialThinking.Reset()
+		response, err := ollamaClient.ChatWithModel(ctx, req, fn)
+		if err != nil {
+			thinking.Done()
+			if ctx.Err() == nil {
+				return nil, nil, err
+			}
+
+			// Keep the partial answer of an interrupted response, marked as truncated
+			if settings.structured == nil && partial.Len() > 0 {
+				endAnswer(out, renderer)
+				added = append(added, api.Message{
+					Role:     "assistant",
+					Content:  partial.String() + "\n\n" + truncatedMarker,
+					Thinking: partialThinking.String(),
+				})
+			}
+			return nil, added, errInterrupted
+		}
+		if !settings.stream {
+			thinking.Thinking(response.Message.Thinking)
+		}
+		thinking.Done()
+
+		// A response with only tool calls has no text to finish
+		if settings.structured == nil && (response.Message.Content != "" || len(response.Message.ToolCalls) == 0) {
+			if !settings.stream {
+				fmt.Fprint(answer, response.Message.Content)
+			}
+			endAnswer(out, renderer)
+		}
+
+		validateChatResponse(status, response)
+		added = append(added, response.Message)
+
+		if settings.tools != nil && len(response.Message.ToolCalls) > 0 {
+			if toolRounds >= settings.maxToolRounds {
+				status.WarningPrintf("Stopped after %d rounds of tool calls.\n", settings.maxToolRounds)
+				return response, added, nil
+			}
+			toolRounds++
+
+			for _, call := range response.Message.ToolCalls {
+				result, err := runToolCall(ctx, settings, call)
+				if err != nil {
+					return nil, nil, err
+				}
+				added = append(added, api.Message{
+					Role:       "tool",
+					Content:    result,
+					ToolName:   call.Function.Name,
+					ToolCallID: call.ID,
+				})
+			}
+			continue
+		}
+
+		if settings.structured == nil {
+			return response, added, nil
+		}
+
+		reply, err := settings.structured.validate(response.Message.Content)
+		if err == nil {
+			fmt.Fprintln(out, reply)
+			return response, added, nil
+		}
+		if retries >= settings.structured.retries {
+			return nil, nil, fmt.Errorf("invalid structured reply: %w", err)
+		}
+		retries++
+
+		status.WarningPrintf("Invalid reply, retrying (%d of %d): %s\n", retries, settings.structured.retries, err)
+		added = append(added, api.Message{Role: "user", Content: settings.structured.retryPrompt(err)})
+	}
+}
+
+// assistantPrompt precedes the answers of the model
+const assistantPrompt = "Assistant: "
+
+// answerRenderer renders an answer as Markdown. The first answer to a message starts
+// after the assistant prompt, unless it is left out or a thinking trace was written
+// before it.
+type answerRenderer struct {
+	*markdown.Renderer
+	thinking *thinkingRenderer
+	started  bool
+}
+
+// newAnswerRenderer creates a renderer for an answer written to out
+func newAnswerRenderer(out io.Writer, first bool, thinking *thinkingRenderer) *answerRenderer {
+	renderer := markdown.NewRenderer(out, terminalWidth())
+	if first {
+		renderer.SetColumn(len(assistantPrompt))
+	}
+	return &answerRenderer{Renderer: renderer, thinking: thinking}
+}
+
+// Write renders a chunk of the answer
+func (r *answerRenderer) Write(p []byte) (int, error) {
+	if !r.started {
+		r.started = true
+		if r.thinking.shown() {
+			r.SetColumn(0)
+		}
+	}
+	return r.Renderer.Write(p)
+}
+
+// endAnswer ends the answer written to out, rendering what remains of it when it
+// is rendered as Markdown
+func endAnswer(out io.Writer, renderer *answerRenderer) {
+	if renderer != nil && renderer.started {
+		renderer.Flush()
+		return
+	}
+	fmt.Fprintln(out)
+}
+
+// runToolCall executes a tool call after confirmation and returns the result to send
+// back to the model. Tool failures are reported to the model rather than returned.
+func runToolCall(ctx context.Context, settings *chatSettings, call api.ToolCall) (string, error) {
+	status := settings.status()
+	name := call.Function.Name
+	status.InfoPrintf("Tool call: %s(%s)\n", output.Highlight(name), call.Function.Arguments.String())
+
+	if !settings.tools.Has(name) {
+		status.WarningPrintf("Unknown tool '%s'\n", name)
+		return fmt.Sprintf("Error: unknown tool %q", name), nil
+	}
+
+	if !settings.approveTools {
+		approved, err := confirmToolCall(call)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			status.InfoPrintf("Tool call declined.\n")
+			return "The user declined to run this tool.", nil
+		}
+	}
+
+	result, err := settings.tools.Execute(ctx, call)
+	if err != nil {
+		status.ErrorPrintf("Tool '%s' failed: %s\n", name, err)
+		return "Error: " + err.Error(), nil
+	}
+	return result, nil
+}
+
+// validateChatResponse checks a chat response for security issues and displays any warnings
+func validateChatResponse(status *output.ColorWriter, response *api.ChatResponse) {
+	validationResult := security.ValidateChatResponse(response)
+
+	// Display warnings if any
+	for _, warning := range validationResult.Warnings {
+		status.WarningPrintf("%s\n", warning)
+	}
+
+	// If suspicious, display a warning
+	if validationResult.IsSuspicious {
+		status.WarningPrintf("%s\n", security.GetOutputWarningMessage())
+	}
+}
+
+// displayStats displays the statistics from a chat or generate response
+func displayStats(metrics api.Metrics) {
 	stderr := output.GetStdErr()
 	stderr.InfoPrintf("\nStatistics:\n")
 
 	// Convert nanoseconds to milliseconds for better readability
-	totalDurationMs := float64(response.Metrics.TotalDuration) / 1e6
-	loadDurationMs := float64(response.Metrics.LoadDuration) / 1e6
-	promptEvalDurationMs := float64(response.Metrics.PromptEvalDuration) / 1e6
-	evalDurationMs := float64(response.Metrics.EvalDuration) / 1e6
+	totalDurationMs := float64(metrics.TotalDuration) / 1e6
+	loadDurationMs := float64(metrics.LoadDuration) / 1e6
+	promptEvalDurationMs := float64(metrics.PromptEvalDuration) / 1e6
+	evalDurationMs := float64(metrics.EvalDuration) / 1e6
 
 	// Format durations with appropriate colors based on magnitude
 	totalTimeFormatted := colorizeTime(totalDurationMs)
@@ -395,8 +983,8 @@ func displayStats(response *api.ChatResponse) {
 	evalTimeFormatted := colorizeTime(evalDurationMs)
 
 	// Format token counts with appropriate colors
-	promptTokensFormatted := colorizeTokenCount(response.PromptEvalCount)
-	responseTokensFormatted := colorizeTokenCount(response.EvalCount)
+	promptTokensFormatted := colorizeTokenCount(metrics.PromptEvalCount)
+	responseTokensFormatted := colorizeTokenCount(metrics.EvalCount)
 
 	stderr.InfoPrintf("  Total time: %s\n", totalTimeFormatted)
 	stderr.InfoPrintf("  Load time: %s\n", loadTimeFormatted)
@@ -406,8 +994,8 @@ func displayStats(response *api.ChatResponse) {
 	stderr.InfoPrintf("  Response generation time: %s\n", evalTimeFormatted)
 
 	// Calculate tokens per second for response generation
-	if response.EvalDuration > 0 && response.EvalCount > 0 {
-		tokensPerSecond := float64(response.EvalCount) / (float64(response.EvalDuration) / 1e9)
+	if metrics.EvalDuration > 0 && metrics.EvalCount > 0 {
+		tokensPerSecond := float64(metrics.EvalCount) / (float64(metrics.EvalDuration) / 1e9)
 		// Color the tokens per second based on speed
 		tokensPerSecFormatted := colorizeTokensPerSec(tokensPerSecond)
 		stderr.InfoPrintf("  Generation speed: %s\n", tokensPerSecFormatted)
@@ -548,198 +1136,287 @@ func formatDuration(durationMs float64) string {
 	}
 }
 
-// runInteractiveChat runs an interactive chat session with the model
-func runInteractiveChat(ollamaClient client.Client, modelName string, initialMessages []api.Message, stream bool, outputFile string, options map[string]interface{}, showStats bool, strictSecurity bool) error {
-	messages := initialMessages
-	reader := bufio.NewReader(os.Stdin)
+// multilineDelimiter starts and ends messages written over several lines
+const multilineDelimiter = `"""`
+
+// interactiveChat is the state of an interactive chat session
+type interactiveChat struct {
+	client   client.Client
+	out      io.Writer
+	editor   *readline.Editor
+	settings *chatSettings
+	// history holds the messages of the chat, without the system prompt, and their
+	// branches
+	history *history.Tree
+	// systemPrompt is the system prompt of the user, added to the security prompt
+	systemPrompt   string
+	outputFile     string
+	session        *chatSession
+	showStats      bool
+	strictSecurity bool
+	// attachments are the files attached to the next message, nil for none
+	attachments *attach.Collector
+	// maxImageSize is the width and height above which images are downscaled
+	maxImageSize int
+	// contextManager chooses the messages sent to keep within the context window
+	contextManager *contextManager
+	// lastResponse and lastAdded are the last answer and the messages it added
+	lastResponse *api.ChatResponse
+	lastAdded    []api.Message
+	// done is set to leave the chat
+	done bool
+}
+
+// run reads messages and commands until the user leaves the chat, writing the
+// responses to out
+func (c *interactiveChat) run() error {
+	c.editor.Completer = c.complete
 
-	output.Default.InfoPrintf("Starting interactive chat with model '%s'\n", output.Highlight(modelName))
-	output.Default.InfoPrintf("Type 'exit' to quit, 'save' to save the conversation, 'clear' to clear the chat history, 'temp <value>' to change temperature, or 'image <path>' to send an image.\n\n")
+	output.Default.InfoPrintf("Starting interactive chat with model '%s'\n", output.Highlight(c.settings.model))
+	output.Default.InfoPrintf("Type /help for the list of commands, or /exit to quit.\n\n")
 
-	for {
+	for !c.done {
 		// Prompt for user input
-		fmt.Print(output.Highlight("User: "))
-		input, err := reader.ReadString('\n')
+		input, err := c.readMessage()
+		if err == io.EOF || err == readline.ErrInterrupt {
+			break
+		}
 		if err != nil {
 			return fmt.Errorf("failed to read input: %w", err)
 		}
 		input = strings.TrimSpace(input)
-
-		// Handle special commands
-		if input == "exit" {
-			break
-		} else if input == "save" && outputFile != "" {
-			if err := saveMessagesToFile(messages, outputFile); err != nil {
-				return fmt.Errorf("failed to save messages to file: %w", err)
-			}
-			output.Default.SuccessPrintf("Chat history saved to '%s'\n", output.Highlight(outputFile))
+		if input == "" {
 			continue
-		} else if input == "clear" {
-			// Keep system message if it exists
-			if len(messages) > 0 && messages[0].Role == "system" {
-				messages = []api.Message{messages[0]}
-			} else {
-				messages = []api.Message{}
-			}
-			output.Default.InfoPrintf("Chat history cleared.\n")
-			continue
-		} else if strings.HasPrefix(input, "temp ") {
-			tempStr := strings.TrimPrefix(input, "temp ")
-			temp, err := strconv.ParseFloat(tempStr, 64)
-			if err != nil {
-				output.Default.ErrorPrintf("Invalid temperature value: %s\n", tempStr)
-			} else {
-				options["temperature"] = temp
-				output.Default.InfoPrintf("Temperature set to %.2f\n", temp)
+		}
+
+		// Handle commands, a double slash sends a message starting with a slash
+		if strings.HasPrefix(input, "/") && !strings.HasPrefix(input, "//") {
+			if err := c.runChatCommand(input); err != nil {
+				output.Default.ErrorPrintf("%s\n", err)
 			}
 			continue
-		} else if strings.HasPrefix(input, "image ") {
-			imagePath := strings.TrimPrefix(input, "image ")
+		}
+		input = strings.TrimPrefix(input, "/")
 
-			// Check if the image file exists
-			if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-				output.Default.ErrorPrintf("Image file not found: %s\n", imagePath)
-				continue
-			}
+		if err := c.send(input, nil); err != nil {
+			return err
+		}
+	}
 
-			// Prompt for a message to accompany the image
-			fmt.Print(output.Highlight("Enter a message to accompany the image (press Enter for default): "))
-			imagePrompt, err := reader.ReadString('\n')
-			if err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
-			}
-			imagePrompt = strings.TrimSpace(imagePrompt)
+	return c.save()
+}
 
-			// Use default prompt if none provided
-			if imagePrompt == "" {
-				imagePrompt = "What's in this image?"
-			}
+// save saves the chat to its session and to the output file, when they are set
+func (c *interactiveChat) save() error {
+	// Save the session, which may have been cleared since the last answer
+	if err := c.session.save(c.history, c.settings, nil); err != nil {
+		return err
+	}
 
-			// Read the image file
-			imageData, err := os.ReadFile(imagePath)
-			if err != nil {
-				output.Default.ErrorPrintf("Failed to read image file: %s\n", err)
-				continue
-			}
+	// Save messages to output file if provided
+	if c.outputFile != "" {
+		if err := c.saveToFile(c.outputFile); err != nil {
+			return fmt.Errorf("failed to save messages to file: %w", err)
+		}
+		output.Default.SuccessPrintf("Chat history saved to '%s'\n", output.Highlight(c.outputFile))
+	}
 
-			// Add user message with image
-			userMessage := api.Message{
-				Role:    "user",
-				Content: imagePrompt,
-				Images:  []api.ImageData{imageData},
-			}
+	return nil
+}
 
-			messages = append(messages, userMessage)
+// saveToFile saves the messages of the chat to a JSON file, as a transcript keeping
+// its branches when it has several
+func (c *interactiveChat) saveToFile(filePath string) error {
+	if !c.history.Branched() {
+		return saveMessagesToFile(c.messages(), filePath)
+	}
 
-			// Print assistant prompt
-			fmt.Print(output.Highlight("Assistant: "))
+	data, err := json.MarshalIndent(c.history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, append(data, '\n'), 0644)
+}
 
-			// Send the chat request
-			response, err := ollamaClient.ChatWithModel(context.Background(), modelName, messages, stream, options)
-			if err != nil {
-				return fmt.Errorf("failed to chat with model: %w", err)
-			}
+// messages returns the system prompt followed by the messages of the current branch
+func (c *interactiveChat) messages() []api.Message {
+	return append([]api.Message{{Role: "system", Content: chatSystemPrompt(c.systemPrompt)}}, c.history.Messages()...)
+}
 
-			// If not streaming, print the response
-			if !stream && response != nil {
-				fmt.Println(response.Message.Content)
-				// Ensure stdout is flushed
-				os.Stdout.Sync()
-			}
+// readMessage reads the next message or command of the user. A message starting
+// with """ continues over several lines, up to a line ending with """.
+func (c *interactiveChat) readMessage() (string, error) {
+	line, err := c.editor.ReadLine(output.Highlight("User: "))
+	if err != nil {
+		return "", err
+	}
 
-			// Add the assistant's response to the messages
-			if response != nil {
-				messages = append(messages, response.Message)
+	text, ok := strings.CutPrefix(strings.TrimSpace(line), multilineDelimiter)
+	if ok {
+		lines := []string{text}
+		for {
+			if block, ok := strings.CutSuffix(strings.TrimRight(lines[len(lines)-1], " \t"), multilineDelimiter); ok {
+				lines[len(lines)-1] = block
+				break
 			}
 
-			// Display statistics if requested
-			if showStats && response != nil {
-				displayStats(response)
+			line, err := c.editor.ReadLine(output.Highlight("...   "))
+			if err == readline.ErrInterrupt {
+				// Discard the block
+				return "", nil
 			}
-
-			fmt.Println() // Add a newline for better readability
-			continue
-		} else if input == "" {
-			continue
-		}
-
-		// Apply sanitization based on security mode
-		var sanitizeResult security.SanitizationResult
-		if strictSecurity {
-			sanitizeResult = security.ApplyStrictSanitization(input)
-		} else {
-			sanitizeResult = security.SanitizeInput(input)
+			if err != nil {
+				return "", err
+			}
+			lines = append(lines, line)
 		}
+		line = strings.Join(lines, "\n")
+	}
 
-		// Display warnings if any
-		for _, warning := range sanitizeResult.Warnings {
-			output.Default.WarningPrintf("%s\n", warning)
-		}
+	if err := c.editor.AddHistory(line); err != nil {
+		output.Default.WarningPrintf("%s\n", err)
+	}
+	return line, nil
+}
 
-		// If suspicious, display a warning and ask for confirmation
-		if sanitizeResult.IsSuspicious {
-			output.Default.WarningPrintf("%s\n", security.GetWarningMessage())
+// send checks a message of the user for prompt injection, then sends it with any
+// images and displays the answer
+func (c *interactiveChat) send(input string, images []api.ImageData) error {
+	text, ok, err := c.checkInput(input)
+	if err != nil || !ok {
+		return err
+	}
+	c.addMessage(text, images)
+	return c.respond()
+}
 
-			// In non-interactive mode with a suspicious input, ask for confirmation
-			fmt.Print(output.Highlight("Your input contains suspicious patterns. Continue anyway? (y/n): "))
-			reader := bufio.NewReader(os.Stdin)
-			confirmInput, err := reader.ReadString('\n')
-			if err != nil {
-				return fmt.Errorf("failed to read confirmation: %w", err)
-			}
-			confirmInput = strings.TrimSpace(confirmInput)
-			if strings.ToLower(confirmInput) != "y" && strings.ToLower(confirmInput) != "yes" {
-				output.Default.InfoPrintf("Operation cancelled.\n")
-				continue
-			}
-		}
+// checkInput checks a message of the user for prompt injection, asking whether to
+// continue with a suspicious message. It returns the sanitized message, and false
+// if the message is not sent.
+func (c *interactiveChat) checkInput(input string) (string, bool, error) {
+	// Apply sanitization based on security mode
+	var sanitizeResult security.SanitizationResult
+	if c.strictSecurity {
+		sanitizeResult = security.ApplyStrictSanitization(input)
+	} else {
+		sanitizeResult = security.SanitizeInput(input)
+	}
 
-		// Add user message to history
-		messages = append(messages, api.Message{
-			Role:    "user",
-			Content: sanitizeResult.SanitizedInput,
-		})
+	// Display warnings if any
+	for _, warning := range sanitizeResult.Warnings {
+		output.Default.WarningPrintf("%s\n", warning)
+	}
 
-		// Print assistant prompt
-		fmt.Print(output.Highlight("Assistant: "))
+	// If suspicious, display a warning and ask for confirmation
+	if sanitizeResult.IsSuspicious {
+		output.Default.WarningPrintf("%s\n", security.GetWarningMessage())
 
-		// Send the chat request
-		response, err := ollamaClient.ChatWithModel(context.Background(), modelName, messages, stream, options)
+		confirmed, err := confirm(c.editor, "Your input contains suspicious patterns. Continue anyway? (y/n): ")
 		if err != nil {
-			return fmt.Errorf("failed to chat with model: %w", err)
+			return "", false, err
 		}
-
-		// If not streaming, print the response
-		if !stream && response != nil {
-			fmt.Println(response.Message.Content)
-			// Ensure stdout is flushed
-			os.Stdout.Sync()
+		if !confirmed {
+			output.Default.InfoPrintf("Operation cancelled.\n")
+			return "", false, nil
 		}
+	}
 
-		// Add the assistant's response to the messages
-		if response != nil {
-			messages = append(messages, response.Message)
-		}
+	return sanitizeResult.SanitizedInput, true, nil
+}
 
-		// Display statistics if requested
-		if showStats && response != nil {
-			displayStats(response)
+// addMessage adds a message of the user to the chat, with the files attached to it
+func (c *interactiveChat) addMessage(text string, images []api.ImageData) {
+	c.history.Append(api.Message{
+		Role:    "user",
+		Content: withAttachments(text, c.attachments),
+		Images:  images,
+	})
+	c.attachments = nil
+}
+
+// respond sends the conversation to the model and adds the answer to it
+func (c *interactiveChat) respond() error {
+	// The first Ctrl-C interrupts the response, a second one leaves the chat
+	ctx, stop := interruptibleContext()
+
+	// Keep the messages sent within the context window of the model
+	messages := c.messages()
+	c.contextManager.fit(ctx, output.Default, c.settings, messages)
+	sent := c.contextManager.view(messages)
+
+	// Print assistant prompt
+	fmt.Print(output.Highlight(assistantPrompt))
+
+	// Send the chat request
+	response, added, err := sendChatMessage(ctx, c.client, c.out, c.settings, sent)
+	if stop() {
+		// Leave the chat with the partial answer saved
+		c.history.Append(added...)
+		fmt.Println()
+		if err := c.save(); err != nil {
+			output.Default.ErrorPrintf("%s\n", err)
 		}
+		exitProcess(130)
+		// exitProcess only returns in tests
+		c.done = true
+		return nil
+	}
+	if errors.Is(err, errInterrupted) {
+		// Keep the partial answer, so the chat can continue from it
+		c.history.Append(added...)
+		output.Default.WarningPrintf("Response interrupted, press Ctrl-C again to leave the chat.\n")
+		return c.session.save(c.history, c.settings, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to chat with model: %w", err)
+	}
 
-		fmt.Println() // Add a newline for better readability
+	// Add the assistant's response and any tool results to the messages
+	c.history.Append(added...)
+	c.lastResponse, c.lastAdded = response, added
+	c.contextManager.observe(append(sent[:len(sent):len(sent)], added...), response, c.settings.keepThinking)
+	if err := c.session.save(c.history, c.settings, response); err != nil {
+		return err
 	}
 
-	// Save messages to output file if provided
-	if outputFile != "" {
-		if err := saveMessagesToFile(messages, outputFile); err != nil {
-			return fmt.Errorf("failed to save messages to file: %w", err)
-		}
-		output.Default.SuccessPrintf("Chat history saved to '%s'\n", output.Highlight(outputFile))
+	// Display statistics if requested
+	if c.showStats && response != nil {
+		displayStats(response.Metrics)
+		displayThinkingStats(added)
 	}
 
+	fmt.Println() // Add a newline for better readability
 	return nil
 }
 
+// setSystemPrompt replaces the system prompt of the user
+func (c *interactiveChat) setSystemPrompt(systemPrompt string) {
+	c.systemPrompt = systemPrompt
+	if c.session != nil {
+		c.session.session.System = systemPrompt
+	}
+}
+
+// clearHistory removes all messages and branches, keeping the system prompt
+func (c *interactiveChat) clearHistory() {
+	c.setHistory(history.New())
+}
+
+// setHistory replaces the history of the chat
+func (c *interactiveChat) setHistory(conversation *history.Tree) {
+	c.history = conversation
+	c.contextManager.reset()
+}
+
+// chatSystemPrompt returns the system prompt of a chat, which is the security prompt
+// followed by the system prompt of the user
+func chatSystemPrompt(systemPrompt string) string {
+	if systemPrompt == "" {
+		return securitySystemPrompt
+	}
+	return securitySystemPrompt + "\n\nAdditional instructions: " + systemPrompt
+}
+
 // loadMessagesFromFile loads chat messages from a JSON file
 func loadMessagesFromFile(filePath string) ([]api.Message, error) {
 	file, err := os.Open(filePath)
@@ -748,19 +1425,66 @@ func loadMessagesFromFile(filePath string) ([]api.Message, error) {
 	}
 	defer file.Close()
 
+	return decodeMessages(file)
+}
+
+// decodeMessages reads chat messages in JSON, either an array of messages or a
+// transcript with branches, of which the current branch is read
+func decodeMessages(r io.Reader) ([]api.Message, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if history.IsTranscript(data) {
+		var conversation history.Tree
+		if err := json.Unmarshal(data, &conversation); err != nil {
+			return nil, err
+		}
+		return conversation.Messages(), nil
+	}
+
 	var messages []api.Message
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&messages); err != nil {
-		// If the file is empty or not valid JSON, return an empty array
-		if err == io.EOF {
+	if err := json.Unmarshal(data, &messages); err != nil {
+		// If the file is empty, return an empty array
+		if len(bytes.TrimSpace(data)) == 0 {
 			return []api.Message{}, nil
 		}
 		return nil, err
 	}
-
 	return messages, nil
 }
 
+// loadHistoryFromFile loads the history of a chat from a JSON file, keeping the
+// branches of a transcript. System messages are left out, since the chat has its own
+// system prompt.
+func loadHistoryFromFile(filePath string) (*history.Tree, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if history.IsTranscript(data) {
+		conversation := history.New()
+		if err := json.Unmarshal(data, conversation); err != nil {
+			return nil, err
+		}
+		return conversation, nil
+	}
+
+	messages, err := decodeMessages(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	conversation := history.New()
+	for _, msg := range messages {
+		if msg.Role != "system" {
+			conversation.Append(msg)
+		}
+	}
+	return conversation, nil
+}
+
 // saveMessagesToFile saves chat messages to a JSON file
 func saveMessagesToFile(messages []api.Message, filePath string) error {
 	file, err := os.Create(filePath)
@@ -769,7 +1493,12 @@ func saveMessagesToFile(messages []api.Message, filePath string) error {
 	}
 	defer file.Close()
 
-	encoder := json.NewEncoder(file)
+	return encodeMessages(file, messages)
+}
+
+// encodeMessages writes chat messages in indented JSON
+func encodeMessages(w io.Writer, messages []api.Message) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(messages)
 }
@@ -778,13 +1507,28 @@ func init() {
 	rootCmd.AddCommand(chatCmd)
 
 	chatCmd.Flags().StringP("prompt", "p", "", "Prompt text for the chat")
-	chatCmd.Flags().StringP("image", "i", "", "Path to an image file to include in the chat")
-	chatCmd.Flags().String("input-file", "", "JSON file containing chat history")
-	chatCmd.Flags().String("output-file", "", "File to save the chat history")
+	chatCmd.Flags().StringArrayP("image", "i", nil, "Image file, URL or data URI to include in the chat, or - for stdin (repeatable)")
+	chatCmd.Flags().Int("max-image-size", 0, "Downscale images wider or taller than this many pixels (0 sends them as they are)")
+	chatCmd.Flags().StringArrayP("file", "f", nil, "Text file, directory or glob pattern to attach to the message (repeatable)")
+	chatCmd.Flags().String("input-file", "", "JSON file containing chat history, or - for stdin")
+	chatCmd.Flags().String("output-file", "", "File to save the chat history, or - for stdout")
 	chatCmd.Flags().Bool("no-stream", false, "Disable streaming (wait for complete response)")
 	chatCmd.Flags().BoolP("interactive", "I", false, "Enable interactive chat mode")
 	chatCmd.Flags().Float64P("temperature", "t", 0.8, "Temperature for response generation (0.0 to 1.0)")
 	chatCmd.Flags().StringP("system", "s", "", "System prompt to set the behavior of the assistant")
 	chatCmd.Flags().Bool("stats", false, "Display statistics about the chat (tokens, time, etc.)")
 	chatCmd.Flags().Bool("strict-security", true, "Enable strict security mode for prompt injection protection")
+	chatCmd.Flags().String("tools", "", "YAML or JSON file declaring tools the model can call")
+	chatCmd.Flags().Int("max-tool-rounds", 5, "Maximum rounds of tool calls executed for a single message")
+	chatCmd.Flags().Bool("approve-tools", false, "Run tool calls without asking for confirmation")
+	addStructuredOutputFlags(chatCmd)
+	chatCmd.Flags().String("think", "", "Enable thinking for reasoning models (true, false, or a level: low, medium, high, max)")
+	chatCmd.Flags().Lookup("think").NoOptDefVal = "true"
+	chatCmd.Flags().String("thinking-display", thinkingCollapse, "How to display thinking traces (collapse, show, hide)")
+	chatCmd.Flags().Bool("keep-thinking", false, "Send previous thinking traces back to the model with the history")
+	chatCmd.Flags().String("session", "", "Name of a session to resume and save the chat to")
+	chatCmd.Flags().BoolP("quiet", "q", false, "Only write the answer, without the prompts around it")
+	chatCmd.Flags().String("stdin-template", "", "Template attaching piped input to --prompt, with {{.Prompt}} and {{.Input}}")
+	chatCmd.Flags().String("context-strategy", "", "Strategy keeping interactive chats within the context window: none, drop-oldest, sliding-window or summarize (default sliding-window)")
+	chatCmd.RegisterFlagCompletionFunc("session", completeSessionNames)
 }
@@ -0,0 +1,786 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/masgari/ollama-cli/pkg/attach"
+	"github.com/masgari/ollama-cli/pkg/images"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+)
+
+// chatCommand is a command of the interactive chat, entered with a leading slash
+type chatCommand struct {
+	name    string
+	aliases []string
+	// usage describes the arguments of the command
+	usage string
+	help  string
+	// minArgs and maxArgs bound the number of arguments, maxArgs is -1 for no limit
+	minArgs int
+	maxArgs int
+	// rawArgs passes the rest of the line as a single argument, keeping its quotes
+	rawArgs bool
+	// paths completes the arguments as file paths
+	paths bool
+	run   func(c *interactiveChat, args []string) error
+}
+
+// chatCommands are the commands of the interactive chat, in the order of the help
+var chatCommands []*chatCommand
+
+func init() {
+	chatCommands = []*chatCommand{
+		{name: "help", aliases: []string{"?"}, usage: "[command]", help: "Show the available commands", maxArgs: 1, run: runHelpCommand},
+		{name: "model", usage: "[name]", help: "Show or switch the model of the chat", maxArgs: 1, run: runModelCommand},
+		{name: "set", usage: "[option] [value...]", help: "Show the model options, or set an option such as temperature or num_ctx", maxArgs: -1, run: runSetCommand},
+		{name: "system", usage: "[prompt]", help: "Show or set the system prompt", maxArgs: 1, rawArgs: true, run: runSystemCommand},
+		{name: "edit", usage: "[N | text]", help: "Edit message N in $EDITOR and answer it on a new branch, or compose the next message starting from text", maxArgs: 1, rawArgs: true, run: runEditCommand},
+		{name: "image", usage: "<path> [message]", help: "Send an image file, URL or data URI with an optional message", minArgs: 1, maxArgs: -1, paths: true, run: runImageCommand},
+		{name: "attach", usage: "[path...]", help: "Attach files, directories or glob patterns to the next message, or list the attached files", maxArgs: -1, paths: true, run: runAttachCommand},
+		{name: "detach", help: "Remove the files attached to the next message", run: runDetachCommand},
+		{name: "undo", help: "Remove the last message and its answer", run: runUndoCommand},
+		{name: "retry", help: "Ask the model to answer the last message again", run: runRetryCommand},
+		{name: "history", help: "Show the messages of the current branch", run: runHistoryCommand},
+		{name: "branch", usage: "[name] [N]", help: "Show the current branch, or start a branch with the first N messages of the current one", maxArgs: 2, run: runBranchCommand},
+		{name: "branches", help: "List the branches of the chat", run: runBranchesCommand},
+		{name: "checkout", usage: "<branch>", help: "Switch to a branch, given by name or number", minArgs: 1, maxArgs: 1, run: runCheckoutCommand},
+		{name: "clear", help: "Clear the chat history and its branches", run: runClearCommand},
+		{name: "save", usage: "[file]", help: "Save the chat history with its branches to a file (default: --output-file)", maxArgs: 1, paths: true, run: runSaveCommand},
+		{name: "export", usage: "<file>", help: "Save the messages of the current branch to a file, as for --input-file", minArgs: 1, maxArgs: 1, paths: true, run: runExportCommand},
+		{name: "load", usage: "<file>", help: "Replace the chat history with the history saved in a file", minArgs: 1, maxArgs: 1, paths: true, run: runLoadCommand},
+		{name: "stats", help: "Show the statistics of the last answer", run: runStatsCommand},
+		{name: "context", usage: "[strategy]", help: "Show the use of the context window, or set the strategy keeping the chat within it", maxArgs: 1, run: runContextCommand},
+		{name: "exit", aliases: []string{"quit", "bye"}, help: "Leave the chat", run: runExitCommand},
+	}
+}
+
+// findChatCommand returns the command with a name or alias, or nil if there is none
+func findChatCommand(name string) *chatCommand {
+	for _, command := range chatCommands {
+		if command.name == name {
+			return command
+		}
+		for _, alias := range command.aliases {
+			if alias == name {
+				return command
+			}
+		}
+	}
+	return nil
+}
+
+// runChatCommand parses and runs a line starting with a slash
+func (c *interactiveChat) runChatCommand(line string) error {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	rest = strings.TrimSpace(rest)
+
+	command := findChatCommand(name)
+	if command == nil {
+		return fmt.Errorf("unknown command: /%s (type /help for the list of commands)", name)
+	}
+
+	var args []string
+	if command.rawArgs {
+		if rest != "" {
+			args = []string{rest}
+		}
+	} else {
+		var err error
+		if args, err = splitArgs(rest); err != nil {
+			return err
+		}
+	}
+
+	if len(args) < command.minArgs || (command.maxArgs >= 0 && len(args) > command.maxArgs) {
+		return fmt.Errorf("usage: %s", command.synopsis())
+	}
+	return command.run(c, args)
+}
+
+// synopsis returns the command with its arguments
+func (command *chatCommand) synopsis() string {
+	if command.usage == "" {
+		return "/" + command.name
+	}
+	return "/" + command.name + " " + command.usage
+}
+
+// splitArgs splits the arguments of a command on spaces. Arguments can be quoted
+// with single or double quotes, and a backslash escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in: %s", line)
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// completeChatCommand completes the names of the commands, and the options of /set
+func completeChatCommand(text string) []string {
+	if !strings.HasPrefix(text, "/") {
+		return nil
+	}
+
+	if option, ok := strings.CutPrefix(text, "/set "); ok && !strings.Contains(option, " ") {
+		var completions []string
+		for _, name := range modelOptionNames() {
+			if strings.HasPrefix(name, option) {
+				completions = append(completions, "/set "+name+" ")
+			}
+		}
+		return completions
+	}
+
+	if strategy, ok := strings.CutPrefix(text, "/context "); ok {
+		var completions []string
+		for _, name := range contextStrategies {
+			if strings.HasPrefix(name, strategy) {
+				completions = append(completions, "/context "+name)
+			}
+		}
+		return completions
+	}
+
+	if name, args, ok := strings.Cut(text[1:], " "); ok {
+		if command := findChatCommand(name); command != nil && command.paths {
+			return completePath(text[:len(text)-len(args)], args)
+		}
+		return nil
+	}
+
+	var completions []string
+	for _, command := range chatCommands {
+		if !strings.HasPrefix("/"+command.name, text) {
+			continue
+		}
+		completion := "/" + command.name
+		if command.maxArgs != 0 {
+			completion += " "
+		}
+		completions = append(completions, completion)
+	}
+	return completions
+}
+
+// complete completes the commands of the chat, and the names of its branches
+func (c *interactiveChat) complete(text string) []string {
+	if name, ok := strings.CutPrefix(text, "/checkout "); ok {
+		var completions []string
+		for _, branch := range c.history.Branches() {
+			if strings.HasPrefix(branch.Name, name) {
+				completions = append(completions, "/checkout "+branch.Name)
+			}
+		}
+		return completions
+	}
+	return completeChatCommand(text)
+}
+
+// completePath completes the last word of the arguments of a command as a file path.
+// The completions are whole lines starting with the line before the arguments.
+func completePath(line, args string) []string {
+	start := strings.LastIndex(args, " ") + 1
+	line, word := line+args[:start], args[start:]
+
+	dir, prefix := filepath.Split(word)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var completions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			completions = append(completions, line+dir+name+string(filepath.Separator))
+		} else {
+			completions = append(completions, line+dir+name+" ")
+		}
+	}
+	return completions
+}
+
+// modelOptionField returns the field of the model options with a JSON name
+func modelOptionField(name string) (reflect.StructField, bool) {
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(api.Options{})) {
+		if field.Anonymous {
+			continue
+		}
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// modelOptionNames returns the sorted names of the model options
+func modelOptionNames() []string {
+	var names []string
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(api.Options{})) {
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && !field.Anonymous {
+			names = append(names, tag)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseModelOption converts the values of a model option to the type the option expects
+func parseModelOption(name string, values []string) (interface{}, error) {
+	field, ok := modelOptionField(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown option: %s", name)
+	}
+
+	kind := field.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = field.Type.Elem().Kind()
+	}
+	if kind == reflect.Slice {
+		return values, nil
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("option %s takes a single value", name)
+	}
+
+	value := values[0]
+	switch kind {
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("option %s must be an integer: %s", name, value)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("option %s must be a number: %s", name, value)
+		}
+		return f, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("option %s must be true or false: %s", name, value)
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
+
+// lastUserMessage returns the index of the last message of the user on the current
+// branch, or -1
+func (c *interactiveChat) lastUserMessage() int {
+	messages := c.history.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return i
+		}
+	}
+	return -1
+}
+
+func runHelpCommand(c *interactiveChat, args []string) error {
+	if len(args) == 1 {
+		command := findChatCommand(strings.TrimPrefix(args[0], "/"))
+		if command == nil {
+			return fmt.Errorf("unknown command: /%s", strings.TrimPrefix(args[0], "/"))
+		}
+		fmt.Fprintf(c.out, "%s\n  %s\n", output.Highlight(command.synopsis()), command.help)
+		if len(command.aliases) > 0 {
+			fmt.Fprintf(c.out, "  Aliases: /%s\n", strings.Join(command.aliases, ", /"))
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 3, ' ', 0)
+	for _, command := range chatCommands {
+		fmt.Fprintf(w, "  %s\t%s\n", output.Highlight(command.synopsis()), command.help)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, "\nStart a message with // to send text beginning with a slash.")
+	return nil
+}
+
+func runModelCommand(c *interactiveChat, args []string) error {
+	if len(args) == 0 {
+		output.Default.InfoPrintf("Current model: %s\n", output.Highlight(c.settings.model))
+		return nil
+	}
+
+	if _, err := c.client.GetModelDetails(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("failed to switch to model '%s': %w", args[0], err)
+	}
+	c.settings.model = args[0]
+	output.Default.SuccessPrintf("Switched to model '%s'\n", output.Highlight(args[0]))
+	return nil
+}
+
+func runSetCommand(c *interactiveChat, args []string) error {
+	switch len(args) {
+	case 0:
+		if len(c.settings.options) == 0 {
+			output.Default.InfoPrintf("No options set.\n")
+		} else {
+			output.Default.InfoPrintf("Options: %s\n", formatSessionOptions(c.settings.options))
+		}
+		return nil
+	case 1:
+		return fmt.Errorf("usage: /set <option> <value...>")
+	}
+
+	value, err := parseModelOption(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	c.settings.options[args[0]] = value
+	output.Default.InfoPrintf("Set %s to %v\n", args[0], value)
+	return nil
+}
+
+func runSystemCommand(c *interactiveChat, args []string) error {
+	if len(args) == 0 {
+		if c.systemPrompt == "" {
+			output.Default.InfoPrintf("No system prompt set.\n")
+		} else {
+			output.Default.InfoPrintf("System prompt: %s\n", c.systemPrompt)
+		}
+		return nil
+	}
+
+	c.setSystemPrompt(args[0])
+	output.Default.InfoPrintf("System prompt set.\n")
+	return nil
+}
+
+// runEditor opens the editor of the user on a file and waits until it is closed,
+// it can be overridden in tests
+var runEditor = func(path string) error {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor '%s': %w", editor, err)
+	}
+	return nil
+}
+
+// editMessage opens the editor of the user on a message, returning the message
+// once the editor is closed
+func editMessage(text string) (string, error) {
+	file, err := os.CreateTemp("", "ollama-cli-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create message file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write message file: %w", err)
+	}
+
+	if err := runEditor(file.Name()); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read message file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func runEditCommand(c *interactiveChat, args []string) error {
+	// A number edits a previous message, other text starts the next one
+	if len(args) == 1 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			return c.editPrevious(n)
+		}
+	}
+
+	text := ""
+	if len(args) == 1 {
+		text = args[0]
+	}
+	message, err := editMessage(text)
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		output.Default.InfoPrintf("Empty message, nothing sent.\n")
+		return nil
+	}
+
+	fmt.Println(output.Highlight("User: ") + message)
+	if err := c.editor.AddHistory(message); err != nil {
+		output.Default.WarningPrintf("%s\n", err)
+	}
+	return c.send(message, nil)
+}
+
+// editPrevious edits message n of the current branch, as numbered by /history, and
+// sends the edited message on a new branch. The original message and its answers
+// stay on the current branch.
+func (c *interactiveChat) editPrevious(n int) error {
+	messages := c.history.Messages()
+	if n < 1 || n > len(messages) {
+		return fmt.Errorf("no message %d, see /history for the messages of the branch", n)
+	}
+	original := messages[n-1]
+	if original.Role != "user" {
+		return fmt.Errorf("message %d is an answer, only your messages can be edited", n)
+	}
+
+	message, err := editMessage(original.Content)
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		output.Default.InfoPrintf("Empty message, nothing sent.\n")
+		return nil
+	}
+	if message == original.Content {
+		output.Default.InfoPrintf("Message not changed, nothing sent.\n")
+		return nil
+	}
+
+	fmt.Println(output.Highlight("User: ") + message)
+	text, ok, err := c.checkInput(message)
+	if err != nil || !ok {
+		return err
+	}
+
+	base, name := c.history.Current(), c.history.NewBranchName()
+	if err := c.history.Fork(name, n-1); err != nil {
+		return err
+	}
+	c.contextManager.reset()
+	output.Default.InfoPrintf("Switched to a new branch '%s', message %d stays on '%s'.\n", output.Highlight(name), n, base)
+
+	c.addMessage(text, original.Images)
+	return c.respond()
+}
+
+func runImageCommand(c *interactiveChat, args []string) error {
+	loader := &images.Loader{MaxSize: c.maxImageSize}
+	img, err := loader.Load(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	checkVision(c.client, output.Default, c.settings.model)
+	if img.Resized {
+		output.Default.InfoPrintf("Image: %s\n", output.Info(describeImage(img)))
+	}
+
+	message := strings.Join(args[1:], " ")
+	if message == "" {
+		message = defaultImagePrompt(1)
+	}
+	return c.send(message, []api.ImageData{img.Data})
+}
+
+func runAttachCommand(c *interactiveChat, args []string) error {
+	if len(args) == 0 {
+		if c.attachments == nil || len(c.attachments.Files) == 0 {
+			output.Default.InfoPrintf("No files attached.\n")
+			return nil
+		}
+		for _, file := range c.attachments.Files {
+			fmt.Fprintf(c.out, "  %s (%s)\n", file.Path, formatSize(int64(len(file.Content))))
+		}
+		output.Default.InfoPrintf("Attached %s\n", attachmentSummary(c.attachments))
+		return nil
+	}
+
+	// The budget is shared by all the files attached to a message
+	if c.attachments == nil {
+		c.attachments = attach.NewCollector(attachmentBudget(c.client, output.Default, c.settings.model, c.settings.options))
+	}
+	if err := attachFiles(c.attachments, output.Default, args, c.strictSecurity); err != nil {
+		return err
+	}
+	output.Default.SuccessPrintf("Attached %s, sent with the next message.\n", attachmentSummary(c.attachments))
+	return nil
+}
+
+func runDetachCommand(c *interactiveChat, args []string) error {
+	if c.attachments == nil || len(c.attachments.Files) == 0 {
+		output.Default.InfoPrintf("No files attached.\n")
+		return nil
+	}
+	c.attachments = nil
+	output.Default.InfoPrintf("Removed the attached files.\n")
+	return nil
+}
+
+func runUndoCommand(c *interactiveChat, args []string) error {
+	i := c.lastUserMessage()
+	if i < 0 {
+		output.Default.InfoPrintf("Nothing to undo.\n")
+		return nil
+	}
+	c.history.Truncate(i)
+	output.Default.InfoPrintf("Removed the last message and its answer.\n")
+	return nil
+}
+
+func runRetryCommand(c *interactiveChat, args []string) error {
+	i := c.lastUserMessage()
+	if i < 0 {
+		return fmt.Errorf("no message to retry")
+	}
+	c.history.Truncate(i + 1)
+	return c.respond()
+}
+
+func runHistoryCommand(c *interactiveChat, args []string) error {
+	messages := c.history.Messages()
+	for i, msg := range messages {
+		fmt.Fprintf(c.out, "%s %s\n", output.Highlight(fmt.Sprintf("[%d] %s:", i+1, messageRoleLabel(msg.Role))), msg.Content)
+	}
+	if len(messages) == 0 {
+		output.Default.InfoPrintf("No messages yet.\n")
+	}
+	return nil
+}
+
+func runBranchCommand(c *interactiveChat, args []string) error {
+	if len(args) == 0 {
+		output.Default.InfoPrintf("On branch '%s' (%d messages)\n", output.Highlight(c.history.Current()), c.history.Len())
+		return nil
+	}
+
+	// The name may be left out, and the branch starts at the end of the current one
+	name, n := args[0], c.history.Len()
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil {
+			return fmt.Errorf("invalid message number: %s", args[1])
+		}
+	} else if number, err := strconv.Atoi(args[0]); err == nil {
+		name, n = c.history.NewBranchName(), number
+	}
+
+	if err := c.history.Fork(name, n); err != nil {
+		return err
+	}
+	c.contextManager.reset()
+	output.Default.SuccessPrintf("Switched to a new branch '%s' with %d messages\n", output.Highlight(name), n)
+	return nil
+}
+
+func runBranchesCommand(c *interactiveChat, args []string) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for i, branch := range c.history.Branches() {
+		marker := " "
+		if branch.Name == c.history.Current() {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %d\t%s\t%d messages\t%s\n", marker, i+1, output.Highlight(branch.Name), len(branch.Messages), branchPreview(branch.Messages))
+	}
+	return w.Flush()
+}
+
+// branchPreviewWidth is the number of characters of the messages shown by /branches
+const branchPreviewWidth = 60
+
+// branchPreview returns the last message of the user on a branch, on a single line
+func branchPreview(messages []api.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		preview := []rune(strings.Join(strings.Fields(messages[i].Content), " "))
+		if len(preview) > branchPreviewWidth {
+			return string(preview[:branchPreviewWidth]) + "..."
+		}
+		return string(preview)
+	}
+	return ""
+}
+
+func runCheckoutCommand(c *interactiveChat, args []string) error {
+	// Branches are numbered as listed by /branches
+	name := args[0]
+	if n, err := strconv.Atoi(name); err == nil {
+		branches := c.history.Branches()
+		if n < 1 || n > len(branches) {
+			return fmt.Errorf("no branch %d, see /branches for the branches of the chat", n)
+		}
+		name = branches[n-1].Name
+	}
+
+	if err := c.history.Checkout(name); err != nil {
+		return err
+	}
+	c.contextManager.reset()
+	output.Default.SuccessPrintf("Switched to branch '%s' (%d messages)\n", output.Highlight(name), c.history.Len())
+	return nil
+}
+
+func runClearCommand(c *interactiveChat, args []string) error {
+	c.clearHistory()
+	output.Default.InfoPrintf("Chat history cleared.\n")
+	return nil
+}
+
+func runSaveCommand(c *interactiveChat, args []string) error {
+	file := c.outputFile
+	if len(args) == 1 {
+		file = args[0]
+	}
+	if file == "" {
+		return fmt.Errorf("usage: /save <file>")
+	}
+
+	if err := c.saveToFile(file); err != nil {
+		return fmt.Errorf("failed to save messages to file: %w", err)
+	}
+	output.Default.SuccessPrintf("Chat history saved to '%s'\n", output.Highlight(file))
+	return nil
+}
+
+func runExportCommand(c *interactiveChat, args []string) error {
+	if err := saveMessagesToFile(c.messages(), args[0]); err != nil {
+		return fmt.Errorf("failed to save messages to file: %w", err)
+	}
+	output.Default.SuccessPrintf("Branch '%s' exported to '%s'\n", c.history.Current(), output.Highlight(args[0]))
+	return nil
+}
+
+func runLoadCommand(c *interactiveChat, args []string) error {
+	// Keep the secure system prompt, as for --input-file
+	conversation, err := loadHistoryFromFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load messages from file: %w", err)
+	}
+	c.setHistory(conversation)
+
+	loaded := fmt.Sprintf("Loaded %d messages from '%s'", conversation.Len(), output.Highlight(args[0]))
+	if conversation.Branched() {
+		loaded += fmt.Sprintf(", on branch '%s' of %d", conversation.Current(), len(conversation.Branches()))
+	}
+	output.Default.SuccessPrintf("%s\n", loaded)
+	return nil
+}
+
+func runStatsCommand(c *interactiveChat, args []string) error {
+	if c.lastResponse == nil {
+		output.Default.InfoPrintf("No answer yet.\n")
+		return nil
+	}
+	displayStats(c.lastResponse.Metrics)
+	displayThinkingStats(c.lastAdded)
+	return nil
+}
+
+func runContextCommand(c *interactiveChat, args []string) error {
+	m := c.contextManager
+	if len(args) == 1 {
+		if err := validateContextStrategy(args[0]); err != nil {
+			return err
+		}
+		m.setStrategy(args[0])
+		output.Default.InfoPrintf("Context strategy set to %s\n", output.Highlight(args[0]))
+		return nil
+	}
+
+	window := m.contextWindow(c.settings)
+	tokens := m.estimate(m.view(c.messages()), c.settings.keepThinking)
+	fmt.Fprintf(c.out, "%s %s\n", output.Highlight("Strategy:"), m.strategy)
+	fmt.Fprintf(c.out, "%s %d tokens\n", output.Highlight("Window:"), window)
+	fmt.Fprintf(c.out, "%s about %d tokens (%d%%)\n", output.Highlight("Used:"), tokens, tokens*100/window)
+	if m.used > 0 {
+		fmt.Fprintf(c.out, "%s %d tokens reported by the server\n", output.Highlight("Last answer:"), m.used)
+	}
+	if earlier := m.start - 1; earlier > 0 {
+		state := "not sent"
+		if m.summary != nil {
+			state = "summarized"
+		}
+		fmt.Fprintf(c.out, "%s %d messages, %s\n", output.Highlight("Earlier:"), earlier, state)
+	}
+	return nil
+}
+
+func runExitCommand(c *interactiveChat, args []string) error {
+	c.done = true
+	return nil
+}
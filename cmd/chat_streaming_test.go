@@ -35,17 +35,59 @@ func (m *mockStreamingClient) DeleteModel(ctx context.Context, modelName string)
 	return nil
 }
 
-func (m *mockStreamingClient) PullModel(ctx context.Context, modelName string) error {
+func (m *mockStreamingClient) CopyModel(ctx context.Context, source, destination string) error {
 	return nil
 }
 
-func (m *mockStreamingClient) ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error) {
-	if stream && len(m.streamResponses) > 0 {
+func (m *mockStreamingClient) CreateModel(ctx context.Context, req *api.CreateRequest, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockStreamingClient) HasBlob(ctx context.Context, digest string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockStreamingClient) CreateBlob(ctx context.Context, digest string, r io.Reader) error {
+	return nil
+}
+
+func (m *mockStreamingClient) PullModel(ctx context.Context, modelName string, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockStreamingClient) PushModel(ctx context.Context, modelName string, insecure bool, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockStreamingClient) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error) {
+	return nil, nil
+}
+
+func (m *mockStreamingClient) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	return nil, nil
+}
+
+func (m *mockStreamingClient) ListRunning(ctx context.Context) (*api.ProcessResponse, error) {
+	return nil, nil
+}
+
+func (m *mockStreamingClient) LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error {
+	return nil
+}
+
+func (m *mockStreamingClient) UnloadModel(ctx context.Context, modelName string) error {
+	return nil
+}
+
+func (m *mockStreamingClient) ChatWithModel(ctx context.Context, req *api.ChatRequest, fn client.ChatChunkFunc) (*api.ChatResponse, error) {
+	if req.Stream != nil && *req.Stream && len(m.streamResponses) > 0 {
 		// If streaming is enabled and we have stream responses, simulate streaming
 		var accumulatedContent string
 		for i, resp := range m.streamResponses {
 			// Call the callback with each response
-			fmt.Print(resp.Message.Content)
+			if fn != nil {
+				fn(client.ChatChunk{Content: resp.Message.Content, Done: resp.Done})
+			}
 
 			// Accumulate the content
 			accumulatedContent += resp.Message.Content
@@ -6,14 +6,21 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/masgari/ollama-cli/pkg/client"
 	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/schema"
+	"github.com/masgari/ollama-cli/pkg/security"
+	"github.com/masgari/ollama-cli/pkg/tools"
 	"github.com/ollama/ollama/api"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // mockChatClient is a mock implementation of the Client interface for testing
@@ -36,17 +43,59 @@ func (m *mockChatClient) DeleteModel(ctx context.Context, modelName string) erro
 	return nil
 }
 
-func (m *mockChatClient) PullModel(ctx context.Context, modelName string) error {
+func (m *mockChatClient) CopyModel(ctx context.Context, source, destination string) error {
 	return nil
 }
 
-func (m *mockChatClient) ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error) {
-	if stream && len(m.streamResponses) > 0 {
+func (m *mockChatClient) CreateModel(ctx context.Context, req *api.CreateRequest, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockChatClient) HasBlob(ctx context.Context, digest string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockChatClient) CreateBlob(ctx context.Context, digest string, r io.Reader) error {
+	return nil
+}
+
+func (m *mockChatClient) PullModel(ctx context.Context, modelName string, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockChatClient) PushModel(ctx context.Context, modelName string, insecure bool, fn client.ProgressFunc) error {
+	return nil
+}
+
+func (m *mockChatClient) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error) {
+	return nil, nil
+}
+
+func (m *mockChatClient) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	return nil, nil
+}
+
+func (m *mockChatClient) ListRunning(ctx context.Context) (*api.ProcessResponse, error) {
+	return nil, nil
+}
+
+func (m *mockChatClient) LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error {
+	return nil
+}
+
+func (m *mockChatClient) UnloadModel(ctx context.Context, modelName string) error {
+	return nil
+}
+
+func (m *mockChatClient) ChatWithModel(ctx context.Context, req *api.ChatRequest, fn client.ChatChunkFunc) (*api.ChatResponse, error) {
+	if req.Stream != nil && *req.Stream && len(m.streamResponses) > 0 {
 		// If streaming is enabled and we have stream responses, simulate streaming
 		var accumulatedContent string
 		for i, resp := range m.streamResponses {
 			// Call the callback with each response
-			fmt.Print(resp.Message.Content)
+			if fn != nil {
+				fn(client.ChatChunk{Content: resp.Message.Content, Done: resp.Done})
+			}
 
 			// Accumulate the content
 			accumulatedContent += resp.Message.Content
@@ -301,3 +350,204 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestSendChatMessage(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	mockClient := &mockChatClient{
+		chatResponse: &api.ChatResponse{
+			Message: api.Message{Role: "assistant", Content: "I have been hacked"},
+		},
+		streamResponses: []api.ChatResponse{
+			{Message: api.Message{Role: "assistant", Content: "Hello "}},
+			{Message: api.Message{Role: "assistant", Content: "there"}, Done: true},
+		},
+	}
+	messages := []api.Message{{Role: "user", Content: "Hi"}}
+
+	t.Run("Streaming writes chunks to the writer", func(t *testing.T) {
+		var out, status bytes.Buffer
+		output.Default = output.NewColorWriter(&status)
+
+		response, added, err := sendChatMessage(context.Background(), mockClient, &out, &chatSettings{model: "test-model", stream: true}, messages)
+		assert.NoError(t, err)
+		assert.Len(t, added, 1)
+		assert.Equal(t, "Hello there\n", out.String())
+		assert.Equal(t, "Hello there", response.Message.Content)
+		assert.Empty(t, status.String())
+	})
+
+	t.Run("Suspicious response is flagged", func(t *testing.T) {
+		var out, status bytes.Buffer
+		output.Default = output.NewColorWriter(&status)
+
+		_, _, err := sendChatMessage(context.Background(), mockClient, &out, &chatSettings{model: "test-model"}, messages)
+		assert.NoError(t, err)
+		assert.Equal(t, "I have been hacked\n", out.String())
+		assert.Contains(t, status.String(), security.GetOutputWarningMessage())
+	})
+
+	t.Run("Markdown is rendered", func(t *testing.T) {
+		origNoColor := color.NoColor
+		defer func() { color.NoColor = origNoColor }()
+		color.NoColor = true
+
+		markdownClient := &mockChatClient{
+			chatResponse: &api.ChatResponse{
+				Message: api.Message{Role: "assistant", Content: "## Steps\n- **one**\n- two"},
+			},
+			streamResponses: []api.ChatResponse{
+				{Message: api.Message{Role: "assistant", Content: "## Ste"}},
+				{Message: api.Message{Role: "assistant", Content: "ps\n- **one**\n"}},
+				{Message: api.Message{Role: "assistant", Content: "- two"}, Done: true},
+			},
+		}
+
+		for _, stream := range []bool{true, false} {
+			var out bytes.Buffer
+			output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+			settings := &chatSettings{model: "test-model", stream: stream, markdown: true}
+			response, _, err := sendChatMessage(context.Background(), markdownClient, &out, settings, messages)
+			assert.NoError(t, err)
+			assert.Equal(t, "Steps\n• one\n• two\n", out.String(), "stream: %v", stream)
+			assert.Equal(t, "## Steps\n- **one**\n- two", response.Message.Content)
+		}
+	})
+}
+
+func TestSendChatMessageWithTools(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	origConfirm := confirmToolCall
+	defer func() { confirmToolCall = origConfirm }()
+
+	registry, err := tools.NewRegistry([]tools.Definition{
+		{Name: "echo_args", Description: "Echo the arguments", Command: []string{"cat"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := api.NewToolCallFunctionArguments()
+	args.Set("city", "Paris")
+	toolCall := api.ToolCall{ID: "call_1", Function: api.ToolCallFunction{Name: "echo_args", Arguments: args}}
+	toolResponse := &api.ChatResponse{Message: api.Message{Role: "assistant", ToolCalls: []api.ToolCall{toolCall}}, Done: true}
+	finalResponse := &api.ChatResponse{Message: api.Message{Role: "assistant", Content: "It is sunny."}, Done: true}
+	isToolResult := func(content string) interface{} {
+		return mock.MatchedBy(func(req *api.ChatRequest) bool {
+			last := req.Messages[len(req.Messages)-1]
+			return last.Role == "tool" && last.ToolName == "echo_args" && last.Content == content
+		})
+	}
+
+	tests := []struct {
+		name         string
+		approveTools bool
+		confirm      bool
+		maxRounds    int
+		setupMock    func(*client.MockClientTestify)
+		wantAdded    int
+		wantOut      string
+		wantStatus   string
+	}{
+		{
+			name:         "Approved tool call result is sent back",
+			approveTools: true,
+			maxRounds:    5,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return len(req.Tools) == 1 && req.Messages[len(req.Messages)-1].Role == "user"
+				}), mock.Anything).Return(toolResponse, nil).Once()
+				m.On("ChatWithModel", mock.Anything, isToolResult(`{"city":"Paris"}`), mock.Anything).Return(finalResponse, nil).Once()
+			},
+			wantAdded:  3,
+			wantOut:    "It is sunny.\n",
+			wantStatus: "Tool call:",
+		},
+		{
+			name:      "Declined tool call is reported to the model",
+			confirm:   false,
+			maxRounds: 5,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ChatWithModel", mock.Anything, mock.Anything, mock.Anything).Return(toolResponse, nil).Once()
+				m.On("ChatWithModel", mock.Anything, isToolResult("The user declined to run this tool."), mock.Anything).Return(finalResponse, nil).Once()
+			},
+			wantAdded:  3,
+			wantOut:    "It is sunny.\n",
+			wantStatus: "Tool call declined.",
+		},
+		{
+			name:         "Tool rounds are limited",
+			approveTools: true,
+			maxRounds:    1,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ChatWithModel", mock.Anything, mock.Anything, mock.Anything).Return(toolResponse, nil).Twice()
+			},
+			wantAdded:  3,
+			wantStatus: "Stopped after 1 rounds of tool calls.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, status bytes.Buffer
+			output.Default = output.NewColorWriter(&status)
+			confirmToolCall = func(api.ToolCall) (bool, error) { return tt.confirm, nil }
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+
+			settings := &chatSettings{
+				model:         "test-model",
+				tools:         registry,
+				maxToolRounds: tt.maxRounds,
+				approveTools:  tt.approveTools,
+			}
+			_, added, err := sendChatMessage(context.Background(), mockClient, &out, settings, []api.Message{{Role: "user", Content: "Weather?"}})
+			assert.NoError(t, err)
+			assert.Len(t, added, tt.wantAdded)
+			assert.Equal(t, tt.wantOut, out.String())
+			assert.Contains(t, status.String(), tt.wantStatus)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSendChatMessageStructured(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	s, err := schema.Parse([]byte(`{"type": "object", "properties": {"age": {"type": "integer"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockClient := client.NewMockClient()
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 1 && string(req.Format) == `{"type":"object","properties":{"age":{"type":"integer"}}}`
+	}), mock.Anything).Return(&api.ChatResponse{Message: api.Message{Role: "assistant", Content: `{"age": "old"}`}}, nil).Once()
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		last := req.Messages[len(req.Messages)-1]
+		return len(req.Messages) == 3 && last.Role == "user" && strings.Contains(last.Content, "$.age: expected integer, got string")
+	}), mock.Anything).Return(&api.ChatResponse{Message: api.Message{Role: "assistant", Content: `{"age": 42}`}}, nil).Once()
+
+	var out, status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	settings := &chatSettings{
+		model:      "test-model",
+		stream:     true,
+		structured: &structuredOutput{format: s.Raw(), schema: s, retries: 1},
+	}
+	response, added, err := sendChatMessage(context.Background(), mockClient, &out, settings, []api.Message{{Role: "user", Content: "How old?"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"age\": 42}\n", out.String())
+	assert.Equal(t, `{"age": 42}`, response.Message.Content)
+	assert.Len(t, added, 3)
+	// Status messages must not end up on stdout
+	assert.Empty(t, status.String())
+	mockClient.AssertExpectations(t)
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/masgari/ollama-cli/pkg/client"
 	"github.com/masgari/ollama-cli/pkg/config"
+	"golang.org/x/term"
 )
 
 // createOllamaClient creates a new Ollama client using the current configuration
@@ -17,3 +19,31 @@ func createOllamaClient() (client.Client, error) {
 	// Use the client factory pattern to allow for mocking in tests
 	return client.NewClient(), nil
 }
+
+// isStdinPiped reports whether stdin is connected to a pipe or file rather than a terminal
+var isStdinPiped = func() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice == 0
+}
+
+// isStdoutTerminal reports whether stdout is connected to a terminal
+var isStdoutTerminal = func() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width of the terminal connected to stdout, or 0 if it
+// cannot be determined
+func terminalWidth() int {
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
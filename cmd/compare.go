@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/security"
+	"github.com/mattn/go-runewidth"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// Layouts of the answers compared
+const (
+	compareLayoutAuto    = "auto"
+	compareLayoutColumns = "columns"
+	compareLayoutBlocks  = "blocks"
+)
+
+// minColumnWidth is the narrowest column answers are written in side by side
+const minColumnWidth = 30
+
+// defaultColumnsWidth is the width of the columns when stdout is not a terminal
+const defaultColumnsWidth = 120
+
+// columnGap is the number of spaces between columns
+const columnGap = 3
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare [model...]",
+	Short: "Compare the answers of several models to the same prompt",
+	Long: `Send the same prompt to several models and compare their answers and their speed.
+
+The models answer one after the other by default, so that only one of them needs to be
+loaded in memory at a time. --parallel lets several models answer at once when the server
+has the memory for them, and --unload frees the memory of each model once it answered.
+
+The answers are written side by side in columns when stdout is a terminal wide enough
+for them, or one after the other in blocks, which --layout can choose. They are followed
+by a table of statistics reported by the server for each model: the generation speed,
+the tokens of the answer and of the prompt, and the time spent loading the model,
+evaluating the prompt, generating the answer and in total.
+
+NOTE: This command shares the chat setting in your configuration. If chat is disabled, you will
+be prompted to enable it on first use.
+
+Examples:
+  # Compare three models
+  ollama-cli compare -p "Explain recursion in one paragraph" llama3.2 qwen3:8b phi4-mini
+
+  # Read the prompt from stdin and write the answers one after the other
+  cat question.txt | ollama-cli compare llama3.2 gemma3 --layout blocks
+
+  # Let two models answer at once, with the same temperature and system prompt
+  ollama-cli compare -p "Write a limerick" -t 0.2 -s "You are a poet" llama3.2 gemma3 -j 2
+
+  # Free the memory of each model before the next one is loaded
+  ollama-cli compare -p "Summarize the plot of Hamlet" llama3.1:70b qwen3:32b --unload`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Skip completion if chat is not enabled
+		if !config.Current.ChatEnabled {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeModelNames(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Compare is gated by the same setting as chat
+		if !config.Current.ChatEnabled {
+			if err := enableChatCommand(); err != nil {
+				// If the error message is "chat command not enabled", return nil to exit gracefully
+				if err.Error() == "chat command not enabled" {
+					return nil
+				}
+				return err
+			}
+		}
+
+		promptText, _ := cmd.Flags().GetString("prompt")
+		systemPrompt, _ := cmd.Flags().GetString("system")
+		temperature, _ := cmd.Flags().GetFloat64("temperature")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		layout, _ := cmd.Flags().GetString("layout")
+		unload, _ := cmd.Flags().GetBool("unload")
+		strictSecurity, _ := cmd.Flags().GetBool("strict-security")
+
+		models := uniqueStrings(args)
+		if len(models) < 2 {
+			return fmt.Errorf("specify at least two different models to compare")
+		}
+		if parallel < 1 {
+			parallel = 1
+		}
+		layout, columnWidth, err := compareLayout(strings.ToLower(layout), len(models))
+		if err != nil {
+			return err
+		}
+
+		// Read the prompt from stdin if it was not provided as a flag
+		if promptText == "" && isStdinPiped() {
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read prompt from stdin: %w", err)
+			}
+			promptText = strings.TrimSpace(string(data))
+		}
+		if promptText == "" {
+			return fmt.Errorf("no prompt provided, use --prompt or pipe the prompt through stdin")
+		}
+
+		// The prompt is checked once for all models, there is no one to confirm a
+		// suspicious prompt
+		var sanitizeResult security.SanitizationResult
+		if strictSecurity {
+			sanitizeResult = security.ApplyStrictSanitization(promptText)
+		} else {
+			sanitizeResult = security.SanitizeInput(promptText)
+		}
+		for _, warning := range sanitizeResult.Warnings {
+			output.Default.WarningPrintf("%s\n", warning)
+		}
+		if sanitizeResult.IsSuspicious {
+			output.Default.WarningPrintf("%s\n", security.GetWarningMessage())
+			return fmt.Errorf("input contains suspicious patterns, use --strict-security=false to send it anyway")
+		}
+
+		// Prepare model options
+		options := make(map[string]interface{})
+		if cmd.Flags().Changed("temperature") {
+			options["temperature"] = temperature
+		}
+		messages := []api.Message{
+			{Role: "system", Content: chatSystemPrompt(systemPrompt)},
+			{Role: "user", Content: sanitizeResult.SanitizedInput},
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		output.Default.InfoPrintf("Comparing %d models...\n", len(models))
+		results := compareModels(context.Background(), ollamaClient, messages, options, models, parallel, unload)
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out)
+		if layout == compareLayoutColumns {
+			writeAnswerColumns(out, results, columnWidth)
+		} else {
+			writeAnswerBlocks(out, results)
+		}
+		fmt.Fprintln(out)
+		if err := writeCompareStats(out, results); err != nil {
+			return err
+		}
+
+		return compareError(results)
+	},
+}
+
+// compareLayout resolves the layout of the answers of models, returning the width of
+// each column for the columns layout
+func compareLayout(layout string, models int) (string, int, error) {
+	width := terminalWidth()
+	if width == 0 {
+		width = defaultColumnsWidth
+	}
+	columnWidth := (width - columnGap*(models-1)) / models
+
+	switch layout {
+	case compareLayoutAuto:
+		if isStdoutTerminal() && columnWidth >= minColumnWidth {
+			return compareLayoutColumns, columnWidth, nil
+		}
+		return compareLayoutBlocks, 0, nil
+	case compareLayoutColumns:
+		if columnWidth < minColumnWidth {
+			return "", 0, fmt.Errorf("%d columns do not fit in %d characters, use --layout blocks", models, width)
+		}
+		return layout, columnWidth, nil
+	case compareLayoutBlocks:
+		return layout, 0, nil
+	default:
+		return "", 0, fmt.Errorf("invalid layout: %s (must be auto, columns or blocks)", layout)
+	}
+}
+
+// compareResult is the answer of a single model
+type compareResult struct {
+	model    string
+	response *api.ChatResponse
+	duration time.Duration
+	err      error
+}
+
+// answer returns the text of the answer, or the error of the model
+func (r compareResult) answer() string {
+	if r.err != nil {
+		return "Error: " + r.err.Error()
+	}
+	return strings.TrimSpace(r.response.Message.Content)
+}
+
+// compareModels sends the messages to every model, with at most parallel models
+// answering at a time, and returns the result of every model in their order
+func compareModels(ctx context.Context, ollamaClient client.Client, messages []api.Message, options map[string]interface{}, models []string, parallel int, unload bool) []compareResult {
+	results := make([]compareResult, len(models))
+
+	// Status messages of concurrent models are written one at a time
+	var mu sync.Mutex
+	done := 0
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, modelName := range models {
+		wg.Add(1)
+		go func(i int, modelName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			stream := false
+			start := time.Now()
+			response, err := ollamaClient.ChatWithModel(ctx, &api.ChatRequest{
+				Model:    modelName,
+				Messages: messages,
+				Stream:   &stream,
+				Options:  options,
+			}, nil)
+			results[i] = compareResult{model: modelName, response: response, duration: time.Since(start), err: err}
+
+			var unloadErr error
+			if unload {
+				unloadErr = ollamaClient.UnloadModel(ctx, modelName)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			if err != nil {
+				output.Default.ErrorPrintf("Model '%s' failed (%d of %d): %s\n", modelName, done, len(models), err)
+			} else {
+				output.Default.InfoPrintf("Model '%s' answered in %s (%d of %d)\n", output.Highlight(modelName), colorizeDuration(results[i].duration), done, len(models))
+				validation := security.ValidateChatResponse(response)
+				for _, warning := range validation.Warnings {
+					output.Default.WarningPrintf("Model '%s': %s\n", modelName, warning)
+				}
+				if validation.IsSuspicious {
+					output.Default.WarningPrintf("Model '%s': %s\n", modelName, security.GetOutputWarningMessage())
+				}
+			}
+			if unloadErr != nil {
+				output.Default.WarningPrintf("Failed to unload model '%s': %s\n", modelName, unloadErr)
+			}
+		}(i, modelName)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// writeAnswerBlocks writes the answers one after the other, under the name of their
+// model
+func writeAnswerBlocks(out io.Writer, results []compareResult) {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, output.Highlight(result.model))
+		fmt.Fprintln(out, strings.Repeat("-", runewidth.StringWidth(result.model)))
+		fmt.Fprintln(out, result.answer())
+	}
+}
+
+// writeAnswerColumns writes the answers side by side, in columns of width terminal
+// cells under the name of their model
+func writeAnswerColumns(out io.Writer, results []compareResult, width int) {
+	names := make([]string, len(results))
+	rules := make([]string, len(results))
+	columns := make([][]string, len(results))
+	rows := 0
+	for i, result := range results {
+		names[i] = wrapText(result.model, width)[0]
+		rules[i] = strings.Repeat("-", width)
+		columns[i] = wrapText(result.answer(), width)
+		rows = max(rows, len(columns[i]))
+	}
+
+	writeColumnsRow(out, names, width, output.Highlight)
+	writeColumnsRow(out, rules, width, nil)
+	cells := make([]string, len(results))
+	for row := 0; row < rows; row++ {
+		for i, column := range columns {
+			cells[i] = ""
+			if row < len(column) {
+				cells[i] = column[row]
+			}
+		}
+		writeColumnsRow(out, cells, width, nil)
+	}
+}
+
+// writeColumnsRow writes cells side by side, padding all but the last one to width
+// terminal cells, so wide characters such as CJK and emoji stay aligned. style
+// applies to the text of each cell, if set.
+func writeColumnsRow(out io.Writer, cells []string, width int, style func(...interface{}) string) {
+	var line strings.Builder
+	for i, cell := range cells {
+		text := cell
+		if style != nil && cell != "" {
+			text = style(cell)
+		}
+		line.WriteString(text)
+		if i < len(cells)-1 {
+			line.WriteString(strings.Repeat(" ", width-runewidth.StringWidth(cell)+columnGap))
+		}
+	}
+	fmt.Fprintln(out, strings.TrimRight(line.String(), " "))
+}
+
+// wrapText wraps the lines of text to width terminal cells, breaking them at spaces
+// when possible. Wide characters take two cells.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		runes := []rune(strings.TrimRight(line, " "))
+		wrapped := len(lines)
+		for runewidth.StringWidth(string(runes)) > width {
+			// fit is the number of runes that fit in width, at least one
+			fit, used := 0, 0
+			for fit < len(runes) && used+runewidth.RuneWidth(runes[fit]) <= width {
+				used += runewidth.RuneWidth(runes[fit])
+				fit++
+			}
+			fit = max(fit, 1)
+
+			cut := fit
+			for i := min(fit, len(runes)-1); i > 0; i-- {
+				if runes[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+			runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+		}
+		// A character wider than width may leave nothing after the last cut
+		if len(runes) > 0 || len(lines) == wrapped {
+			lines = append(lines, string(runes))
+		}
+	}
+	return lines
+}
+
+// writeCompareStats writes a table of the statistics of every answer, with the same
+// figures as --stats
+func writeCompareStats(out io.Writer, results []compareResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, output.MakeHeader("MODEL\tTOKENS/S\tRESPONSE TOKENS\tPROMPT TOKENS\tLOAD TIME\tPROMPT EVAL TIME\tGENERATION TIME\tTOTAL TIME"))
+
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(w, "%s\tfailed\t-\t-\t-\t-\t-\t-\n", output.Highlight(result.model))
+			continue
+		}
+
+		metrics := result.response.Metrics
+		tokensPerSecond := "-"
+		if metrics.EvalDuration > 0 && metrics.EvalCount > 0 {
+			tokensPerSecond = fmt.Sprintf("%.2f", float64(metrics.EvalCount)/(float64(metrics.EvalDuration)/1e9))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			output.Highlight(result.model),
+			tokensPerSecond,
+			metrics.EvalCount,
+			metrics.PromptEvalCount,
+			formatDuration(float64(metrics.LoadDuration)/1e6),
+			formatDuration(float64(metrics.PromptEvalDuration)/1e6),
+			formatDuration(float64(metrics.EvalDuration)/1e6),
+			formatDuration(float64(metrics.TotalDuration)/1e6),
+		)
+	}
+	return w.Flush()
+}
+
+// compareError returns an error if any of the models failed to answer
+func compareError(results []compareResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to get an answer from %d of %d models", failed, len(results))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringP("prompt", "p", "", "Prompt sent to every model (default: read from stdin)")
+	compareCmd.Flags().StringP("system", "s", "", "System prompt to set the behavior of the models")
+	compareCmd.Flags().Float64P("temperature", "t", 0.8, "Temperature for response generation (0.0 to 1.0)")
+	compareCmd.Flags().IntP("parallel", "j", 1, "Maximum number of models answering at once")
+	compareCmd.Flags().String("layout", compareLayoutAuto, "Layout of the answers: auto, columns or blocks")
+	compareCmd.Flags().Bool("unload", false, "Unload each model once it answered, to free its memory")
+	compareCmd.Flags().Bool("strict-security", true, "Enable strict security mode for prompt injection protection")
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareCommand(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+	isStdinPiped = func() bool { return false }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(m *client.MockClientTestify)
+		wantErr     string
+		wantOut     []string
+		wantStatus  []string
+		wantNoCalls bool
+	}{
+		{
+			name: "Blocks",
+			args: []string{"-p", "Capital of France?", "model-a", "model-b", "--layout", "blocks", "-t", "0.2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return req.Model == "model-a" && req.Options["temperature"] == 0.2 &&
+						len(req.Messages) == 2 && req.Messages[0].Content == securitySystemPrompt &&
+						req.Messages[1].Content == "Capital of France?"
+				}), mock.Anything).Return(&api.ChatResponse{
+					Message: api.Message{Role: "assistant", Content: "Paris\n"},
+					Metrics: api.Metrics{
+						TotalDuration: 2 * time.Second,
+						LoadDuration:  500 * time.Millisecond,
+						EvalCount:     50,
+						EvalDuration:  time.Second,
+					},
+				}, nil).Once()
+				m.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return req.Model == "model-b"
+				}), mock.Anything).Return(nil, errors.New("model not found")).Once()
+			},
+			wantErr: "failed to get an answer from 1 of 2 models",
+			wantOut: []string{
+				"model-a\n-------\nParis\n\nmodel-b\n-------\nError: model not found\n",
+				"MODEL     TOKENS/S   RESPONSE TOKENS   PROMPT TOKENS   LOAD TIME   PROMPT EVAL TIME   GENERATION TIME   TOTAL TIME\n",
+				"model-a   50.00      50                0               500.00 ms   0.000 ms           1.00 s            2.00 s\n",
+				"model-b   failed     -                 -               -           -                  -                 -\n",
+			},
+			wantStatus: []string{
+				"Comparing 2 models...",
+				"Model 'model-a' answered in",
+				"Model 'model-b' failed",
+			},
+		},
+		{
+			name: "Columns, unloading the models",
+			args: []string{"-p", "Hi", "model-a", "model-b", "--layout", "columns", "--unload", "-j", "2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return req.Model == "model-a"
+				}), mock.Anything).Return(&api.ChatResponse{
+					Message: api.Message{Role: "assistant", Content: "Hello there"},
+				}, nil).Once()
+				m.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return req.Model == "model-b"
+				}), mock.Anything).Return(&api.ChatResponse{
+					Message: api.Message{Role: "assistant", Content: "Hi!\nHow can I help?"},
+				}, nil).Once()
+				m.On("UnloadModel", mock.Anything, "model-a").Return(nil).Once()
+				m.On("UnloadModel", mock.Anything, "model-b").Return(errors.New("connection refused")).Once()
+			},
+			wantOut: []string{
+				"model-a" + strings.Repeat(" ", 51+columnGap) + "model-b\n",
+				strings.Repeat("-", 58) + strings.Repeat(" ", columnGap) + strings.Repeat("-", 58) + "\n",
+				"Hello there" + strings.Repeat(" ", 47+columnGap) + "Hi!\n",
+				strings.Repeat(" ", 58+columnGap) + "How can I help?\n",
+			},
+			wantStatus: []string{"Failed to unload model 'model-b': connection refused"},
+		},
+		{
+			name:        "Same model twice",
+			args:        []string{"-p", "Hi", "model-a", "model-a"},
+			setupMock:   func(m *client.MockClientTestify) {},
+			wantErr:     "specify at least two different models to compare",
+			wantNoCalls: true,
+		},
+		{
+			name:        "Invalid layout",
+			args:        []string{"-p", "Hi", "model-a", "model-b", "--layout", "grid"},
+			setupMock:   func(m *client.MockClientTestify) {},
+			wantErr:     "invalid layout: grid (must be auto, columns or blocks)",
+			wantNoCalls: true,
+		},
+		{
+			name:        "Too many columns",
+			args:        []string{"-p", "Hi", "a", "b", "c", "d", "e", "--layout", "columns"},
+			setupMock:   func(m *client.MockClientTestify) {},
+			wantErr:     "5 columns do not fit in 120 characters, use --layout blocks",
+			wantNoCalls: true,
+		},
+		{
+			name:        "No prompt",
+			args:        []string{"model-a", "model-b"},
+			setupMock:   func(m *client.MockClientTestify) {},
+			wantErr:     "no prompt provided",
+			wantNoCalls: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status bytes.Buffer
+			output.Default = output.NewColorWriter(&status)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			resetFlags(compareCmd)
+			var out bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.AddCommand(compareCmd)
+			root.SetArgs(append([]string{"compare"}, tt.args...))
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, want := range tt.wantOut {
+				assert.Contains(t, out.String(), want)
+			}
+			for _, want := range tt.wantStatus {
+				assert.Contains(t, status.String(), want)
+			}
+			if tt.wantNoCalls {
+				mockClient.AssertNotCalled(t, "ChatWithModel", mock.Anything, mock.Anything, mock.Anything)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWriteColumnsRow(t *testing.T) {
+	// Wide characters take two cells, so the next column starts at the same place
+	var out bytes.Buffer
+	writeColumnsRow(&out, []string{"日本", "b"}, 6, nil)
+	writeColumnsRow(&out, []string{"abcd", "b"}, 6, nil)
+	assert.Equal(t, "日本"+strings.Repeat(" ", 2+columnGap)+"b\nabcd"+strings.Repeat(" ", 2+columnGap)+"b\n", out.String())
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{name: "Short", text: "Hello", width: 10, want: []string{"Hello"}},
+		{name: "At spaces", text: "The quick brown fox jumps", width: 10, want: []string{"The quick", "brown fox", "jumps"}},
+		{name: "Long word", text: "abcdefghijkl mn", width: 5, want: []string{"abcde", "fghij", "kl mn"}},
+		{name: "Lines and tabs", text: "if x {\n\treturn\n}", width: 20, want: []string{"if x {", "    return", "}"}},
+		{name: "Wide characters", text: "日本語のテキスト です", width: 6, want: []string{"日本語", "のテキ", "スト", "です"}},
+		{name: "Wider than the width", text: "日本", width: 1, want: []string{"日", "本"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, wrapText(tt.text, tt.width))
+		})
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// completeModelNames provides completion for model names from the Ollama server
-// This function can be used by any command that requires a model name argument
-func completeModelNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+// newCompletionClient creates an Ollama client for shell completion.
+// Completion runs without PersistentPreRunE, so the configuration is loaded from the command flags here.
+func newCompletionClient(cmd *cobra.Command) (client.Client, error) {
 	// Get configuration context from command flags
 	configName := ""
 	if cmd.Flags().Changed("config-name") {
@@ -23,7 +23,7 @@ func completeModelNames(cmd *cobra.Command, args []string, toComplete string) ([
 	// Load the appropriate configuration
 	cfg, err := config.LoadConfig(configName)
 	if err != nil {
-		return nil, cobra.ShellCompDirectiveNoFileComp
+		return nil, err
 	}
 
 	// Override config with command line flags if provided
@@ -49,7 +49,24 @@ func completeModelNames(cmd *cobra.Command, args []string, toComplete string) ([
 	}
 
 	// Create Ollama client with the correct configuration
-	ollamaClient, err := client.NewClientWithConfig(cfg)
+	return client.NewClientWithConfig(cfg)
+}
+
+// filterModelNames returns the names starting with what the user has typed
+func filterModelNames(names []string, toComplete string) []string {
+	var modelNames []string
+	for _, name := range names {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(toComplete)) {
+			modelNames = append(modelNames, name)
+		}
+	}
+	return modelNames
+}
+
+// completeModelNames provides completion for model names from the Ollama server
+// This function can be used by any command that requires a model name argument
+func completeModelNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ollamaClient, err := newCompletionClient(cmd)
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
@@ -61,12 +78,31 @@ func completeModelNames(cmd *cobra.Command, args []string, toComplete string) ([
 	}
 
 	// Extract model names and filter based on what user has typed
-	var modelNames []string
+	var names []string
 	for _, model := range models.Models {
-		if strings.HasPrefix(strings.ToLower(model.Name), strings.ToLower(toComplete)) {
-			modelNames = append(modelNames, model.Name)
-		}
+		names = append(names, model.Name)
+	}
+
+	return filterModelNames(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRunningModelNames provides completion for the names of models currently loaded in memory
+func completeRunningModelNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ollamaClient, err := newCompletionClient(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Fetch running models
+	models, err := ollamaClient.ListRunning(context.Background())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, model := range models.Models {
+		names = append(names, model.Name)
 	}
 
-	return modelNames, cobra.ShellCompDirectiveNoFileComp
+	return filterModelNames(names, toComplete), cobra.ShellCompDirectiveNoFileComp
 }
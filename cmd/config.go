@@ -112,6 +112,18 @@ var configSetCmd = &cobra.Command{
 				return
 			}
 			config.Current.CheckUpdates = checkUpdates
+		case "stdin-template":
+			if _, err := parseStdinTemplate(value); err != nil {
+				output.Default.ErrorPrintf("Error: %v\n", err)
+				return
+			}
+			config.Current.StdinTemplate = value
+		case "context-strategy":
+			if err := validateContextStrategy(value); err != nil {
+				output.Default.ErrorPrintf("Error: %v\n", err)
+				return
+			}
+			config.Current.ContextStrategy = value
 		default:
 			output.Default.ErrorPrintf("Error: unknown configuration key: %s\n", key)
 			return
@@ -152,6 +164,10 @@ var configGetCmd = &cobra.Command{
 			fmt.Println(output.Highlight(config.Current.GetServerURL()))
 		case "chat_enabled":
 			fmt.Println(output.Highlight(strconv.FormatBool(config.Current.ChatEnabled)))
+		case "stdin-template":
+			fmt.Println(output.Highlight(stdinTemplateText(config.Current)))
+		case "context-strategy":
+			fmt.Println(output.Highlight(contextStrategyText(config.Current)))
 		default:
 			output.Default.ErrorPrintf("Error: unknown configuration key: %s\n", key)
 		}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+)
+
+// Strategies applied when a conversation nears the context window of the model
+const (
+	contextNone          = "none"
+	contextDropOldest    = "drop-oldest"
+	contextSlidingWindow = "sliding-window"
+	contextSummarize     = "summarize"
+)
+
+// contextStrategies are the valid context strategies
+var contextStrategies = []string{contextNone, contextDropOldest, contextSlidingWindow, contextSummarize}
+
+// defaultContextStrategy applies when no strategy is configured
+const defaultContextStrategy = contextSlidingWindow
+
+// contextThreshold is the share of the context window above which the strategy
+// applies, leaving room for the answer
+const contextThreshold = 0.8
+
+// contextWindowShare is the share of the context window kept by the sliding window
+// and summarize strategies, so they only apply again after several messages
+const contextWindowShare = 0.5
+
+// messageOverhead is the number of tokens the template of a model adds to a message
+const messageOverhead = 4
+
+// summaryRequest is the message the summary of earlier messages answers
+const summaryRequest = "Summarize our conversation so far."
+
+// summarizePrompt asks the model to summarize earlier messages of a conversation
+const summarizePrompt = `Summarize the following conversation between a user and an assistant. Keep the facts, decisions, names, numbers and open questions that later messages may refer to. Write only the summary.`
+
+// validateContextStrategy checks that a context strategy is known
+func validateContextStrategy(strategy string) error {
+	for _, valid := range contextStrategies {
+		if strategy == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid context strategy %q, must be one of: %s", strategy, strings.Join(contextStrategies, ", "))
+}
+
+// contextStrategyText returns the context strategy of a configuration, or the default one
+func contextStrategyText(cfg *config.Config) string {
+	if cfg != nil && cfg.ContextStrategy != "" {
+		return cfg.ContextStrategy
+	}
+	return defaultContextStrategy
+}
+
+// numCtxOption returns the num_ctx option, which may be an int or a float64 read from
+// JSON, or zero if it is not set
+func numCtxOption(options map[string]interface{}) int {
+	switch value := options["num_ctx"].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return 0
+}
+
+// contextManager keeps a conversation within the context window of the model. The
+// conversation itself is kept whole, the manager chooses the messages that are sent:
+// the system prompt, a summary of earlier messages if any, and the latest messages.
+type contextManager struct {
+	client   client.Client
+	strategy string
+	// window is the context window in tokens, and windowKey the model and num_ctx
+	// option it was found for
+	window    int
+	windowKey string
+	// ratio calibrates token estimates with the prompt_eval_count of responses
+	ratio float64
+	// used is the number of tokens of the conversation after the last response
+	used int
+	// start is the index of the first message sent after the system prompt
+	start int
+	// summary replaces the messages before start, nil when they are dropped
+	summary []api.Message
+}
+
+// newContextManager creates a context manager applying a strategy
+func newContextManager(ollamaClient client.Client, strategy string) *contextManager {
+	return &contextManager{client: ollamaClient, strategy: strategy, ratio: 1, start: 1}
+}
+
+// reset sends the whole conversation again, such as after it was replaced
+func (m *contextManager) reset() {
+	m.start = 1
+	m.summary = nil
+}
+
+// setStrategy changes the strategy, the whole conversation is sent again until the
+// new strategy applies
+func (m *contextManager) setStrategy(strategy string) {
+	m.strategy = strategy
+	m.reset()
+}
+
+// view returns the messages to send for a conversation
+func (m *contextManager) view(messages []api.Message) []api.Message {
+	// Messages may have been removed since the strategy applied
+	if m.start > len(messages) {
+		m.reset()
+	}
+	if m.start == 1 && m.summary == nil {
+		return messages
+	}
+
+	view := make([]api.Message, 0, 1+len(m.summary)+len(messages)-m.start)
+	view = append(view, messages[0])
+	view = append(view, m.summary...)
+	return append(view, messages[m.start:]...)
+}
+
+// estimate returns the approximate number of tokens of messages, calibrated with the
+// last response
+func (m *contextManager) estimate(messages []api.Message, keepThinking bool) int {
+	return int(float64(rawEstimate(messages, keepThinking)) * m.ratio)
+}
+
+// rawEstimate returns the approximate number of tokens of messages from their size
+func rawEstimate(messages []api.Message, keepThinking bool) int {
+	tokens := 0
+	for _, msg := range messages {
+		size := len(msg.Content)
+		if keepThinking {
+			size += len(msg.Thinking)
+		}
+		for _, call := range msg.ToolCalls {
+			size += len(call.Function.Name) + len(call.Function.Arguments.String())
+		}
+		tokens += size/bytesPerToken + messageOverhead
+	}
+	return tokens
+}
+
+// observe calibrates the estimates with a response to the messages sent, which
+// reports the tokens of the prompt and of the answer
+func (m *contextManager) observe(sent []api.Message, response *api.ChatResponse, keepThinking bool) {
+	if response == nil || response.PromptEvalCount == 0 {
+		return
+	}
+	m.used = response.PromptEvalCount + response.EvalCount
+	if estimate := rawEstimate(sent, keepThinking); estimate > 0 {
+		m.ratio = min(4, max(0.25, float64(m.used)/float64(estimate)))
+	}
+}
+
+// contextWindow returns the context window of the model in tokens: the num_ctx
+// option, or the context length of the model if it is running, or its num_ctx
+// parameter, or the default of the server. It is capped by the context length the
+// model supports.
+func (m *contextManager) contextWindow(settings *chatSettings) int {
+	numCtx := numCtxOption(settings.options)
+	key := fmt.Sprintf("%s/%d", settings.model, numCtx)
+	if key == m.windowKey {
+		return m.window
+	}
+
+	// The window is kept until the model or num_ctx changes, so the server is not
+	// asked again for every message
+	window := numCtx
+	if window == 0 {
+		// The context length of a running model is the one in use
+		if running, err := m.client.ListRunning(context.Background()); err == nil {
+			for _, model := range running.Models {
+				if (sameModelName(model.Name, settings.model) || sameModelName(model.Model, settings.model)) &&
+					model.ContextLength > 0 {
+					window = model.ContextLength
+				}
+			}
+		}
+	}
+
+	details, err := m.client.GetModelDetails(context.Background(), settings.model)
+	if err != nil {
+		details = nil
+	}
+	if window == 0 {
+		window = modelNumCtx(details)
+	}
+	if window == 0 {
+		window = defaultContextLength
+	}
+	if maxLength := modelContextLength(details); maxLength > 0 && window > maxLength {
+		window = maxLength
+	}
+
+	m.window, m.windowKey = window, key
+	return window
+}
+
+// modelNumCtx returns the num_ctx parameter of a model, or zero if it is not set
+func modelNumCtx(details *api.ShowResponse) int {
+	if details == nil {
+		return 0
+	}
+	for _, line := range strings.Split(details.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if value, err := strconv.Atoi(fields[1]); err == nil {
+				return value
+			}
+		}
+	}
+	return 0
+}
+
+// fit applies the strategy when the messages to send take more than the threshold
+// of the context window. The last message of the user is always sent.
+func (m *contextManager) fit(ctx context.Context, status *output.ColorWriter, settings *chatSettings, messages []api.Message) {
+	if m.strategy == contextNone {
+		return
+	}
+
+	window := m.contextWindow(settings)
+	tokens := m.estimate(m.view(messages), settings.keepThinking)
+	if tokens <= int(float64(window)*contextThreshold) {
+		return
+	}
+
+	// Messages are removed by turns, each starting with a message of the user
+	var turns []int
+	for i := m.start; i < len(messages); i++ {
+		if messages[i].Role == "user" {
+			turns = append(turns, i)
+		}
+	}
+	if len(turns) == 0 {
+		return
+	}
+
+	target := int(float64(window) * contextThreshold)
+	if m.strategy != contextDropOldest {
+		target = int(float64(window) * contextWindowShare)
+	}
+
+	// A previous summary is summarized again with the messages it precedes
+	start, summary := m.start, m.summary
+	m.summary = nil
+	for _, turn := range turns {
+		if m.estimate(m.view(messages), settings.keepThinking) <= target {
+			break
+		}
+		m.start = turn
+	}
+	removed := m.start - start
+	if removed == 0 {
+		m.summary = summary
+	}
+
+	action := "dropped"
+	if m.strategy == contextSummarize && removed > 0 {
+		earlier := append(summary[:len(summary):len(summary)], messages[start:m.start]...)
+		if summarized, err := m.summarize(ctx, settings, earlier, window); err != nil {
+			status.WarningPrintf("Failed to summarize earlier messages, dropping them: %s\n", err)
+		} else {
+			m.summary = summarized
+			action = "summarized"
+		}
+	}
+
+	if removed > 0 {
+		status.InfoPrintf("Context %d%% full (%d of %d tokens), %s %d earlier messages.\n",
+			tokens*100/window, tokens, window, action, removed)
+	}
+	if m.estimate(m.view(messages), settings.keepThinking) > window {
+		status.WarningPrintf("The conversation may not fit in the context window of %d tokens.\n", window)
+	}
+}
+
+// summarize asks the model to summarize messages, returning the messages that
+// replace them
+func (m *contextManager) summarize(ctx context.Context, settings *chatSettings, messages []api.Message, window int) ([]api.Message, error) {
+	transcript := formatTranscript(messages)
+
+	// The most recent part of the transcript is kept when it does not fit in the
+	// context window with the summary
+	maxBytes := int(float64(window)*contextThreshold) * bytesPerToken
+	if len(transcript) > maxBytes {
+		// The cut moves forward to the start of a character, so the text stays valid UTF-8
+		cut := len(transcript) - maxBytes
+		for cut < len(transcript) && !utf8.RuneStart(transcript[cut]) {
+			cut++
+		}
+		transcript = "..." + transcript[cut:]
+	}
+
+	stream := false
+	response, err := m.client.ChatWithModel(ctx, &api.ChatRequest{
+		Model: settings.model,
+		Messages: []api.Message{
+			{Role: "system", Content: securitySystemPrompt},
+			{Role: "user", Content: summarizePrompt + "\n\n<conversation>\n" + transcript + "\n</conversation>"},
+		},
+		Stream:  &stream,
+		Options: settings.options,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := strings.TrimSpace(response.Message.Content)
+	if summary == "" {
+		return nil, fmt.Errorf("the summary is empty")
+	}
+	return []api.Message{
+		{Role: "user", Content: summaryRequest},
+		{Role: "assistant", Content: summary},
+	}, nil
+}
+
+// formatTranscript writes messages as a plain text transcript
+func formatTranscript(messages []api.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			b.WriteString("User: ")
+		case "assistant":
+			if msg.Content == "" {
+				continue
+			}
+			b.WriteString("Assistant: ")
+		case "tool":
+			fmt.Fprintf(&b, "Tool %s: ", msg.ToolName)
+		default:
+			continue
+		}
+		b.WriteString(strings.TrimSpace(msg.Content))
+		b.WriteString("\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/history"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// contextTestMessages returns the system prompt followed by turns of 104 tokens per
+// message, the last turn being a message of the user without an answer
+func contextTestMessages(turns int) []api.Message {
+	messages := []api.Message{{Role: "system", Content: "S"}}
+	for i := 1; i <= turns; i++ {
+		messages = append(messages, api.Message{Role: "user", Content: fmt.Sprintf("u%d %s", i, strings.Repeat("x", 397))})
+		if i < turns {
+			messages = append(messages, api.Message{Role: "assistant", Content: fmt.Sprintf("a%d %s", i, strings.Repeat("x", 397))})
+		}
+	}
+	return messages
+}
+
+// contents returns the first word of the content of messages
+func contents(messages []api.Message) []string {
+	var words []string
+	for _, msg := range messages {
+		word, _, _ := strings.Cut(msg.Content, " ")
+		words = append(words, word)
+	}
+	return words
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		setup   func(m *client.MockClientTestify)
+		want    int
+	}{
+		{
+			name:    "num_ctx option",
+			options: map[string]interface{}{"num_ctx": float64(1000)},
+			setup: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{}, nil).Once()
+			},
+			want: 1000,
+		},
+		{
+			name: "Running model",
+			setup: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(&api.ProcessResponse{Models: []api.ProcessModelResponse{
+					{Name: "other-model:latest", ContextLength: 2048},
+					{Name: "Test-Model:latest", ContextLength: 8192},
+				}}, nil).Once()
+				m.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{}, nil).Once()
+			},
+			want: 8192,
+		},
+		{
+			name: "num_ctx parameter of the model",
+			setup: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(&api.ProcessResponse{}, nil).Once()
+				m.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+					Parameters: "num_ctx                        2048\nstop                           \"<|end|>\"",
+				}, nil).Once()
+			},
+			want: 2048,
+		},
+		{
+			name: "Default",
+			setup: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(nil, errors.New("connection refused")).Once()
+				m.On("GetModelDetails", mock.Anything, "test-model").Return(nil, errors.New("connection refused")).Once()
+			},
+			want: defaultContextLength,
+		},
+		{
+			name:    "Capped by the context length of the model",
+			options: map[string]interface{}{"num_ctx": 100000},
+			setup: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+					ModelInfo: map[string]interface{}{"general.architecture": "llama", "llama.context_length": float64(8192)},
+				}, nil).Once()
+			},
+			want: 8192,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := client.NewMockClient()
+			tt.setup(mockClient)
+
+			m := newContextManager(mockClient, contextSlidingWindow)
+			settings := &chatSettings{model: "test-model", options: tt.options}
+			assert.Equal(t, tt.want, m.contextWindow(settings))
+			// Windows are looked up once, including the fallbacks
+			assert.Equal(t, tt.want, m.contextWindow(settings))
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContextManagerFit(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   string
+		turns      int
+		summary    string
+		summaryErr error
+		want       []string
+		wantStatus string
+	}{
+		{
+			name:     "Under the threshold",
+			strategy: contextDropOldest,
+			turns:    3,
+			want:     []string{"S", "u1", "a1", "u2", "a2", "u3"},
+		},
+		{
+			name:     "None",
+			strategy: contextNone,
+			turns:    5,
+			want:     []string{"S", "u1", "a1", "u2", "a2", "u3", "a3", "u4", "a4", "u5"},
+		},
+		{
+			name:       "Drop oldest",
+			strategy:   contextDropOldest,
+			turns:      5,
+			want:       []string{"S", "u2", "a2", "u3", "a3", "u4", "a4", "u5"},
+			wantStatus: "Context 94% full (940 of 1000 tokens), dropped 2 earlier messages.",
+		},
+		{
+			name:       "Sliding window",
+			strategy:   contextSlidingWindow,
+			turns:      5,
+			want:       []string{"S", "u4", "a4", "u5"},
+			wantStatus: "Context 94% full (940 of 1000 tokens), dropped 6 earlier messages.",
+		},
+		{
+			name:       "Summarize",
+			strategy:   contextSummarize,
+			turns:      5,
+			summary:    "s1-3 The user asked three questions.",
+			want:       []string{"S", "Summarize", "s1-3", "u4", "a4", "u5"},
+			wantStatus: "Context 94% full (940 of 1000 tokens), summarized 6 earlier messages.",
+		},
+		{
+			name:       "Summary fails",
+			strategy:   contextSummarize,
+			turns:      5,
+			summaryErr: errors.New("model not found"),
+			want:       []string{"S", "u4", "a4", "u5"},
+			wantStatus: "Failed to summarize earlier messages, dropping them: model not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := client.NewMockClient()
+			mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{}, nil).Maybe()
+			if tt.strategy == contextSummarize {
+				mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+					return len(req.Messages) == 2 && req.Messages[0].Content == securitySystemPrompt &&
+						strings.Contains(req.Messages[1].Content, "<conversation>\nUser: u1 ") &&
+						strings.Contains(req.Messages[1].Content, "Assistant: a3 ") &&
+						!strings.Contains(req.Messages[1].Content, "u4")
+				}), mock.Anything).Return(&api.ChatResponse{
+					Message: api.Message{Role: "assistant", Content: tt.summary},
+				}, tt.summaryErr).Once()
+			}
+
+			var status bytes.Buffer
+			m := newContextManager(mockClient, tt.strategy)
+			settings := &chatSettings{model: "test-model", options: map[string]interface{}{"num_ctx": 1000}}
+			messages := contextTestMessages(tt.turns)
+			m.fit(t.Context(), output.NewColorWriter(&status), settings, messages)
+
+			assert.Equal(t, tt.want, contents(m.view(messages)))
+			if tt.wantStatus != "" {
+				assert.Contains(t, status.String(), tt.wantStatus)
+			} else {
+				assert.Empty(t, status.String())
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContextManagerSummarizeCutsAtCharacters(t *testing.T) {
+	// Each character takes three bytes, so some windows cut the transcript inside one
+	messages := []api.Message{{Role: "user", Content: strings.Repeat("€", 100)}}
+	for window := 10; window < 13; window++ {
+		mockClient := client.NewMockClient()
+		mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+			return utf8.ValidString(req.Messages[1].Content) && strings.Contains(req.Messages[1].Content, "\n...€")
+		}), mock.Anything).Return(&api.ChatResponse{
+			Message: api.Message{Role: "assistant", Content: "A summary."},
+		}, nil).Once()
+
+		m := newContextManager(mockClient, contextSummarize)
+		_, err := m.summarize(t.Context(), &chatSettings{model: "test-model"}, messages, window)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	}
+}
+
+func TestContextManagerView(t *testing.T) {
+	m := newContextManager(client.NewMockClient(), contextSlidingWindow)
+	messages := contextTestMessages(3)
+	m.start = 3
+	assert.Equal(t, []string{"S", "u2", "a2", "u3"}, contents(m.view(messages)))
+
+	// The whole conversation is sent again when messages before start were removed
+	assert.Equal(t, []string{"S", "u1"}, contents(m.view(messages[:2])))
+	assert.Equal(t, 1, m.start)
+}
+
+func TestContextManagerObserve(t *testing.T) {
+	m := newContextManager(client.NewMockClient(), contextSlidingWindow)
+	messages := contextTestMessages(5)
+	assert.Equal(t, 940, m.estimate(messages, false))
+
+	// The tokens reported by the server calibrate the estimates
+	m.observe(messages, &api.ChatResponse{Metrics: api.Metrics{PromptEvalCount: 1800, EvalCount: 80}}, false)
+	assert.Equal(t, 1880, m.used)
+	assert.Equal(t, 1880, m.estimate(messages, false))
+
+	// Responses without counts are ignored
+	m.observe(messages, &api.ChatResponse{}, false)
+	assert.Equal(t, 1880, m.estimate(messages, false))
+}
+
+func TestInteractiveChatContext(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	mockClient := client.NewMockClient()
+	mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{}, nil)
+
+	// Only the latest messages are sent after the security prompt
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return assert.ObjectsAreEqual([]string{"You", "u4", "a4", "u5"}, contents(req.Messages))
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "a5"},
+	}, nil).Once()
+
+	// Four turns, the fifth message is entered in the chat
+	messages := contextTestMessages(5)
+	chat, out := newTestInteractiveChat(mockClient, strings.Join([]string{
+		"u5 " + strings.Repeat("x", 397),
+		"/context",
+		"/context unknown",
+		"/context summarize",
+		"/exit",
+	}, "\n"))
+	chat.history = history.FromMessages(messages[1 : len(messages)-1])
+	chat.settings.options["num_ctx"] = 1500
+	chat.contextManager = newContextManager(mockClient, contextSlidingWindow)
+
+	require.NoError(t, chat.run())
+	mockClient.AssertExpectations(t)
+
+	// The conversation is kept whole
+	assert.Len(t, chat.messages(), 11)
+	assert.Contains(t, status.String(), "dropped 6 earlier messages")
+	assert.Contains(t, out.String(), "Strategy: sliding-window\nWindow: 1500 tokens\nUsed: about 683 tokens (45%)\nEarlier: 6 messages, not sent\n")
+	assert.Contains(t, status.String(), `invalid context strategy "unknown"`)
+	assert.Contains(t, status.String(), "Context strategy set to summarize")
+	assert.Equal(t, contextSummarize, chat.contextManager.strategy)
+	assert.Equal(t, 1, chat.contextManager.start)
+}
+
+func TestConfigContextStrategy(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	origGetConfigDir := config.GetConfigDir
+	defer func() { config.GetConfigDir = origGetConfigDir }()
+	configDir := t.TempDir()
+	config.GetConfigDir = func() string { return configDir }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+	assert.Equal(t, contextSlidingWindow, contextStrategyText(config.Current))
+
+	configSetCmd.Run(configSetCmd, []string{"context-strategy", "summarize"})
+	assert.Equal(t, contextSummarize, config.Current.ContextStrategy)
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "context_strategy: summarize")
+
+	// Unknown strategies are rejected
+	configSetCmd.Run(configSetCmd, []string{"context-strategy", "forget"})
+	assert.Equal(t, contextSummarize, config.Current.ContextStrategy)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// cpCmd represents the cp command
+var cpCmd = &cobra.Command{
+	Use:     "cp [source] [destination]",
+	Aliases: []string{"copy"},
+	Short:   "Copy a model to a new name on the Ollama server",
+	Long: `Copy a model on the remote Ollama server to a new name, e.g. to tag a tested model for release.
+The source model must exist on the server. An existing destination is only overwritten with --force.
+
+Examples:
+  # Tag a model as the stable release
+  ollama-cli cp team/assistant:rc1 team/assistant:stable
+
+  # Move the stable tag to a newer model
+  ollama-cli cp team/assistant:rc2 team/assistant:stable --force`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 2 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeModelNames(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, destination := args[0], args[1]
+		force, _ := cmd.Flags().GetBool("force")
+
+		if sameModelName(source, destination) {
+			return fmt.Errorf("source and destination are the same model")
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		// Check that the source exists and the destination does not
+		models, err := ollamaClient.ListModels(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to list models: %w", err)
+		}
+
+		sourceExists, destinationExists := false, false
+		for _, model := range models.Models {
+			if sameModelName(model.Name, source) {
+				sourceExists = true
+			}
+			if sameModelName(model.Name, destination) {
+				destinationExists = true
+			}
+		}
+
+		if !sourceExists {
+			return fmt.Errorf("model '%s' not found on the server", source)
+		}
+		if destinationExists && !force {
+			return fmt.Errorf("model '%s' already exists, use --force to overwrite it", destination)
+		}
+
+		if err := ollamaClient.CopyModel(context.Background(), source, destination); err != nil {
+			return fmt.Errorf("failed to copy model: %w", err)
+		}
+
+		output.Default.SuccessPrintf("Copied '%s' to '%s'.\n", output.Highlight(source), output.Highlight(destination))
+		return nil
+	},
+}
+
+// sameModelName reports whether two model names refer to the same model,
+// treating a name without a tag as the "latest" tag
+func sameModelName(a, b string) bool {
+	return normalizeModelName(a) == normalizeModelName(b)
+}
+
+// normalizeModelName adds the implicit "latest" tag to a model name without a tag
+func normalizeModelName(name string) string {
+	name = strings.ToLower(name)
+	// A colon before the last slash belongs to a registry host, not a tag
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name += ":latest"
+	}
+	return name
+}
+
+func init() {
+	rootCmd.AddCommand(cpCmd)
+
+	cpCmd.Flags().BoolP("force", "f", false, "Overwrite the destination if it already exists")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCpCommand(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	mockModels := &api.ListResponse{
+		Models: []api.ListModelResponse{
+			{Name: "team/assistant:rc1"},
+			{Name: "team/assistant:stable"},
+			{Name: "llama3.2:latest"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Copy to a new name",
+			args: []string{"cp", "team/assistant:rc1", "team/assistant:rc1-backup"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+				m.On("CopyModel", mock.Anything, "team/assistant:rc1", "team/assistant:rc1-backup").Return(nil)
+			},
+			wantContain: []string{"Copied 'team/assistant:rc1' to 'team/assistant:rc1-backup'."},
+		},
+		{
+			name: "Source without tag matches latest",
+			args: []string{"cp", "llama3.2", "my-llama"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+				m.On("CopyModel", mock.Anything, "llama3.2", "my-llama").Return(nil)
+			},
+			wantContain: []string{"Copied 'llama3.2' to 'my-llama'."},
+		},
+		{
+			name: "Destination exists",
+			args: []string{"cp", "team/assistant:rc1", "team/assistant:stable"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+			},
+			wantErr: "model 'team/assistant:stable' already exists, use --force to overwrite it",
+		},
+		{
+			name: "Overwrite destination with force",
+			args: []string{"cp", "team/assistant:rc1", "team/assistant:stable", "--force"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+				m.On("CopyModel", mock.Anything, "team/assistant:rc1", "team/assistant:stable").Return(nil)
+			},
+			wantContain: []string{"Copied 'team/assistant:rc1' to 'team/assistant:stable'."},
+		},
+		{
+			name: "Source not found",
+			args: []string{"cp", "missing", "copy"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+			},
+			wantErr: "model 'missing' not found on the server",
+		},
+		{
+			name:      "Same source and destination",
+			args:      []string{"cp", "llama3.2", "llama3.2:latest"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "source and destination are the same model",
+		},
+		{
+			name: "Error copying model",
+			args: []string{"cp", "team/assistant:rc1", "other"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListModels", mock.Anything).Return(mockModels, nil)
+				m.On("CopyModel", mock.Anything, "team/assistant:rc1", "other").Return(errors.New("copy error"))
+			},
+			wantErr: "failed to copy model: copy error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(cpCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(cpCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNormalizeModelName(t *testing.T) {
+	assert.Equal(t, "llama3.2:latest", normalizeModelName("llama3.2"))
+	assert.Equal(t, "team/assistant:stable", normalizeModelName("team/assistant:stable"))
+	assert.Equal(t, "localhost:5000/team/assistant:latest", normalizeModelName("localhost:5000/team/assistant"))
+}
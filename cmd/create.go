@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/modelfile"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// createCmd represents the create command
+var createCmd = &cobra.Command{
+	Use:   "create [model]",
+	Short: "Create a model from a Modelfile",
+	Long: `Create a model on the remote Ollama server from a Modelfile.
+
+The Modelfile is parsed locally. Supported instructions are FROM, PARAMETER, TEMPLATE,
+SYSTEM, ADAPTER, LICENSE and MESSAGE. Local model weights and adapters referenced by FROM
+and ADAPTER are uploaded to the server first, skipping files the server already has.
+
+Examples:
+  # Create a model from the Modelfile in the current directory
+  ollama-cli create team/assistant
+
+  # Create a model from a specific Modelfile and quantize it
+  ollama-cli create team/assistant -f models/assistant.Modelfile --quantize q4_K_M`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelName := args[0]
+		path, _ := cmd.Flags().GetString("file")
+		quantize, _ := cmd.Flags().GetString("quantize")
+
+		mode, err := progressMode(cmd, progressPlain)
+		if err != nil {
+			return err
+		}
+
+		mf, err := modelfile.ParseFile(path)
+		if err != nil {
+			return err
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		// Keep stdout clean for JSON progress events
+		status := output.Default
+		if mode == progressJSON {
+			status = output.GetStdErr()
+		}
+
+		ctx := context.Background()
+		upload := func(file string) (string, error) {
+			return uploadBlob(ctx, ollamaClient, status, file)
+		}
+
+		req, err := mf.CreateRequest(modelName, filepath.Dir(path), upload)
+		if err != nil {
+			return fmt.Errorf("invalid Modelfile %s: %w", path, err)
+		}
+		req.Quantize = quantize
+
+		renderer := newProgressRenderer(cmd.OutOrStdout(), mode, []string{modelName})
+		status.InfoPrintf("Creating model '%s'...\n", output.Highlight(modelName))
+
+		start := time.Now()
+		renderer.Start(modelName)
+		err = ollamaClient.CreateModel(ctx, req, renderer.Update)
+		renderer.Finish(modelName, err)
+		if err != nil {
+			return fmt.Errorf("failed to create model: %w", err)
+		}
+		duration := time.Since(start)
+
+		status.SuccessPrintf("Model '%s' created successfully in %s.\n", output.Highlight(modelName), colorizeDuration(duration))
+		return nil
+	},
+}
+
+// uploadBlob uploads a local file to the server unless a blob with the same digest
+// already exists, and returns the digest of the file. Status messages go to status.
+func uploadBlob(ctx context.Context, ollamaClient client.Client, status *output.ColorWriter, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	status.InfoPrintf("Computing digest of %s...\n", output.Highlight(filepath.Base(path)))
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	digest := fmt.Sprintf("sha256:%x", hash.Sum(nil))
+
+	exists, err := ollamaClient.HasBlob(ctx, digest)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		status.InfoPrintf("Using existing blob for %s\n", output.Highlight(filepath.Base(path)))
+		return digest, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	status.InfoPrintf("Uploading %s (%s)...\n", output.Highlight(filepath.Base(path)), formatSize(size))
+	if err := ollamaClient.CreateBlob(ctx, digest, file); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+func init() {
+	rootCmd.AddCommand(createCmd)
+
+	createCmd.Flags().StringP("file", "f", "Modelfile", "Path to the Modelfile")
+	createCmd.Flags().StringP("quantize", "q", "", "Quantize the model to this level (e.g. q4_K_M)")
+	addProgressFlag(createCmd, progressPlain)
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCommand(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.gguf"), []byte("weights"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Modelfile"), []byte("FROM llama3.2\nSYSTEM You are terse.\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Local.Modelfile"), []byte("FROM ./model.gguf\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Bad.Modelfile"), []byte("FROM llama3.2\nRUN rm -rf /\n"), 0o644))
+
+	origIsStdoutTerminal := isStdoutTerminal
+	defer func() { isStdoutTerminal = origIsStdoutTerminal }()
+	isStdoutTerminal = func() bool { return true }
+
+	isLocalBlob := mock.MatchedBy(func(digest string) bool { return len(digest) == len("sha256:")+64 })
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Create from an existing model",
+			args: []string{"create", "team/assistant", "-f", filepath.Join(dir, "Modelfile"), "-q", "q4_K_M"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("CreateModel", mock.Anything, mock.MatchedBy(func(req *api.CreateRequest) bool {
+					return req.Model == "team/assistant" && req.From == "llama3.2" && req.System == "You are terse." && req.Quantize == "q4_K_M"
+				}), mock.Anything).Return(nil)
+			},
+			wantContain: []string{"Creating model 'team/assistant'", "Model 'team/assistant' created successfully"},
+		},
+		{
+			name: "Upload a local file",
+			args: []string{"create", "custom", "-f", filepath.Join(dir, "Local.Modelfile")},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("HasBlob", mock.Anything, isLocalBlob).Return(false, nil)
+				m.On("CreateBlob", mock.Anything, isLocalBlob, mock.Anything).Return(nil)
+				m.On("CreateModel", mock.Anything, mock.MatchedBy(func(req *api.CreateRequest) bool {
+					return len(req.Files) == 1 && req.Files["model.gguf"] != ""
+				}), mock.Anything).Return(nil)
+			},
+			wantContain: []string{"Uploading model.gguf (7 B)", "Model 'custom' created successfully"},
+		},
+		{
+			name: "Skip existing blob",
+			args: []string{"create", "custom", "-f", filepath.Join(dir, "Local.Modelfile")},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("HasBlob", mock.Anything, isLocalBlob).Return(true, nil)
+				m.On("CreateModel", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			},
+			wantContain: []string{"Using existing blob for model.gguf"},
+		},
+		{
+			name:      "Invalid Modelfile",
+			args:      []string{"create", "custom", "-f", filepath.Join(dir, "Bad.Modelfile")},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   `line 2: unknown instruction "run"`,
+		},
+		{
+			name:      "Missing Modelfile",
+			args:      []string{"create", "custom", "-f", filepath.Join(dir, "Missing")},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "failed to open Modelfile",
+		},
+		{
+			name: "Upload error",
+			args: []string{"create", "custom", "-f", filepath.Join(dir, "Local.Modelfile")},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("HasBlob", mock.Anything, isLocalBlob).Return(false, nil)
+				m.On("CreateBlob", mock.Anything, isLocalBlob, mock.Anything).Return(errors.New("disk full"))
+			},
+			wantErr: "disk full",
+		},
+		{
+			name: "Error from client",
+			args: []string{"create", "custom", "-f", filepath.Join(dir, "Modelfile")},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("CreateModel", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection error"))
+			},
+			wantErr: "failed to create model: connection error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(createCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(createCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// embeddingBinaryMagic identifies files written with the binary output format
+const embeddingBinaryMagic = "OEMB"
+
+// embedding is a single embedded input together with its position in the input stream
+type embedding struct {
+	Index     int       `json:"index"`
+	Input     string    `json:"input"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// embedCmd represents the embed command
+var embedCmd = &cobra.Command{
+	Use:   "embed [model] [text...]",
+	Short: "Generate embeddings with an Ollama model",
+	Long: `Generate embeddings for one or more inputs using the /api/embed endpoint.
+
+Inputs are taken from the arguments following the model name, from --input-file, or from
+stdin when it is piped. Files and stdin are read line by line, one input per line; with
+--jsonl every line is parsed as JSON, either a string or an object whose --jsonl-field
+holds the text. Inputs are sent to the server in batches of --batch-size.
+
+Output formats:
+  json    A single JSON document with the model name and all embeddings
+  jsonl   One JSON object per line with index, input and embedding
+  csv     One row per input: index, input, followed by one column per dimension
+  binary  Little-endian header "OEMB", uint32 count, uint32 dimensions, then
+          count*dimensions float32 values in input order
+
+Examples:
+  # Embed a single text
+  ollama-cli embed nomic-embed-text "The sky is blue"
+
+  # Embed every line of a file and write JSONL
+  ollama-cli embed nomic-embed-text --input-file docs.txt -o jsonl > vectors.jsonl
+
+  # Embed a JSONL stream using the "body" field, truncated to 256 dimensions
+  cat docs.jsonl | ollama-cli embed nomic-embed-text --jsonl --jsonl-field body --dimensions 256
+
+  # Write a compact binary file
+  ollama-cli embed nomic-embed-text -f docs.txt -o binary --output-file vectors.bin`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeModelNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelName := args[0]
+		inputFile, _ := cmd.Flags().GetString("input-file")
+		jsonl, _ := cmd.Flags().GetBool("jsonl")
+		jsonlField, _ := cmd.Flags().GetString("jsonl-field")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		format, _ := cmd.Flags().GetString("output")
+		outputFile, _ := cmd.Flags().GetString("output-file")
+		truncate, _ := cmd.Flags().GetBool("truncate")
+		dimensions, _ := cmd.Flags().GetInt("dimensions")
+
+		format = strings.ToLower(format)
+		switch format {
+		case "json", "jsonl", "csv", "binary":
+		default:
+			return fmt.Errorf("invalid output format: %s", format)
+		}
+
+		if batchSize <= 0 {
+			return fmt.Errorf("batch size must be greater than 0")
+		}
+
+		if dimensions < 0 {
+			return fmt.Errorf("dimensions must not be negative")
+		}
+
+		// Collect inputs from arguments, the input file or stdin
+		inputs := args[1:]
+		if inputFile != "" {
+			var reader io.Reader
+			if inputFile == "-" {
+				reader = cmd.InOrStdin()
+			} else {
+				file, err := os.Open(inputFile)
+				if err != nil {
+					return fmt.Errorf("failed to open input file: %w", err)
+				}
+				defer file.Close()
+				reader = file
+			}
+
+			fileInputs, err := readEmbedInputs(reader, jsonl, jsonlField)
+			if err != nil {
+				return fmt.Errorf("failed to read inputs: %w", err)
+			}
+			inputs = append(inputs, fileInputs...)
+		} else if len(inputs) == 0 && isStdinPiped() {
+			stdinInputs, err := readEmbedInputs(cmd.InOrStdin(), jsonl, jsonlField)
+			if err != nil {
+				return fmt.Errorf("failed to read inputs from stdin: %w", err)
+			}
+			inputs = stdinInputs
+		}
+
+		if len(inputs) == 0 {
+			return fmt.Errorf("no inputs provided, pass text arguments, use --input-file or pipe inputs through stdin")
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		// Send the inputs in batches
+		embeddings := make([]embedding, 0, len(inputs))
+		for start := 0; start < len(inputs); start += batchSize {
+			end := min(start+batchSize, len(inputs))
+
+			req := &api.EmbedRequest{
+				Model:      modelName,
+				Input:      inputs[start:end],
+				Dimensions: dimensions,
+			}
+			if cmd.Flags().Changed("truncate") {
+				req.Truncate = &truncate
+			}
+
+			response, err := ollamaClient.Embed(context.Background(), req)
+			if err != nil {
+				return fmt.Errorf("failed to embed inputs %d-%d: %w", start+1, end, err)
+			}
+
+			if len(response.Embeddings) != end-start {
+				return fmt.Errorf("server returned %d embeddings for %d inputs", len(response.Embeddings), end-start)
+			}
+
+			for i, vector := range response.Embeddings {
+				embeddings = append(embeddings, embedding{
+					Index:     start + i,
+					Input:     inputs[start+i],
+					Embedding: vector,
+				})
+			}
+		}
+
+		// Write the embeddings to the output file or stdout
+		out := cmd.OutOrStdout()
+		if outputFile != "" {
+			file, err := os.Create(outputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		var writeErr error
+		switch format {
+		case "json":
+			writeErr = writeEmbeddingsJSON(out, modelName, embeddings)
+		case "jsonl":
+			writeErr = writeEmbeddingsJSONL(out, embeddings)
+		case "csv":
+			writeErr = writeEmbeddingsCSV(out, embeddings)
+		case "binary":
+			writeErr = writeEmbeddingsBinary(out, embeddings)
+		}
+		if writeErr != nil {
+			return fmt.Errorf("failed to write embeddings: %w", writeErr)
+		}
+
+		if outputFile != "" {
+			output.Default.SuccessPrintf("Wrote %d embeddings to '%s'\n", len(embeddings), output.Highlight(outputFile))
+		}
+
+		return nil
+	},
+}
+
+// readEmbedInputs reads one input per line, skipping empty lines.
+// In JSONL mode each line is either a JSON string or an object holding the text in field.
+func readEmbedInputs(r io.Reader, jsonl bool, field string) ([]string, error) {
+	var inputs []string
+
+	scanner := bufio.NewScanner(r)
+	// Allow long lines, e.g. whole documents on a single JSONL line
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !jsonl {
+			inputs = append(inputs, line)
+			continue
+		}
+
+		var value interface{}
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", lineNumber, err)
+		}
+
+		switch v := value.(type) {
+		case string:
+			inputs = append(inputs, v)
+		case map[string]interface{}:
+			text, ok := v[field].(string)
+			if !ok {
+				return nil, fmt.Errorf("line %d: field '%s' is missing or not a string", lineNumber, field)
+			}
+			inputs = append(inputs, text)
+		default:
+			return nil, fmt.Errorf("line %d: expected a JSON string or object", lineNumber)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return inputs, nil
+}
+
+// writeEmbeddingsJSON writes all embeddings as a single JSON document
+func writeEmbeddingsJSON(out io.Writer, modelName string, embeddings []embedding) error {
+	document := struct {
+		Model      string      `json:"model"`
+		Embeddings []embedding `json:"embeddings"`
+	}{
+		Model:      modelName,
+		Embeddings: embeddings,
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// writeEmbeddingsJSONL writes one JSON object per embedding
+func writeEmbeddingsJSONL(out io.Writer, embeddings []embedding) error {
+	encoder := json.NewEncoder(out)
+	for _, e := range embeddings {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEmbeddingsCSV writes one row per embedding with a column per dimension
+func writeEmbeddingsCSV(out io.Writer, embeddings []embedding) error {
+	w := csv.NewWriter(out)
+
+	dimensions := 0
+	if len(embeddings) > 0 {
+		dimensions = len(embeddings[0].Embedding)
+	}
+
+	header := []string{"index", "input"}
+	for i := 0; i < dimensions; i++ {
+		header = append(header, fmt.Sprintf("dim_%d", i))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range embeddings {
+		record := []string{strconv.Itoa(e.Index), e.Input}
+		for _, value := range e.Embedding {
+			record = append(record, strconv.FormatFloat(float64(value), 'g', -1, 32))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// writeEmbeddingsBinary writes the embeddings as little-endian float32 values
+// preceded by a header with the magic bytes, the count and the dimensions
+func writeEmbeddingsBinary(out io.Writer, embeddings []embedding) error {
+	dimensions := 0
+	if len(embeddings) > 0 {
+		dimensions = len(embeddings[0].Embedding)
+	}
+
+	w := bufio.NewWriter(out)
+	if _, err := w.WriteString(embeddingBinaryMagic); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(embeddings)))
+	binary.LittleEndian.PutUint32(header[4:8], uint32(dimensions))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	value := make([]byte, 4)
+	for _, e := range embeddings {
+		if len(e.Embedding) != dimensions {
+			return fmt.Errorf("embedding %d has %d dimensions, expected %d", e.Index, len(e.Embedding), dimensions)
+		}
+		for _, v := range e.Embedding {
+			binary.LittleEndian.PutUint32(value, math.Float32bits(v))
+			if _, err := w.Write(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(embedCmd)
+
+	embedCmd.Flags().StringP("input-file", "f", "", "File with one input per line (use '-' for stdin)")
+	embedCmd.Flags().Bool("jsonl", false, "Parse each input line as JSON (a string or an object)")
+	embedCmd.Flags().String("jsonl-field", "text", "Object field holding the text in JSONL mode")
+	embedCmd.Flags().Int("batch-size", 32, "Number of inputs sent per request")
+	embedCmd.Flags().StringP("output", "o", "json", "Output format (json, jsonl, csv, binary)")
+	embedCmd.Flags().String("output-file", "", "File to write the embeddings to (default is stdout)")
+	embedCmd.Flags().Bool("truncate", true, "Truncate inputs that exceed the model's context length")
+	embedCmd.Flags().Int("dimensions", 0, "Truncate the output embeddings to this many dimensions")
+}
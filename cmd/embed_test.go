@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEmbedCommand(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "JSON output from arguments",
+			args: []string{"embed", "embed-model", "hello", "world"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Embed", mock.Anything, mock.Anything).Return(&api.EmbedResponse{
+					Embeddings: [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+				}, nil)
+			},
+			wantContain: []string{`"model": "embed-model"`, `"input": "hello"`, `"input": "world"`, `0.4`},
+		},
+		{
+			name:  "Batched JSONL from stdin",
+			args:  []string{"embed", "embed-model", "--batch-size", "2", "-o", "jsonl"},
+			stdin: "one\n\ntwo\nthree\n",
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Embed", mock.Anything, mock.MatchedBy(func(req *api.EmbedRequest) bool {
+					return len(req.Input.([]string)) == 2
+				})).Return(&api.EmbedResponse{Embeddings: [][]float32{{0.1}, {0.2}}}, nil).Once()
+				m.On("Embed", mock.Anything, mock.MatchedBy(func(req *api.EmbedRequest) bool {
+					return len(req.Input.([]string)) == 1
+				})).Return(&api.EmbedResponse{Embeddings: [][]float32{{0.3}}}, nil).Once()
+			},
+			wantContain: []string{`{"index":0,"input":"one"`, `{"index":2,"input":"three"`},
+		},
+		{
+			name:  "JSONL input with field",
+			args:  []string{"embed", "embed-model", "--jsonl", "--jsonl-field", "body", "-o", "csv", "--dimensions", "2", "--truncate=false"},
+			stdin: `{"body":"first"}` + "\n" + `"second"` + "\n",
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Embed", mock.Anything, mock.MatchedBy(func(req *api.EmbedRequest) bool {
+					return req.Dimensions == 2 && req.Truncate != nil && !*req.Truncate
+				})).Return(&api.EmbedResponse{Embeddings: [][]float32{{0, 0.5}, {1, 0.5}}}, nil)
+			},
+			wantContain: []string{"index,input,dim_0,dim_1", "0,first,0,0.5", "1,second,1,0.5"},
+		},
+		{
+			name:      "Invalid output format",
+			args:      []string{"embed", "embed-model", "text", "-o", "xml"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "invalid output format: xml",
+		},
+		{
+			name:      "Negative dimensions",
+			args:      []string{"embed", "embed-model", "text", "--dimensions", "-1"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "dimensions must not be negative",
+		},
+		{
+			name:      "No inputs",
+			args:      []string{"embed", "embed-model"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "no inputs provided",
+		},
+		{
+			name: "Error from client",
+			args: []string{"embed", "embed-model", "text"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Embed", mock.Anything, mock.Anything).Return(nil, errors.New("connection error"))
+			},
+			wantErr: "failed to embed inputs 1-1: connection error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(embedCmd)
+			isStdinPiped = func() bool { return tt.stdin != "" }
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.SetIn(strings.NewReader(tt.stdin))
+			root.AddCommand(embedCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWriteEmbeddingsBinary(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeEmbeddingsBinary(&buf, []embedding{
+		{Index: 0, Embedding: []float32{1, 2, 3}},
+		{Index: 1, Embedding: []float32{4, 5, 6}},
+	})
+	assert.NoError(t, err)
+
+	data := buf.Bytes()
+	assert.Equal(t, 4+8+2*3*4, len(data))
+	assert.Equal(t, embeddingBinaryMagic, string(data[:4]))
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(data[4:8]))
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(data[8:12]))
+	assert.Equal(t, float32(6), math.Float32frombits(binary.LittleEndian.Uint32(data[len(data)-4:])))
+}
+
+func TestWriteEmbeddingsBinaryDimensionMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeEmbeddingsBinary(&buf, []embedding{
+		{Index: 0, Embedding: []float32{1, 2}},
+		{Index: 1, Embedding: []float32{3}},
+	})
+	assert.Error(t, err)
+}
+
+func TestReadEmbedInputsInvalidJSONL(t *testing.T) {
+	_, err := readEmbedInputs(strings.NewReader("not json\n"), true, "text")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1")
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/security"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:     "generate [model]",
+	Aliases: []string{"gen"},
+	Short:   "Generate a completion from an Ollama model",
+	Long: `Generate a single-shot completion from an Ollama model using the /api/generate endpoint.
+
+Unlike chat, generate sends a plain prompt without any conversation history, which makes it
+suitable for code completion, fill-in-the-middle (with --suffix) and scripting. The prompt can
+be provided with --prompt or piped through stdin. Only the generated text is written to stdout.
+
+NOTE: This command shares the chat setting in your configuration. If chat is disabled, you will
+be prompted to enable it on first use.
+
+Examples:
+  # Simple completion
+  ollama-cli generate llama3.2 --prompt "Write a haiku about the sea"
+
+  # Read the prompt from stdin
+  cat prompt.txt | ollama-cli generate llama3.2
+
+  # Fill-in-the-middle code completion
+  ollama-cli generate codellama:7b-code -p "def fib(n):" --suffix "    return result"
+
+  # Raw mode (no prompt template applied)
+  ollama-cli generate llama3.2 --raw -p "[INST] Why is the sky blue? [/INST]"
+
+  # Reuse the context of a previous call for a short conversational memory
+  ollama-cli generate llama3.2 -p "My name is Sam" --context-file ctx.json
+  ollama-cli generate llama3.2 -p "What is my name?" --context-file ctx.json
+
+  # Describe an image with a multimodal model
+  ollama-cli generate llava -p "What's in this image?" --image /path/to/image.jpg
+
+  # Write only JSON matching a schema, for use in pipelines
+  ollama-cli generate llama3.2 -p "List the people in this text: Ada met Alan." --schema people.json`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Skip completion if chat is not enabled
+		if !config.Current.ChatEnabled {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeModelNames(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Generate is gated by the same setting as chat
+		if !config.Current.ChatEnabled {
+			if err := enableChatCommand(); err != nil {
+				// If the error message is "chat command not enabled", return nil to exit gracefully
+				if err.Error() == "chat command not enabled" {
+					return nil
+				}
+				return err
+			}
+		}
+
+		modelName := args[0]
+		promptText, _ := cmd.Flags().GetString("prompt")
+		suffix, _ := cmd.Flags().GetString("suffix")
+		systemPrompt, _ := cmd.Flags().GetString("system")
+		template, _ := cmd.Flags().GetString("template")
+		raw, _ := cmd.Flags().GetBool("raw")
+		imagePaths, _ := cmd.Flags().GetStringArray("image")
+		noStream, _ := cmd.Flags().GetBool("no-stream")
+		temperature, _ := cmd.Flags().GetFloat64("temperature")
+		contextFile, _ := cmd.Flags().GetString("context-file")
+		showStats, _ := cmd.Flags().GetBool("stats")
+		stream := !noStream
+
+		if raw && template != "" {
+			return fmt.Errorf("--template cannot be used together with --raw")
+		}
+
+		// Read the prompt from stdin if it was not provided as a flag
+		if promptText == "" && isStdinPiped() {
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read prompt from stdin: %w", err)
+			}
+			promptText = string(data)
+		}
+
+		if promptText == "" && len(imagePaths) == 0 {
+			return fmt.Errorf("no prompt provided, use --prompt or pipe the prompt through stdin")
+		}
+
+		structured, err := structuredOutputFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Prepare model options
+		options := make(map[string]interface{})
+		if cmd.Flags().Changed("temperature") {
+			options["temperature"] = temperature
+		}
+
+		req := &api.GenerateRequest{
+			Model:    modelName,
+			Prompt:   promptText,
+			Suffix:   suffix,
+			System:   systemPrompt,
+			Template: template,
+			Raw:      raw,
+			Stream:   &stream,
+			Options:  options,
+		}
+		if structured != nil {
+			req.Format = structured.format
+		}
+
+		for _, imagePath := range imagePaths {
+			imageData, err := os.ReadFile(imagePath)
+			if err != nil {
+				return fmt.Errorf("failed to read image file: %w", err)
+			}
+			req.Images = append(req.Images, api.ImageData(imageData))
+		}
+
+		if contextFile != "" {
+			generateContext, err := loadGenerateContext(contextFile)
+			if err != nil {
+				return fmt.Errorf("failed to load context from file: %w", err)
+			}
+			req.Context = generateContext
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		stderr := output.GetStdErr()
+
+		var response *api.GenerateResponse
+		if structured != nil {
+			response, err = generateStructured(context.Background(), ollamaClient, out, req, structured)
+			if err != nil {
+				return err
+			}
+		} else {
+			// Print chunks as they arrive when streaming
+			var fn api.GenerateResponseFunc
+			if stream {
+				fn = func(response api.GenerateResponse) error {
+					fmt.Fprint(out, response.Response)
+					return nil
+				}
+			}
+
+			response, err = ollamaClient.Generate(context.Background(), req, fn)
+			if err != nil {
+				return fmt.Errorf("generate error: %w", err)
+			}
+
+			if !stream {
+				fmt.Fprint(out, response.Response)
+			}
+			if !strings.HasSuffix(response.Response, "\n") {
+				fmt.Fprintln(out)
+			}
+		}
+
+		// Validate the response for security issues, keeping stdout clean for scripts
+		validationResult := security.ValidateGenerateResponse(response)
+		for _, warning := range validationResult.Warnings {
+			stderr.WarningPrintf("%s\n", warning)
+		}
+		if validationResult.IsSuspicious {
+			stderr.WarningPrintf("%s\n", security.GetOutputWarningMessage())
+		}
+
+		// Display statistics if requested
+		if showStats {
+			displayStats(response.Metrics)
+		}
+
+		// Save the returned context for the next call
+		if contextFile != "" && len(response.Context) > 0 {
+			if err := saveGenerateContext(response.Context, contextFile); err != nil {
+				return fmt.Errorf("failed to save context to file: %w", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// generateStructured generates a reply constrained to JSON and writes it to out once
+// it is valid. Invalid replies are retried with the validation errors appended to the
+// prompt, as many times as allowed.
+func generateStructured(ctx context.Context, ollamaClient client.Client, out io.Writer, req *api.GenerateRequest, structured *structuredOutput) (*api.GenerateResponse, error) {
+	prompt := req.Prompt
+	for attempt := 0; ; attempt++ {
+		response, err := ollamaClient.Generate(ctx, req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("generate error: %w", err)
+		}
+
+		reply, err := structured.validate(response.Response)
+		if err == nil {
+			fmt.Fprintln(out, reply)
+			return response, nil
+		}
+		if attempt >= structured.retries {
+			return nil, fmt.Errorf("invalid structured reply: %w", err)
+		}
+
+		output.GetStdErr().WarningPrintf("Invalid reply, retrying (%d of %d): %s\n", attempt+1, structured.retries, err)
+		req.Prompt = fmt.Sprintf("%s\n\nYour previous reply was:\n%s\n\n%s", prompt, response.Response, structured.retryPrompt(err))
+	}
+}
+
+// loadGenerateContext loads a generate context from a JSON file.
+// A missing file is not an error, so the same path can be used for the first call.
+func loadGenerateContext(filePath string) ([]int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+
+	var generateContext []int
+	if err := json.Unmarshal(data, &generateContext); err != nil {
+		return nil, err
+	}
+
+	return generateContext, nil
+}
+
+// saveGenerateContext saves a generate context to a JSON file
+func saveGenerateContext(generateContext []int, filePath string) error {
+	data, err := json.Marshal(generateContext)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, data, 0600)
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().StringP("prompt", "p", "", "Prompt text (read from stdin if omitted)")
+	generateCmd.Flags().String("suffix", "", "Text that comes after the completion (fill-in-the-middle)")
+	generateCmd.Flags().StringP("system", "s", "", "System prompt overriding the model's default")
+	generateCmd.Flags().String("template", "", "Prompt template overriding the model's default")
+	generateCmd.Flags().Bool("raw", false, "Send the prompt without applying any template")
+	generateCmd.Flags().StringArrayP("image", "i", nil, "Path to an image file to include (can be repeated)")
+	generateCmd.Flags().Bool("no-stream", false, "Disable streaming (wait for complete response)")
+	generateCmd.Flags().Float64P("temperature", "t", 0.8, "Temperature for response generation (0.0 to 1.0)")
+	generateCmd.Flags().String("context-file", "", "JSON file to load the previous context from and save the new context to")
+	generateCmd.Flags().Bool("stats", false, "Display statistics about the generation (tokens, time, etc.)")
+	addStructuredOutputFlags(generateCmd)
+}
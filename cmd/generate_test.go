@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// resetFlags restores all flags of a command to their default values, since
+// cobra keeps flag values between executions of the same command
+func resetFlags(c *cobra.Command) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+func TestGenerateCommand(t *testing.T) {
+	// Save original config and restore it after the test
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	// Stdin is never piped in these tests
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+	isStdinPiped = func() bool { return false }
+
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Non-streaming completion",
+			args: []string{"generate", "test-model", "--prompt", "Hello", "--no-stream"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+					return req.Model == "test-model" && req.Prompt == "Hello" && !*req.Stream
+				}), mock.Anything).Return(&api.GenerateResponse{Response: "Hi there", Done: true}, nil)
+			},
+			wantContain: []string{"Hi there"},
+		},
+		{
+			name: "Streaming completion",
+			args: []string{"generate", "test-model", "--prompt", "Hello"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) {
+						fn := args.Get(2).(api.GenerateResponseFunc)
+						_ = fn(api.GenerateResponse{Response: "Hi "})
+						_ = fn(api.GenerateResponse{Response: "there", Done: true})
+					}).
+					Return(&api.GenerateResponse{Response: "Hi there", Done: true}, nil)
+			},
+			wantContain: []string{"Hi there"},
+		},
+		{
+			name: "Fill-in-the-middle and raw",
+			args: []string{"generate", "test-model", "-p", "def fib(n):", "--suffix", "return result", "--raw", "--no-stream"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+					return req.Suffix == "return result" && req.Raw
+				}), mock.Anything).Return(&api.GenerateResponse{Response: "    pass", Done: true}, nil)
+			},
+			wantContain: []string{"    pass"},
+		},
+		{
+			name:      "Template with raw",
+			args:      []string{"generate", "test-model", "-p", "Hello", "--raw", "--template", "{{ .Prompt }}"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "--template cannot be used together with --raw",
+		},
+		{
+			name:      "Missing prompt",
+			args:      []string{"generate", "test-model"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "no prompt provided",
+		},
+		{
+			name: "Error from client",
+			args: []string{"generate", "test-model", "-p", "Hello"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection error"))
+			},
+			wantErr: "generate error: connection error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(generateCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(generateCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Context file round trip", func(t *testing.T) {
+		resetFlags(generateCmd)
+		contextFile := filepath.Join(tmpDir, "ctx.json")
+		assert.NoError(t, os.WriteFile(contextFile, []byte("[1,2,3]"), 0600))
+
+		mockClient := client.NewMockClient()
+		mockClient.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+			return len(req.Context) == 3
+		}), mock.Anything).Return(&api.GenerateResponse{Response: "ok", Done: true, Context: []int{1, 2, 3, 4, 5}}, nil)
+		client.SetClientFactory(func() (client.Client, error) {
+			return mockClient, nil
+		})
+		defer client.ResetClientFactory()
+
+		var buf bytes.Buffer
+		root := &cobra.Command{Use: "ollama-cli"}
+		root.SetOut(&buf)
+		root.AddCommand(generateCmd)
+		root.SetArgs([]string{"generate", "test-model", "-p", "Hello", "--no-stream", "--context-file", contextFile})
+
+		assert.NoError(t, root.Execute())
+
+		data, err := os.ReadFile(contextFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "[1,2,3,4,5]", strings.TrimSpace(string(data)))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestLoadGenerateContextMissingFile(t *testing.T) {
+	generateContext, err := loadGenerateContext(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NoError(t, err)
+	assert.Nil(t, generateContext)
+}
+
+func TestGenerateStructuredOutput(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+	isStdinPiped = func() bool { return false }
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	assert.NoError(t, os.WriteFile(schemaFile, []byte(`{"type": "object", "required": ["name"]}`), 0644))
+
+	tests := []struct {
+		name      string
+		args      []string
+		setupMock func(*client.MockClientTestify)
+		wantErr   string
+		wantOut   string
+	}{
+		{
+			name: "JSON format",
+			args: []string{"generate", "test-model", "-p", "Hello", "--format", "json"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+					return string(req.Format) == `"json"`
+				}), mock.Anything).Return(&api.GenerateResponse{Response: ` {"greeting": "hi"} `, Done: true}, nil)
+			},
+			wantOut: "{\"greeting\": \"hi\"}\n",
+		},
+		{
+			name: "Schema is sent and invalid reply retried",
+			args: []string{"generate", "test-model", "-p", "Who?", "--schema", schemaFile, "--retries", "1"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+					return req.Prompt == "Who?" && string(req.Format) == `{"type":"object","required":["name"]}`
+				}), mock.Anything).Return(&api.GenerateResponse{Response: `{}`, Done: true}, nil).Once()
+				m.On("Generate", mock.Anything, mock.MatchedBy(func(req *api.GenerateRequest) bool {
+					return strings.Contains(req.Prompt, `- $: missing required property "name"`)
+				}), mock.Anything).Return(&api.GenerateResponse{Response: `{"name": "Ada"}`, Done: true}, nil).Once()
+			},
+			wantOut: "{\"name\": \"Ada\"}\n",
+		},
+		{
+			name: "Invalid reply without retries",
+			args: []string{"generate", "test-model", "-p", "Who?", "--schema", schemaFile},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("Generate", mock.Anything, mock.Anything, mock.Anything).Return(&api.GenerateResponse{Response: `not json`, Done: true}, nil).Once()
+			},
+			wantErr: "invalid structured reply: reply is not valid JSON",
+		},
+		{
+			name:      "Invalid format",
+			args:      []string{"generate", "test-model", "-p", "Hello", "--format", "xml"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "invalid format: xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(generateCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(generateCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOut, buf.String())
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
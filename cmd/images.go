@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/images"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+)
+
+// visionCapability is the capability of models that accept images
+const visionCapability = "vision"
+
+// loadImages loads images from files, URLs and data URIs, reading the image given as
+// - from stdin
+func loadImages(ctx context.Context, loader *images.Loader, sources []string, stdin io.Reader) ([]*images.Image, error) {
+	loaded := make([]*images.Image, 0, len(sources))
+	for _, source := range sources {
+		var img *images.Image
+		var err error
+		if source == stdinArg {
+			var data []byte
+			if data, err = io.ReadAll(stdin); err != nil {
+				return nil, fmt.Errorf("failed to read image from stdin: %w", err)
+			}
+			img, err = loader.Decode("stdin", data)
+		} else {
+			img, err = loader.Load(ctx, source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, img)
+	}
+	return loaded, nil
+}
+
+// messageImages returns the data of images to send with a message
+func messageImages(loaded []*images.Image) []api.ImageData {
+	var data []api.ImageData
+	for _, img := range loaded {
+		data = append(data, img.Data)
+	}
+	return data
+}
+
+// describeImage returns the source, format and size of an image
+func describeImage(img *images.Image) string {
+	source := img.Source
+	if strings.HasPrefix(source, "data:") {
+		source = "data URI"
+	}
+	details := []string{img.MIME}
+	if img.Width > 0 {
+		details = append(details, fmt.Sprintf("%dx%d", img.Width, img.Height))
+	}
+	if img.Resized {
+		details = append(details, "downscaled")
+	}
+	return fmt.Sprintf("%s (%s)", source, strings.Join(details, ", "))
+}
+
+// defaultImagePrompt is the message sent with images when there is no other text
+func defaultImagePrompt(count int) string {
+	if count > 1 {
+		return "What's in these images?"
+	}
+	return "What's in this image?"
+}
+
+// checkVision warns when the capabilities of a model do not include vision. Nothing
+// is reported when the capabilities are unknown, such as with older servers.
+func checkVision(ollamaClient client.Client, status *output.ColorWriter, model string) {
+	details, err := ollamaClient.GetModelDetails(context.Background(), model)
+	if err != nil || len(details.Capabilities) == 0 {
+		return
+	}
+	for _, capability := range details.Capabilities {
+		if string(capability) == visionCapability {
+			return
+		}
+	}
+	status.WarningPrintf("Model '%s' does not support images, they may be ignored.\n", model)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeTestPNG returns a blank PNG image of the given size
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestCheckVision(t *testing.T) {
+	tests := []struct {
+		name        string
+		details     *api.ShowResponse
+		err         error
+		wantWarning bool
+	}{
+		{name: "Vision model", details: &api.ShowResponse{Capabilities: []model.Capability{model.CapabilityCompletion, model.CapabilityVision}}},
+		{name: "Text model", details: &api.ShowResponse{Capabilities: []model.Capability{model.CapabilityCompletion}}, wantWarning: true},
+		{name: "Unknown capabilities", details: &api.ShowResponse{}},
+		{name: "Model details unavailable", err: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := client.NewMockClient()
+			mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(tt.details, tt.err)
+
+			var status bytes.Buffer
+			checkVision(mockClient, output.NewColorWriter(&status), "test-model")
+			assert.Equal(t, tt.wantWarning, strings.Contains(status.String(), "Model 'test-model' does not support images"))
+		})
+	}
+}
+
+func TestChatImages(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origIsStdinPiped := isStdinPiped
+	defer func() { isStdinPiped = origIsStdinPiped }()
+	isStdinPiped = func() bool { return false }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	small := encodeTestPNG(t, 40, 20)
+	large := encodeTestPNG(t, 400, 200)
+	file := filepath.Join(t.TempDir(), "large.png")
+	require.NoError(t, os.WriteFile(file, large, 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(small)
+	}))
+	defer server.Close()
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(small)
+
+	mockClient := client.NewMockClient()
+	client.SetClientFactory(func() (client.Client, error) {
+		return mockClient, nil
+	})
+	defer client.ResetClientFactory()
+
+	mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+		Capabilities: []model.Capability{model.CapabilityCompletion},
+	}, nil)
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		msg := req.Messages[len(req.Messages)-1]
+		if msg.Content != "What's in these images?" || len(msg.Images) != 3 {
+			return false
+		}
+		// The large image is downscaled, the others are sent as they are
+		cfg, err := png.DecodeConfig(bytes.NewReader(msg.Images[0]))
+		return err == nil && cfg.Width == 100 && cfg.Height == 50 &&
+			bytes.Equal(msg.Images[1], small) && bytes.Equal(msg.Images[2], small)
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "Three gray rectangles"},
+	}, nil).Once()
+
+	resetFlags(chatCmd)
+	defer resetFlags(chatCmd)
+	var out bytes.Buffer
+	root := &cobra.Command{Use: "ollama-cli"}
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.AddCommand(chatCmd)
+	root.SetArgs([]string{"chat", "test-model", "--no-stream", "--max-image-size", "100",
+		"-i", file, "--image", server.URL + "/small.png", "-i", dataURI})
+
+	require.NoError(t, root.Execute())
+	mockClient.AssertExpectations(t)
+
+	assert.Equal(t, "Three gray rectangles\n", out.String())
+	assert.Contains(t, status.String(), "Model 'test-model' does not support images")
+	assert.Contains(t, status.String(), "Image: "+file+" (image/png, 100x50, downscaled)\n")
+	assert.Contains(t, status.String(), "Image: "+server.URL+"/small.png (image/png, 40x20)\n")
+	assert.Contains(t, status.String(), "Image: data URI (image/png, 40x20)\n")
+
+	// Files that are not images are rejected before anything is sent
+	text := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("not an image"), 0644))
+	resetFlags(chatCmd)
+	root.SetArgs([]string{"chat", "test-model", "-p", "What is this?", "-i", text})
+	assert.ErrorContains(t, root.Execute(), "is not a supported image")
+}
+
+func TestInteractiveChatImage(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	file := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(file, encodeTestPNG(t, 300, 300), 0644))
+
+	mockClient := client.NewMockClient()
+	mockClient.On("GetModelDetails", mock.Anything, "test-model").Return(&api.ShowResponse{
+		Capabilities: []model.Capability{model.CapabilityVision},
+	}, nil)
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		msg := req.Messages[len(req.Messages)-1]
+		if msg.Content != "Describe it" || len(msg.Images) != 1 {
+			return false
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(msg.Images[0]))
+		return err == nil && cfg.Width == 64 && cfg.Height == 64
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "A gray square"},
+	}, nil).Once()
+
+	chat, _ := newTestInteractiveChat(mockClient, strings.Join([]string{
+		"/image " + file + " Describe it",
+		"/image missing.png",
+		"/exit",
+	}, "\n"))
+	chat.maxImageSize = 64
+
+	require.NoError(t, chat.run())
+	mockClient.AssertExpectations(t)
+
+	assert.NotContains(t, status.String(), "does not support images")
+	assert.Contains(t, status.String(), "Image: "+file+" (image/png, 64x64, downscaled)")
+	assert.Contains(t, status.String(), "failed to read image missing.png")
+}
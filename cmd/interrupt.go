@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// errInterrupted is returned when the user interrupts a response with Ctrl-C
+var errInterrupted = errors.New("response interrupted")
+
+// truncatedMarker marks a partial answer kept after an interrupt
+const truncatedMarker = "[truncated]"
+
+// notifyInterrupt relays interrupts to a channel until stopInterrupt is called,
+// they can be overridden in tests
+var notifyInterrupt = func(c chan<- os.Signal) { signal.Notify(c, os.Interrupt) }
+var stopInterrupt = func(c chan<- os.Signal) { signal.Stop(c) }
+
+// exitProcess ends the process, it can be overridden in tests
+var exitProcess = os.Exit
+
+// forceExitTimeout is how long a second interrupt waits for the interrupted request
+// to return, before the process exits without saving
+var forceExitTimeout = 5 * time.Second
+
+// interruptibleContext returns a context that is canceled by the first interrupt,
+// instead of the interrupt ending the process. stop restores the default handling
+// of interrupts and must be called once the request is done. It reports whether a
+// second interrupt asked to exit, in which case the caller saves the partial answer
+// and exits. If the request does not return in time after the second interrupt, the
+// process exits at once.
+func interruptibleContext() (ctx context.Context, stop func() (forced bool)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	forced := false
+	notifyInterrupt(signals)
+
+	go func() {
+		defer close(exited)
+		for interrupted := false; ; interrupted = true {
+			select {
+			case <-signals:
+				if interrupted {
+					forced = true
+					select {
+					case <-done:
+					case <-time.After(forceExitTimeout):
+						exitProcess(130)
+					}
+					return
+				}
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return ctx, func() bool {
+		stopInterrupt(signals)
+		close(done)
+		// forced is only read once the goroutine has returned
+		<-exited
+		cancel()
+		return forced
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// interruptStreamedResponse makes a mocked chat stream the start of an answer, then
+// wait until it is interrupted by signals sent to the captured channel, twice to
+// leave the chat
+func interruptStreamedResponse(signals *chan<- os.Signal, interrupts int) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		ctx := args.Get(0).(context.Context)
+		fn := args.Get(2).(client.ChatChunkFunc)
+		_ = fn(client.ChatChunk{Content: "Once upon"})
+		_ = fn(client.ChatChunk{Content: " a time"})
+		for range interrupts {
+			*signals <- os.Interrupt
+		}
+		<-ctx.Done()
+	}
+}
+
+// captureInterrupts replaces the signal handling with a channel the test sends to
+func captureInterrupts(t *testing.T) *chan<- os.Signal {
+	origNotify, origStop, origExit := notifyInterrupt, stopInterrupt, exitProcess
+	t.Cleanup(func() { notifyInterrupt, stopInterrupt, exitProcess = origNotify, origStop, origExit })
+
+	signals := new(chan<- os.Signal)
+	notifyInterrupt = func(c chan<- os.Signal) { *signals = c }
+	stopInterrupt = func(c chan<- os.Signal) {}
+	exitProcess = func(code int) { t.Errorf("unexpected exit with code %d", code) }
+	return signals
+}
+
+// captureExit replaces exitProcess with a function recording the exit code
+func captureExit(t *testing.T) *int {
+	t.Helper()
+	code := new(int)
+	exitProcess = func(c int) { *code = c }
+	return code
+}
+
+func TestInterruptibleContext(t *testing.T) {
+	signals := captureInterrupts(t)
+
+	// A single interrupt cancels the context
+	ctx, stop := interruptibleContext()
+	*signals <- os.Interrupt
+	<-ctx.Done()
+	assert.False(t, stop())
+
+	// A second one asks to exit
+	ctx, stop = interruptibleContext()
+	*signals <- os.Interrupt
+	*signals <- os.Interrupt
+	<-ctx.Done()
+	assert.True(t, stop())
+
+	// The process exits if the request does not return in time
+	origTimeout := forceExitTimeout
+	defer func() { forceExitTimeout = origTimeout }()
+	forceExitTimeout = time.Millisecond
+	exited := make(chan int, 1)
+	exitProcess = func(code int) { exited <- code }
+	_, stop = interruptibleContext()
+	*signals <- os.Interrupt
+	*signals <- os.Interrupt
+	assert.Equal(t, 130, <-exited)
+	assert.True(t, stop())
+}
+
+func TestInteractiveChatInterrupt(t *testing.T) {
+	signals := captureInterrupts(t)
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	mockClient := client.NewMockClient()
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 2
+	}), mock.Anything).Run(interruptStreamedResponse(signals, 1)).Return(nil, context.Canceled).Once()
+
+	// The chat continues after the interrupt, with the partial answer
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 4 && req.Messages[2].Content == "Once upon a time\n\n"+truncatedMarker
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "There was a king."},
+	}, nil).Once()
+
+	chat, out := newTestInteractiveChat(mockClient, "Tell me a story\nGo on\n")
+	chat.settings.stream = true
+	chat.outputFile = filepath.Join(t.TempDir(), "chat.json")
+	require.NoError(t, chat.run())
+	mockClient.AssertExpectations(t)
+
+	assert.Contains(t, out.String(), "Once upon a time\n")
+	assert.Contains(t, status.String(), "Response interrupted")
+	saved, err := loadMessagesFromFile(chat.outputFile)
+	require.NoError(t, err)
+	require.Len(t, saved, 5)
+	assert.Equal(t, "There was a king.", saved[4].Content)
+}
+
+func TestChatCommandInterrupt(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+	tests := []struct {
+		name       string
+		interrupts int
+		wantExit   int
+	}{
+		{name: "Interrupted", interrupts: 1},
+		{name: "Forced exit", interrupts: 2, wantExit: 130},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := captureInterrupts(t)
+			code := captureExit(t)
+
+			mockClient := client.NewMockClient()
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+			mockClient.On("ChatWithModel", mock.Anything, mock.Anything, mock.Anything).
+				Run(interruptStreamedResponse(signals, tt.interrupts)).Return(nil, context.Canceled).Once()
+
+			outputFile := filepath.Join(t.TempDir(), "chat.json")
+			resetFlags(chatCmd)
+			var out bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.AddCommand(chatCmd)
+			root.SetArgs([]string{"chat", "test-model", "-p", "Tell me a story", "--output-file", outputFile})
+
+			// The partial answer is saved before the command fails or the process exits
+			err := root.Execute()
+			assert.ErrorIs(t, err, errInterrupted)
+			assert.Equal(t, tt.wantExit, *code)
+			saved, err := loadMessagesFromFile(outputFile)
+			require.NoError(t, err)
+			require.Len(t, saved, 3)
+			assert.True(t, strings.HasSuffix(saved[2].Content, truncatedMarker))
+		})
+	}
+}
+
+func TestInteractiveChatForcedExit(t *testing.T) {
+	signals := captureInterrupts(t)
+	code := captureExit(t)
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+	mockClient := client.NewMockClient()
+	mockClient.On("ChatWithModel", mock.Anything, mock.Anything, mock.Anything).
+		Run(interruptStreamedResponse(signals, 2)).Return(nil, context.Canceled).Once()
+
+	chat, _ := newTestInteractiveChat(mockClient, "Tell me a story\nGo on\n")
+	chat.settings.stream = true
+	chat.outputFile = filepath.Join(t.TempDir(), "chat.json")
+	require.NoError(t, chat.run())
+	mockClient.AssertExpectations(t)
+
+	// The chat is left with the partial answer saved
+	assert.Equal(t, 130, *code)
+	saved, err := loadMessagesFromFile(chat.outputFile)
+	require.NoError(t, err)
+	require.Len(t, saved, 3)
+	assert.Equal(t, "Once upon a time\n\n"+truncatedMarker, saved[2].Content)
+}
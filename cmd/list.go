@@ -156,19 +156,29 @@ func formatTime(t time.Time) string {
 	now := timeNow()
 	diff := now.Sub(t)
 
+	// Times in the future, e.g. when a loaded model expires
+	if diff < 0 {
+		return output.Warning("in " + formatElapsed(-diff))
+	}
+
+	return output.Warning(formatElapsed(diff) + " ago")
+}
+
+// formatElapsed formats a duration in the largest fitting unit
+func formatElapsed(diff time.Duration) string {
 	switch {
 	case diff < time.Hour:
 		minutes := int(diff.Minutes())
-		return output.Warning(fmt.Sprintf("%d minutes ago", minutes))
+		return fmt.Sprintf("%d minutes", minutes)
 	case diff < 24*time.Hour:
 		hours := int(diff.Hours())
-		return output.Warning(fmt.Sprintf("%d hours ago", hours))
+		return fmt.Sprintf("%d hours", hours)
 	case diff < 30*24*time.Hour:
 		days := int(diff.Hours() / 24)
-		return output.Warning(fmt.Sprintf("%d days ago", days))
+		return fmt.Sprintf("%d days", days)
 	default:
 		months := int(diff.Hours() / 24 / 30)
-		return output.Warning(fmt.Sprintf("%d months ago", months))
+		return fmt.Sprintf("%d months", months)
 	}
 }
 
@@ -326,6 +326,11 @@ func TestFormatTime(t *testing.T) {
 			time: now.Add(-2 * 30 * 24 * time.Hour),
 			want: "ago",
 		},
+		{
+			name: "Minutes in the future",
+			time: now.Add(5*time.Minute + time.Second),
+			want: "in 5 minutes",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// loadCmd represents the load command
+var loadCmd = &cobra.Command{
+	Use:   "load [model]",
+	Short: "Preload a model into memory",
+	Long: `Preload a model into memory on the remote Ollama server, so the first request
+does not have to wait for the model to load.
+
+The model stays loaded for the --keep-alive duration. A negative duration keeps the
+model loaded until it is stopped with 'ollama-cli stop'.
+
+Examples:
+  # Load a model for the default 5 minutes
+  ollama-cli load llama3.2
+
+  # Keep a model loaded for 30 minutes
+  ollama-cli load llama3.2 --keep-alive 30m
+
+  # Keep a model loaded indefinitely
+  ollama-cli load llama3.2 --keep-alive -1s`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeModelNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelName := args[0]
+		keepAlive, _ := cmd.Flags().GetDuration("keep-alive")
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		output.Default.InfoPrintf("Loading model '%s'...\n", output.Highlight(modelName))
+		start := time.Now()
+		if err := ollamaClient.LoadModel(context.Background(), modelName, keepAlive); err != nil {
+			return fmt.Errorf("failed to load model: %w", err)
+		}
+		duration := time.Since(start)
+
+		keepAliveStr := keepAlive.String()
+		if keepAlive < 0 {
+			keepAliveStr = "forever"
+		}
+		output.Default.SuccessPrintf("Model '%s' loaded in %s (keep alive: %s).\n", output.Highlight(modelName), colorizeDuration(duration), keepAliveStr)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loadCmd)
+
+	loadCmd.Flags().Duration("keep-alive", 5*time.Minute, "How long the model stays loaded (negative keeps it loaded indefinitely)")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoadCommand(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Default keep alive",
+			args: []string{"load", "model1"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("LoadModel", mock.Anything, "model1", 5*time.Minute).Return(nil)
+			},
+			wantContain: []string{"Loading model 'model1'", "Model 'model1' loaded", "keep alive: 5m0s"},
+		},
+		{
+			name: "Custom keep alive",
+			args: []string{"load", "model1", "--keep-alive", "30m"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("LoadModel", mock.Anything, "model1", 30*time.Minute).Return(nil)
+			},
+			wantContain: []string{"keep alive: 30m0s"},
+		},
+		{
+			name: "Keep loaded indefinitely",
+			args: []string{"load", "model1", "--keep-alive", "-1s"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("LoadModel", mock.Anything, "model1", -1*time.Second).Return(nil)
+			},
+			wantContain: []string{"keep alive: forever"},
+		},
+		{
+			name: "Error from client",
+			args: []string{"load", "model1"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("LoadModel", mock.Anything, "model1", 5*time.Minute).Return(errors.New("model not found"))
+			},
+			wantErr: "failed to load model: model not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(loadCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(loadCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
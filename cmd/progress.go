@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// Progress display modes selected with --progress
+const (
+	progressAuto  = "auto"
+	progressTTY   = "tty"
+	progressPlain = "plain"
+	progressJSON  = "json"
+)
+
+// progressRenderer displays the progress of pull, push and create operations
+type progressRenderer interface {
+	// Start is called when the operation on a model starts
+	Start(model string)
+	// Update is called for every progress event
+	Update(event client.ProgressEvent) error
+	// Finish is called when the operation on a model is done, err is nil on success
+	Finish(model string, err error)
+}
+
+// addProgressFlag adds the --progress flag to a command, where auto resolves to
+// nonTTYMode when stdout is not a terminal
+func addProgressFlag(cmd *cobra.Command, nonTTYMode string) {
+	cmd.Flags().String("progress", progressAuto, fmt.Sprintf("Progress display (auto, tty, plain, json); auto uses tty on a terminal and %s otherwise", nonTTYMode))
+}
+
+// progressMode reads and validates the --progress flag, resolving auto to tty when
+// stdout is a terminal and to nonTTYMode otherwise
+func progressMode(cmd *cobra.Command, nonTTYMode string) (string, error) {
+	mode, _ := cmd.Flags().GetString("progress")
+
+	switch mode = strings.ToLower(mode); mode {
+	case progressAuto, "":
+		if isStdoutTerminal() {
+			return progressTTY, nil
+		}
+		return nonTTYMode, nil
+	case progressTTY, progressPlain, progressJSON:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid progress mode: %s", mode)
+	}
+}
+
+// newProgressRenderer creates the renderer for the given mode and models
+func newProgressRenderer(out io.Writer, mode string, models []string) progressRenderer {
+	switch mode {
+	case progressJSON:
+		return &jsonProgress{encoder: json.NewEncoder(out)}
+	case progressPlain:
+		return newProgressBoard(out, models, false)
+	default:
+		if len(models) == 1 {
+			return &ttyProgress{out: out}
+		}
+		return newProgressBoard(out, models, true)
+	}
+}
+
+// ttyProgress shows the progress of a single model on one continuously updated line
+type ttyProgress struct {
+	out     io.Writer
+	midLine bool
+}
+
+func (p *ttyProgress) Start(model string) {}
+
+func (p *ttyProgress) Update(event client.ProgressEvent) error {
+	if event.Status == "" {
+		return nil
+	}
+
+	// Calculate percentage if total is available
+	var percentStr string
+	var sizeStr string
+	if event.Total > 0 {
+		percent := float64(event.Completed) / float64(event.Total) * 100
+		percentStr = fmt.Sprintf("[%s] ", output.Info(fmt.Sprintf("%.1f%%", percent)))
+		sizeStr = fmt.Sprintf("[%s] ", output.Warning(fmt.Sprintf("%.1f/%.1f MB", float64(event.Completed)/1024/1024, float64(event.Total)/1024/1024)))
+	}
+
+	fmt.Fprintf(p.out, "\r\033[2K%s: %s%s%s", output.Highlight(event.Model), percentStr, sizeStr, output.Info(event.Status))
+	p.midLine = true
+	if event.Total > 0 && event.Completed == event.Total {
+		fmt.Fprintln(p.out) // Add newline when complete
+		p.midLine = false
+	}
+	return nil
+}
+
+func (p *ttyProgress) Finish(model string, err error) {
+	if p.midLine {
+		fmt.Fprintln(p.out)
+		p.midLine = false
+	}
+}
+
+// jsonProgress writes every progress event as a JSON line, which is safe for concurrent use
+type jsonProgress struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// jsonProgressEvent is a progress event with the error of a failed operation
+type jsonProgressEvent struct {
+	client.ProgressEvent
+	Error string `json:"error,omitempty"`
+}
+
+func (p *jsonProgress) Start(model string) {}
+
+func (p *jsonProgress) Update(event client.ProgressEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.encoder.Encode(jsonProgressEvent{ProgressEvent: event})
+}
+
+func (p *jsonProgress) Finish(model string, err error) {
+	if err == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.encoder.Encode(jsonProgressEvent{
+		ProgressEvent: client.ProgressEvent{Model: model, Status: "error"},
+		Error:         err.Error(),
+	})
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/output"
+)
+
+// progressRenderInterval limits how often the progress rows are redrawn
+const progressRenderInterval = 100 * time.Millisecond
+
+// progressState is the progress of the operation on a single model
+type progressState struct {
+	model     string
+	status    string
+	digest    string
+	completed int64
+	total     int64
+	// layerStart and layerCompleted are used to measure the throughput of the current layer
+	layerStart     time.Time
+	layerCompleted int64
+	rate           float64
+	started        bool
+	start          time.Time
+	duration       time.Duration
+	done           bool
+	err            error
+}
+
+// progressBoard renders the progress of several concurrent operations with one row per
+// model. On a terminal the rows are redrawn in place, otherwise every status change is
+// printed on its own line.
+type progressBoard struct {
+	mu         sync.Mutex
+	out        io.Writer
+	tty        bool
+	states     []*progressState
+	byModel    map[string]*progressState
+	nameWidth  int
+	rendered   int
+	lastRender time.Time
+}
+
+// newProgressBoard creates a board for the given models
+func newProgressBoard(out io.Writer, models []string, tty bool) *progressBoard {
+	board := &progressBoard{out: out, tty: tty, byModel: make(map[string]*progressState)}
+	for _, model := range models {
+		state := &progressState{model: model}
+		board.states = append(board.states, state)
+		board.byModel[model] = state
+		board.nameWidth = max(board.nameWidth, len(model))
+	}
+	if tty {
+		board.renderLocked()
+	}
+	return board
+}
+
+// Start marks the operation on a model as started
+func (b *progressBoard) Start(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.byModel[model]
+	state.started = true
+	state.start = time.Now()
+	b.changed(state, "starting")
+}
+
+// Update records a progress event
+func (b *progressBoard) Update(progress client.ProgressEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.byModel[progress.Model]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if progress.Digest != state.digest {
+		// A new layer, restart the throughput measurement
+		state.digest = progress.Digest
+		state.layerStart = now
+		state.layerCompleted = progress.Completed
+		state.rate = 0
+	} else if elapsed := now.Sub(state.layerStart).Seconds(); elapsed > 0 {
+		state.rate = float64(progress.Completed-state.layerCompleted) / elapsed
+	}
+	state.completed = progress.Completed
+	state.total = progress.Total
+
+	if progress.Status != state.status {
+		b.changed(state, progress.Status)
+		return nil
+	}
+	if b.tty && now.Sub(b.lastRender) >= progressRenderInterval {
+		b.renderLocked()
+	}
+	return nil
+}
+
+// Finish marks the operation on a model as done, failed if err is not nil
+func (b *progressBoard) Finish(model string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.byModel[model]
+	state.done = true
+	state.err = err
+	state.duration = time.Since(state.start)
+	if err != nil {
+		b.changed(state, "failed: "+err.Error())
+		return
+	}
+	b.changed(state, "done")
+}
+
+// changed records a new status and shows it, must be called with the lock held
+func (b *progressBoard) changed(state *progressState, status string) {
+	state.status = status
+	if b.tty {
+		b.renderLocked()
+		return
+	}
+	fmt.Fprintf(b.out, "%s: %s\n", state.model, status)
+}
+
+// renderLocked redraws all rows in place, must be called with the lock held
+func (b *progressBoard) renderLocked() {
+	var sb strings.Builder
+	if b.rendered > 0 {
+		// Move the cursor back to the first row
+		fmt.Fprintf(&sb, "\033[%dA", b.rendered)
+	}
+	for _, state := range b.states {
+		fmt.Fprintf(&sb, "\r\033[2K%s\n", b.row(state))
+	}
+	fmt.Fprint(b.out, sb.String())
+
+	b.rendered = len(b.states)
+	b.lastRender = time.Now()
+}
+
+// row formats the progress row of a single model
+func (b *progressBoard) row(state *progressState) string {
+	name := output.Highlight(fmt.Sprintf("%-*s", b.nameWidth, state.model))
+
+	switch {
+	case !state.started:
+		return fmt.Sprintf("%s  %s", name, output.Info("waiting"))
+	case state.done && state.err != nil:
+		return fmt.Sprintf("%s  %s", name, output.Error(state.status))
+	case state.done:
+		return fmt.Sprintf("%s  %s", name, output.Success("done in "+state.duration.Round(time.Second).String()))
+	case state.total > 0:
+		percent := float64(state.completed) / float64(state.total) * 100
+		return fmt.Sprintf("%s  [%s] [%s] %s  %s  %s",
+			name,
+			output.Info(fmt.Sprintf("%5.1f%%", percent)),
+			output.Warning(fmt.Sprintf("%.1f/%.1f MB", float64(state.completed)/1024/1024, float64(state.total)/1024/1024)),
+			formatThroughput(state.rate),
+			formatETA(state.total-state.completed, state.rate),
+			output.Info(state.status),
+		)
+	default:
+		return fmt.Sprintf("%s  %s", name, output.Info(state.status))
+	}
+}
+
+// formatThroughput formats a transfer rate given in bytes per second
+func formatThroughput(rate float64) string {
+	if rate <= 0 {
+		return "-- MB/s"
+	}
+	return fmt.Sprintf("%.1f MB/s", rate/1024/1024)
+}
+
+// formatETA estimates the time needed to transfer the remaining bytes at the given rate
+func formatETA(remaining int64, rate float64) string {
+	if rate <= 0 || remaining <= 0 {
+		return "ETA --"
+	}
+	eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+	return "ETA " + eta.Round(time.Second).String()
+}
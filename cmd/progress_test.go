@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressMode(t *testing.T) {
+	origIsStdoutTerminal := isStdoutTerminal
+	defer func() { isStdoutTerminal = origIsStdoutTerminal }()
+
+	tests := []struct {
+		name     string
+		flag     string
+		terminal bool
+		want     string
+		wantErr  string
+	}{
+		{"Auto on a terminal", "auto", true, progressTTY, ""},
+		{"Auto when redirected", "auto", false, progressJSON, ""},
+		{"Explicit plain", "PLAIN", true, progressPlain, ""},
+		{"Invalid mode", "fancy", true, "", "invalid progress mode: fancy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isStdoutTerminal = func() bool { return tt.terminal }
+
+			cmd := &cobra.Command{Use: "test"}
+			addProgressFlag(cmd, progressJSON)
+			assert.NoError(t, cmd.Flags().Set("progress", tt.flag))
+
+			mode, err := progressMode(cmd, progressJSON)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mode)
+		})
+	}
+}
+
+func TestTTYProgress(t *testing.T) {
+	var buf bytes.Buffer
+	renderer := newProgressRenderer(&buf, progressTTY, []string{"model1"})
+
+	renderer.Start("model1")
+	assert.NoError(t, renderer.Update(client.ProgressEvent{Model: "model1", Status: "pulling manifest"}))
+	assert.NoError(t, renderer.Update(client.ProgressEvent{Model: "model1", Status: "pulling ad361f123f77", Total: 2 * 1024 * 1024, Completed: 1024 * 1024}))
+	renderer.Finish("model1", nil)
+
+	assert.Contains(t, buf.String(), "model1: pulling manifest")
+	assert.Contains(t, buf.String(), "[50.0%] [1.0/2.0 MB] pulling ad361f123f77")
+	assert.True(t, strings.HasSuffix(buf.String(), "\n"), "Output should end with a newline")
+}
+
+func TestJSONProgress(t *testing.T) {
+	var buf bytes.Buffer
+	renderer := newProgressRenderer(&buf, progressJSON, []string{"model1"})
+
+	renderer.Start("model1")
+	assert.NoError(t, renderer.Update(client.ProgressEvent{Model: "model1", Status: "pulling manifest"}))
+	renderer.Finish("model1", errors.New("file does not exist"))
+
+	assert.Equal(t,
+		`{"model":"model1","status":"pulling manifest"}`+"\n"+
+			`{"model":"model1","status":"error","error":"file does not exist"}`+"\n",
+		buf.String())
+}
+
+func TestPlainProgress(t *testing.T) {
+	var buf bytes.Buffer
+	renderer := newProgressRenderer(&buf, progressPlain, []string{"model1"})
+
+	renderer.Start("model1")
+	assert.NoError(t, renderer.Update(client.ProgressEvent{Model: "model1", Status: "pulling ad361f123f77", Total: 100, Completed: 10}))
+	assert.NoError(t, renderer.Update(client.ProgressEvent{Model: "model1", Status: "pulling ad361f123f77", Total: 100, Completed: 20}))
+	renderer.Finish("model1", nil)
+
+	assert.Equal(t, "model1: starting\nmodel1: pulling ad361f123f77\nmodel1: done\n", buf.String())
+}
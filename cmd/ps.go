@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// psCmd represents the ps command
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List models currently loaded in memory",
+	Long: `List the models that are currently loaded into memory on the remote Ollama server,
+including their size, the part loaded into VRAM, the context length and when they expire.
+
+Examples:
+  # List loaded models
+  ollama-cli ps
+
+  # Show additional details
+  ollama-cli ps -o wide
+
+  # Refresh the list every 5 seconds until interrupted
+  ollama-cli ps --watch --interval 5s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		format = strings.ToLower(format)
+		switch format {
+		case "table", "wide", "json":
+		default:
+			return fmt.Errorf("invalid output format: %s", format)
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if !watch {
+			return renderRunningModels(context.Background(), ollamaClient, out, format)
+		}
+
+		if interval <= 0 {
+			return fmt.Errorf("interval must be greater than 0")
+		}
+
+		// Stop watching on Ctrl-C
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		return watchRunningModels(ctx, ollamaClient, out, format, interval)
+	},
+}
+
+// watchRunningModels renders the running models every interval until the context is done
+func watchRunningModels(ctx context.Context, ollamaClient client.Client, out io.Writer, format string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Only clear the screen for human readable output
+		if format != "json" {
+			fmt.Fprint(out, clearScreen)
+			fmt.Fprintf(out, "%s\n\n", output.Info(fmt.Sprintf("Every %s: ollama-cli ps (%s)", interval, timeNow().Format(time.TimeOnly))))
+		}
+
+		if err := renderRunningModels(ctx, ollamaClient, out, format); err != nil {
+			// An interrupted request is not an error while watching
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderRunningModels fetches the running models and writes them in the given format
+func renderRunningModels(ctx context.Context, ollamaClient client.Client, out io.Writer, format string) error {
+	models, err := ollamaClient.ListRunning(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list running models: %w", err)
+	}
+
+	switch format {
+	case "json":
+		return outputRunningJSON(out, models)
+	case "wide":
+		if len(models.Models) == 0 {
+			fmt.Fprintln(out, "No models are currently loaded.")
+			return nil
+		}
+		return outputRunningWide(out, models)
+	default:
+		if len(models.Models) == 0 {
+			fmt.Fprintln(out, "No models are currently loaded.")
+			return nil
+		}
+		return outputRunningTable(out, models)
+	}
+}
+
+// outputRunningTable formats and displays the running models in a table format
+func outputRunningTable(out io.Writer, models *api.ProcessResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, output.MakeHeader("NAME\tSIZE\tVRAM\tPROCESSOR\tCONTEXT\tEXPIRES"))
+
+	for _, model := range models.Models {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			output.Highlight(model.Name),
+			formatSize(model.Size),
+			formatSize(model.SizeVRAM),
+			formatProcessor(model.Size, model.SizeVRAM),
+			formatOptionalInt(model.ContextLength),
+			formatExpiresAt(model.ExpiresAt),
+		)
+	}
+
+	return w.Flush()
+}
+
+// outputRunningWide formats and displays the running models in a wide table format with all details
+func outputRunningWide(out io.Writer, models *api.ProcessResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, output.MakeHeader("NAME\tSIZE\tVRAM\tPROCESSOR\tCONTEXT\tEXPIRES\tFAMILY\tPARAMETERS\tQUANTIZATION\tDIGEST"))
+
+	for _, model := range models.Models {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			output.Highlight(model.Name),
+			formatSize(model.Size),
+			formatSize(model.SizeVRAM),
+			formatProcessor(model.Size, model.SizeVRAM),
+			formatOptionalInt(model.ContextLength),
+			formatExpiresAt(model.ExpiresAt),
+			getOrDefault(model.Details.Family, "N/A"),
+			getOrDefault(model.Details.ParameterSize, "N/A"),
+			getOrDefault(model.Details.QuantizationLevel, "N/A"),
+			getOrDefault(model.Digest, "N/A"),
+		)
+	}
+
+	return w.Flush()
+}
+
+// outputRunningJSON outputs the running models in JSON format
+func outputRunningJSON(out io.Writer, models *api.ProcessResponse) error {
+	jsonData, err := json.MarshalIndent(models, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal running models to JSON: %w", err)
+	}
+
+	fmt.Fprintln(out, string(jsonData))
+	return nil
+}
+
+// formatProcessor describes how a model is split between CPU and GPU memory
+func formatProcessor(size, sizeVRAM int64) string {
+	switch {
+	case size <= 0 || sizeVRAM <= 0:
+		return "100% CPU"
+	case sizeVRAM >= size:
+		return "100% GPU"
+	default:
+		gpuPercent := int(float64(sizeVRAM) / float64(size) * 100)
+		return fmt.Sprintf("%d%%/%d%% CPU/GPU", 100-gpuPercent, gpuPercent)
+	}
+}
+
+// formatExpiresAt formats when a loaded model expires
+func formatExpiresAt(expiresAt time.Time) string {
+	switch {
+	case expiresAt.IsZero():
+		return "N/A"
+	// Models loaded with a negative keep_alive never expire
+	case expiresAt.After(timeNow().Add(100 * 365 * 24 * time.Hour)):
+		return output.Success("Forever")
+	case expiresAt.Before(timeNow()):
+		return output.Warning("Stopping...")
+	default:
+		return formatTime(expiresAt)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+
+	psCmd.Flags().StringP("output", "o", "table", "Output format (table, wide, json)")
+	psCmd.Flags().BoolP("watch", "w", false, "Refresh the list periodically until interrupted")
+	psCmd.Flags().Duration("interval", 2*time.Second, "Refresh interval in watch mode")
+}
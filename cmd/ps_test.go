@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPsCommand(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	fixedTime := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	origTimeNow := timeNow
+	defer func() { timeNow = origTimeNow }()
+	timeNow = func() time.Time { return fixedTime }
+
+	mockModels := &api.ProcessResponse{
+		Models: []api.ProcessModelResponse{
+			{
+				Name:          "llama3.2:latest",
+				Size:          4 * 1024 * 1024 * 1024,
+				SizeVRAM:      4 * 1024 * 1024 * 1024,
+				ContextLength: 8192,
+				ExpiresAt:     fixedTime.Add(4*time.Minute + 30*time.Second),
+				Digest:        "a80c4f17acd5",
+				Details: api.ModelDetails{
+					Family:            "llama",
+					ParameterSize:     "3.2B",
+					QuantizationLevel: "Q4_K_M",
+				},
+			},
+			{
+				Name:      "phi4-mini:3.8b",
+				Size:      2 * 1024 * 1024 * 1024,
+				SizeVRAM:  1024 * 1024 * 1024,
+				ExpiresAt: fixedTime.Add(200 * 365 * 24 * time.Hour),
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Table output",
+			args: []string{"ps"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(mockModels, nil)
+			},
+			wantContain: []string{"llama3.2:latest", "4.0 GB", "100% GPU", "8192", "in 4 minutes", "phi4-mini:3.8b", "50%/50% CPU/GPU", "Forever"},
+		},
+		{
+			name: "Wide output",
+			args: []string{"ps", "-o", "wide"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(mockModels, nil)
+			},
+			wantContain: []string{"llama", "3.2B", "Q4_K_M", "a80c4f17acd5"},
+		},
+		{
+			name: "JSON output",
+			args: []string{"ps", "-o", "json"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(mockModels, nil)
+			},
+			wantContain: []string{`"name": "llama3.2:latest"`, `"size_vram": 4294967296`, `"context_length": 8192`},
+		},
+		{
+			name: "No running models",
+			args: []string{"ps"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(&api.ProcessResponse{}, nil)
+			},
+			wantContain: []string{"No models are currently loaded."},
+		},
+		{
+			name:      "Invalid output format",
+			args:      []string{"ps", "-o", "xml"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "invalid output format: xml",
+		},
+		{
+			name: "Error from client",
+			args: []string{"ps"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(nil, errors.New("connection error"))
+			},
+			wantErr: "failed to list running models: connection error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(psCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(psCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWatchRunningModels(t *testing.T) {
+	mockClient := client.NewMockClient()
+	mockClient.On("ListRunning", mock.Anything).Return(&api.ProcessResponse{}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := watchRunningModels(ctx, mockClient, &buf, "table", 20*time.Millisecond)
+	assert.NoError(t, err)
+
+	// The list should have been refreshed several times before the context expired
+	assert.Greater(t, strings.Count(buf.String(), "No models are currently loaded."), 1)
+	assert.Contains(t, buf.String(), clearScreen)
+}
+
+func TestFormatProcessor(t *testing.T) {
+	assert.Equal(t, "100% CPU", formatProcessor(100, 0))
+	assert.Equal(t, "100% GPU", formatProcessor(100, 100))
+	assert.Equal(t, "25%/75% CPU/GPU", formatProcessor(100, 75))
+}
@@ -1,10 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
+	"github.com/masgari/ollama-cli/pkg/client"
 	"github.com/masgari/ollama-cli/pkg/output"
 	"github.com/spf13/cobra"
 )
@@ -32,30 +39,202 @@ func colorizeDuration(duration time.Duration) string {
 
 // pullCmd represents the pull command
 var pullCmd = &cobra.Command{
-	Use:   "pull [model]",
+	Use:   "pull [model...]",
 	Short: "Pull a model from the Ollama server",
-	Long:  `Pull a model and its data from the remote Ollama server.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Pull a model and its data from the remote Ollama server.
+
+Several models can be pulled at once, either as arguments or listed one per line in a
+file given with --from-file. They are pulled concurrently with one progress row per model,
+followed by a summary of the results.
+
+Examples:
+  # Pull a single model
+  ollama-cli pull llama3.2
+
+  # Pull several models, two at a time
+  ollama-cli pull llama3.2 qwen3:8b phi4-mini --parallel 2
+
+  # Pull the models listed in a file
+  ollama-cli pull --from-file models.txt`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		modelName := args[0]
+		fromFile, _ := cmd.Flags().GetString("from-file")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+
+		models := append([]string(nil), args...)
+		if fromFile != "" {
+			fileModels, err := readModelList(cmd, fromFile)
+			if err != nil {
+				return err
+			}
+			models = append(models, fileModels...)
+		}
+		models = uniqueStrings(models)
+
+		if len(models) == 0 {
+			return fmt.Errorf("specify at least one model or use --from-file")
+		}
+		if parallel < 1 {
+			parallel = 1
+		}
+
+		mode, err := progressMode(cmd, progressPlain)
+		if err != nil {
+			return err
+		}
 
 		ollamaClient, err := createOllamaClient()
 		if err != nil {
 			return err
 		}
 
-		output.Default.InfoPrintf("Pulling model '%s'...\n", output.Highlight(modelName))
+		out := cmd.OutOrStdout()
+		renderer := newProgressRenderer(out, mode, models)
+
+		if len(models) > 1 {
+			results := pullModels(context.Background(), ollamaClient, renderer, models, parallel)
+			// Failures are part of the JSON events, so there is no need for a summary
+			if mode == progressJSON {
+				return pullError(results)
+			}
+			return printPullSummary(out, results)
+		}
+
+		modelName := models[0]
+		if mode != progressJSON {
+			output.Default.InfoPrintf("Pulling model '%s'...\n", output.Highlight(modelName))
+		}
 		start := time.Now()
-		if err := ollamaClient.PullModel(context.Background(), modelName); err != nil {
+		renderer.Start(modelName)
+		err = ollamaClient.PullModel(context.Background(), modelName, renderer.Update)
+		renderer.Finish(modelName, err)
+		if err != nil {
 			return fmt.Errorf("failed to pull model: %w", err)
 		}
 		duration := time.Since(start)
 
-		output.Default.SuccessPrintf("\nModel '%s' pulled successfully in %s.\n", output.Highlight(modelName), colorizeDuration(duration))
+		if mode != progressJSON {
+			output.Default.SuccessPrintf("Model '%s' pulled successfully in %s.\n", output.Highlight(modelName), colorizeDuration(duration))
+		}
 		return nil
 	},
 }
 
+// readModelList reads model names from a file, one per line, ignoring blank lines
+// and # comments. The path - reads from stdin.
+func readModelList(cmd *cobra.Command, path string) ([]string, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open model list: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var models []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		models = append(models, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read model list: %w", err)
+	}
+
+	return models, nil
+}
+
+// uniqueStrings removes duplicates from values, keeping the first occurrence
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// pullResult is the outcome of pulling a single model
+type pullResult struct {
+	model    string
+	duration time.Duration
+	err      error
+}
+
+// pullModels pulls the models concurrently with at most parallel pulls at a time,
+// reporting the progress to renderer, and returns the result of every pull
+func pullModels(ctx context.Context, ollamaClient client.Client, renderer progressRenderer, models []string, parallel int) []pullResult {
+	results := make([]pullResult, len(models))
+
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, modelName := range models {
+		wg.Add(1)
+		go func(i int, modelName string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			renderer.Start(modelName)
+			err := ollamaClient.PullModel(ctx, modelName, renderer.Update)
+			renderer.Finish(modelName, err)
+			results[i] = pullResult{model: modelName, duration: time.Since(start), err: err}
+		}(i, modelName)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// printPullSummary prints the result of every pull and returns an error if any failed
+func printPullSummary(out io.Writer, results []pullResult) error {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, output.MakeHeader("MODEL\tRESULT\tDURATION\tERROR"))
+
+	for _, result := range results {
+		status := output.Success("pulled")
+		errMsg := ""
+		if result.err != nil {
+			status = output.Error("failed")
+			errMsg = result.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", output.Highlight(result.model), status, colorizeDuration(result.duration), errMsg)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return pullError(results)
+}
+
+// pullError returns an error if any of the pulls failed
+func pullError(results []pullResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to pull %d of %d models", failed, len(results))
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(pullCmd)
+
+	pullCmd.Flags().String("from-file", "", "Read the models to pull from a file, one per line (- for stdin)")
+	pullCmd.Flags().IntP("parallel", "j", 3, "Maximum number of models to pull concurrently")
+	addProgressFlag(pullCmd, progressPlain)
 }
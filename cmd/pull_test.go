@@ -3,6 +3,8 @@ package cmd
 import (
 	"bytes"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/masgari/ollama-cli/pkg/client"
@@ -11,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPullCommand(t *testing.T) {
@@ -30,7 +33,7 @@ func TestPullCommand(t *testing.T) {
 			name: "Basic command execution",
 			args: []string{"model1"},
 			setupMock: func(m *client.MockClientTestify) {
-				m.On("PullModel", mock.Anything, "model1").Return(nil)
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Return(nil)
 			},
 			wantErr: false,
 			wantContain: []string{
@@ -42,7 +45,7 @@ func TestPullCommand(t *testing.T) {
 			name: "Error from client",
 			args: []string{"model1"},
 			setupMock: func(m *client.MockClientTestify) {
-				m.On("PullModel", mock.Anything, "model1").Return(errors.New("connection error"))
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Return(errors.New("connection error"))
 			},
 			wantErr: true,
 			wantContain: []string{
@@ -114,7 +117,7 @@ func TestPullCommandFlags(t *testing.T) {
 	cmd := pullCmd
 
 	// Check command properties
-	assert.Equal(t, "pull [model]", cmd.Use)
+	assert.Equal(t, "pull [model...]", cmd.Use)
 	assert.Equal(t, "Pull a model from the Ollama server", cmd.Short)
 	assert.Contains(t, cmd.Long, "Pull a model and its data from the remote Ollama server")
 }
@@ -138,5 +141,136 @@ func TestPullCommandMissingArg(t *testing.T) {
 
 	// Check the output for the error message
 	output := buf.String()
-	assert.Contains(t, output, "Error: accepts 1 arg(s), received 0", "Output should contain error message")
+	assert.Contains(t, output, "Error: specify at least one model or use --from-file", "Output should contain error message")
+}
+
+func TestPullMultipleModels(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	origIsStdoutTerminal := isStdoutTerminal
+	defer func() { isStdoutTerminal = origIsStdoutTerminal }()
+
+	modelList := filepath.Join(t.TempDir(), "models.txt")
+	require.NoError(t, os.WriteFile(modelList, []byte("# team models\nmodel2\n\nmodel3\nmodel1\n"), 0o644))
+
+	// sendProgress makes the mocked PullModel report progress through the given callback
+	sendProgress := func(args mock.Arguments) {
+		fn := args.Get(2).(client.ProgressFunc)
+		fn(client.ProgressEvent{Model: args.String(1), Status: "pulling ad361f123f77", Digest: "sha256:ad361f123f77", Total: 100, Completed: 50})
+		fn(client.ProgressEvent{Model: args.String(1), Status: "success"})
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		terminal       bool
+		setupMock      func(*client.MockClientTestify)
+		wantErr        string
+		wantContain    []string
+		wantNotContain []string
+	}{
+		{
+			name: "Plain progress and summary",
+			args: []string{"pull", "model1", "model2", "-j", "1"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Run(sendProgress).Return(nil)
+				m.On("PullModel", mock.Anything, "model2", mock.Anything).Run(sendProgress).Return(nil)
+			},
+			wantContain: []string{"model1: pulling ad361f123f77", "model2: success", "model2: done", "MODEL", "RESULT", "pulled"},
+		},
+		{
+			name:     "Models from a file on a terminal",
+			args:     []string{"pull", "model1", "--from-file", modelList},
+			terminal: true,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Run(sendProgress).Return(nil)
+				m.On("PullModel", mock.Anything, "model2", mock.Anything).Run(sendProgress).Return(nil)
+				m.On("PullModel", mock.Anything, "model3", mock.Anything).Run(sendProgress).Return(nil)
+			},
+			wantContain:    []string{"\033[3A", "model3", "done in"},
+			wantNotContain: []string{"team models"},
+		},
+		{
+			name: "JSON progress",
+			args: []string{"pull", "model1", "model2", "--progress", "json"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Run(sendProgress).Return(nil)
+				m.On("PullModel", mock.Anything, "model2", mock.Anything).Return(errors.New("file does not exist"))
+			},
+			wantErr: "failed to pull 1 of 2 models",
+			wantContain: []string{
+				`{"model":"model1","status":"success"}`,
+				`{"model":"model2","status":"error","error":"file does not exist"}`,
+			},
+			wantNotContain: []string{"RESULT"},
+		},
+		{
+			name: "Failed pull",
+			args: []string{"pull", "model1", "model2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PullModel", mock.Anything, "model1", mock.Anything).Return(nil)
+				m.On("PullModel", mock.Anything, "model2", mock.Anything).Return(errors.New("file does not exist"))
+			},
+			wantErr:     "failed to pull 1 of 2 models",
+			wantContain: []string{"model2: failed: file does not exist", "failed"},
+		},
+		{
+			name:      "No models",
+			args:      []string{"pull"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "specify at least one model or use --from-file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(pullCmd)
+			isStdoutTerminal = func() bool { return tt.terminal }
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf safeBuffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(pullCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			for _, notWant := range tt.wantNotContain {
+				assert.NotContains(t, buf.String(), notWant)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "ETA --", formatETA(100, 0))
+	assert.Equal(t, "ETA 10s", formatETA(100, 10))
+	assert.Equal(t, "-- MB/s", formatThroughput(0))
+	assert.Equal(t, "2.0 MB/s", formatThroughput(2*1024*1024))
 }
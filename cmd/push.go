@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// pushCmd represents the push command
+var pushCmd = &cobra.Command{
+	Use:   "push [model]",
+	Short: "Push a model to a registry",
+	Long: `Push a model from the remote Ollama server to a registry.
+
+When the output is not a terminal, progress is written as one JSON event per line
+so it can be consumed by scripts and CI pipelines. Use --progress to choose explicitly.
+
+Examples:
+  # Push a model to ollama.com
+  ollama-cli push team/assistant:stable
+
+  # Push to a registry served over plain HTTP
+  ollama-cli push registry.local:5000/team/assistant --insecure`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeModelNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelName := args[0]
+		insecure, _ := cmd.Flags().GetBool("insecure")
+
+		mode, err := progressMode(cmd, progressJSON)
+		if err != nil {
+			return err
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		renderer := newProgressRenderer(cmd.OutOrStdout(), mode, []string{modelName})
+		if mode != progressJSON {
+			output.Default.InfoPrintf("Pushing model '%s'...\n", output.Highlight(modelName))
+		}
+
+		start := time.Now()
+		renderer.Start(modelName)
+		err = ollamaClient.PushModel(context.Background(), modelName, insecure, renderer.Update)
+		renderer.Finish(modelName, err)
+		if err != nil {
+			return fmt.Errorf("failed to push model: %w", err)
+		}
+		duration := time.Since(start)
+
+		if mode != progressJSON {
+			output.Default.SuccessPrintf("Model '%s' pushed successfully in %s.\n", output.Highlight(modelName), colorizeDuration(duration))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+
+	pushCmd.Flags().Bool("insecure", false, "Allow pushing to a registry over an insecure connection")
+	addProgressFlag(pushCmd, progressJSON)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPushCommand(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	origIsStdoutTerminal := isStdoutTerminal
+	defer func() { isStdoutTerminal = origIsStdoutTerminal }()
+
+	// sendProgress makes the mocked PushModel report progress through the given callback
+	sendProgress := func(args mock.Arguments) {
+		fn := args.Get(3).(client.ProgressFunc)
+		fn(client.ProgressEvent{Model: args.String(1), Status: "pushing ad361f123f77", Digest: "sha256:ad361f123f77", Total: 100, Completed: 50})
+		fn(client.ProgressEvent{Model: args.String(1), Status: "success"})
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		terminal    bool
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name:     "Push to a terminal",
+			args:     []string{"push", "team/assistant"},
+			terminal: true,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PushModel", mock.Anything, "team/assistant", false, mock.Anything).Return(nil)
+			},
+			wantContain: []string{"Pushing model 'team/assistant'", "Model 'team/assistant' pushed successfully"},
+		},
+		{
+			name: "JSON progress when not a terminal",
+			args: []string{"push", "team/assistant", "--insecure"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PushModel", mock.Anything, "team/assistant", true, mock.Anything).Run(sendProgress).Return(nil)
+			},
+			wantContain: []string{
+				`{"model":"team/assistant","status":"pushing ad361f123f77","digest":"sha256:ad361f123f77","total":100,"completed":50}`,
+				`{"model":"team/assistant","status":"success"}`,
+			},
+		},
+		{
+			name:     "Error from client",
+			args:     []string{"push", "team/assistant"},
+			terminal: true,
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("PushModel", mock.Anything, "team/assistant", false, mock.Anything).Return(errors.New("unauthorized"))
+			},
+			wantErr: "failed to push model: unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(pushCmd)
+			isStdoutTerminal = func() bool { return tt.terminal }
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(pushCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
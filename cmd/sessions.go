@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/history"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/session"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// sessionTimeFormat is the format of absolute session timestamps
+const sessionTimeFormat = "2006-01-02 15:04:05"
+
+// chatSession keeps a chat in sync with its stored session
+type chatSession struct {
+	store   *session.Store
+	session *session.Session
+}
+
+// openChatSession loads the named session, or starts a new one if it does not exist
+func openChatSession(store *session.Store, status *output.ColorWriter, name, modelName string) (*chatSession, error) {
+	if err := session.ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	sess, err := store.Load(name)
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		status.InfoPrintf("Starting new session '%s'\n", output.Highlight(name))
+		sess = session.New(name, modelName)
+	case err != nil:
+		return nil, err
+	default:
+		status.InfoPrintf("Resuming session '%s' (%d messages)\n", output.Highlight(name), len(sess.Messages))
+		if sess.Model != "" && sess.Model != modelName {
+			status.WarningPrintf("Session '%s' was started with model '%s', continuing with '%s'\n", name, sess.Model, modelName)
+		}
+	}
+
+	return &chatSession{store: store, session: sess}, nil
+}
+
+// history returns the conversation of the session with its branches, or an empty
+// history when there is no session
+func (c *chatSession) history() *history.Tree {
+	if c == nil {
+		return history.New()
+	}
+	if c.session.Tree != nil {
+		return c.session.Tree
+	}
+	return history.FromMessages(c.session.Messages)
+}
+
+// save records the conversation, the current settings and the token usage of the
+// last response in the session. The conversation has no system messages, since the
+// security prompt is added again when the session is resumed.
+func (c *chatSession) save(conversation *history.Tree, settings *chatSettings, response *api.ChatResponse) error {
+	if c == nil {
+		return nil
+	}
+
+	c.session.Model = settings.model
+	c.session.Options = settings.options
+	c.session.Messages = conversation.Messages()
+	c.session.Tree = nil
+	if conversation.Branched() {
+		c.session.Tree = conversation
+	}
+	if response != nil {
+		c.session.AddUsage(response.Metrics)
+	}
+
+	if err := c.store.Save(c.session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// completeSessionNames provides completion for the names of saved sessions
+func completeSessionNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	sessions, _, err := session.DefaultStore().List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, sess := range sessions {
+		if strings.HasPrefix(sess.Name, toComplete) {
+			names = append(names, sess.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// sessionsCmd represents the sessions command
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage saved chat sessions",
+	Long: `Manage the chat sessions saved with 'chat --session'.
+
+Sessions are stored in the sessions directory of the configuration directory
+(~/.ollama-cli/sessions) and record the model, options, timestamps, token totals
+and messages of a conversation.
+
+Examples:
+  # List all sessions
+  ollama-cli sessions list
+
+  # Show a session with its transcript
+  ollama-cli sessions show work
+
+  # Rename and delete sessions
+  ollama-cli sessions rename work project-x
+  ollama-cli sessions rm old-chat
+
+  # Export a session as chat history for --input-file, or as Markdown
+  ollama-cli sessions export work > history.json
+  ollama-cli sessions export work --format markdown --output-file work.md`,
+}
+
+// sessionsListCmd represents the sessions list command
+var sessionsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List saved chat sessions",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		format = strings.ToLower(format)
+		if format != "table" && format != "json" {
+			return fmt.Errorf("invalid output format: %s", format)
+		}
+
+		sessions, warnings, err := session.DefaultStore().List()
+		if err != nil {
+			return err
+		}
+		// Warnings go to stderr, so they do not break the JSON output
+		for _, warning := range warnings {
+			output.GetStdErr().WarningPrintf("Skipped a session: %v\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if format == "json" {
+			summaries := make([]sessionSummary, 0, len(sessions))
+			for _, sess := range sessions {
+				summaries = append(summaries, newSessionSummary(sess))
+			}
+			jsonData, err := json.MarshalIndent(summaries, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal sessions to JSON: %w", err)
+			}
+			fmt.Fprintln(out, string(jsonData))
+			return nil
+		}
+
+		if len(sessions) == 0 {
+			fmt.Fprintln(out, "No sessions found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, output.MakeHeader("NAME\tMODEL\tMESSAGES\tTOKENS\tCREATED\tUPDATED"))
+		for _, sess := range sessions {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n",
+				output.Highlight(sess.Name),
+				getOrDefault(sess.Model, "N/A"),
+				len(sess.Messages),
+				sess.PromptTokens+sess.ResponseTokens,
+				formatTime(sess.CreatedAt),
+				formatTime(sess.UpdatedAt),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// sessionSummary is the JSON representation of a session in the session list
+type sessionSummary struct {
+	Name           string    `json:"name"`
+	Model          string    `json:"model"`
+	Messages       int       `json:"messages"`
+	PromptTokens   int       `json:"prompt_tokens"`
+	ResponseTokens int       `json:"response_tokens"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// newSessionSummary summarizes a session for the session list
+func newSessionSummary(sess *session.Session) sessionSummary {
+	return sessionSummary{
+		Name:           sess.Name,
+		Model:          sess.Model,
+		Messages:       len(sess.Messages),
+		PromptTokens:   sess.PromptTokens,
+		ResponseTokens: sess.ResponseTokens,
+		CreatedAt:      sess.CreatedAt,
+		UpdatedAt:      sess.UpdatedAt,
+	}
+}
+
+// sessionsShowCmd represents the sessions show command
+var sessionsShowCmd = &cobra.Command{
+	Use:               "show [name]",
+	Short:             "Show the details and transcript of a session",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		format = strings.ToLower(format)
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid output format: %s", format)
+		}
+
+		sess, err := session.DefaultStore().Load(args[0])
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if format == "json" {
+			jsonData, err := json.MarshalIndent(sess, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal session to JSON: %w", err)
+			}
+			fmt.Fprintln(out, string(jsonData))
+			return nil
+		}
+
+		fmt.Fprintf(out, "%s %s\n", output.MakeHeader("Name:"), output.Highlight(sess.Name))
+		fmt.Fprintf(out, "%s %s\n", output.MakeHeader("Model:"), getOrDefault(sess.Model, "N/A"))
+		fmt.Fprintf(out, "%s %s\n", output.MakeHeader("Created:"), sess.CreatedAt.Local().Format(sessionTimeFormat))
+		fmt.Fprintf(out, "%s %s\n", output.MakeHeader("Updated:"), sess.UpdatedAt.Local().Format(sessionTimeFormat))
+		fmt.Fprintf(out, "%s %d\n", output.MakeHeader("Messages:"), len(sess.Messages))
+		if sess.Tree != nil {
+			fmt.Fprintf(out, "%s %d, showing '%s'\n", output.MakeHeader("Branches:"), len(sess.Tree.Branches()), sess.Tree.Current())
+		}
+		fmt.Fprintf(out, "%s %d prompt, %d response\n", output.MakeHeader("Tokens:"), sess.PromptTokens, sess.ResponseTokens)
+		if len(sess.Options) > 0 {
+			fmt.Fprintf(out, "%s %s\n", output.MakeHeader("Options:"), formatSessionOptions(sess.Options))
+		}
+		if sess.System != "" {
+			fmt.Fprintf(out, "%s %s\n", output.MakeHeader("System:"), sess.System)
+		}
+
+		for _, msg := range sess.Messages {
+			fmt.Fprintf(out, "\n%s\n%s\n", output.Highlight(messageRoleLabel(msg.Role)+":"), msg.Content)
+		}
+		return nil
+	},
+}
+
+// sessionsRmCmd represents the sessions rm command
+var sessionsRmCmd = &cobra.Command{
+	Use:               "rm [name...]",
+	Aliases:           []string{"delete", "remove"},
+	Short:             "Delete saved sessions",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := session.DefaultStore()
+		for _, name := range args {
+			if err := store.Delete(name); err != nil {
+				return err
+			}
+			output.Default.SuccessPrintf("Session '%s' deleted.\n", output.Highlight(name))
+		}
+		return nil
+	},
+}
+
+// sessionsRenameCmd represents the sessions rename command
+var sessionsRenameCmd = &cobra.Command{
+	Use:               "rename [name] [new-name]",
+	Aliases:           []string{"mv"},
+	Short:             "Rename a saved session",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := session.DefaultStore().Rename(args[0], args[1]); err != nil {
+			return err
+		}
+		output.Default.SuccessPrintf("Session '%s' renamed to '%s'.\n", output.Highlight(args[0]), output.Highlight(args[1]))
+		return nil
+	},
+}
+
+// sessionsExportCmd represents the sessions export command
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export [name]",
+	Short: "Export the messages of a session",
+	Long: `Export the messages of a session, either as a JSON chat history that can be
+loaded with 'chat --input-file', or as a Markdown transcript. The messages of the
+current branch are exported when the conversation has several branches.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		outputFile, _ := cmd.Flags().GetString("output-file")
+
+		format = strings.ToLower(format)
+		if format != "json" && format != "markdown" {
+			return fmt.Errorf("invalid export format: %s", format)
+		}
+
+		sess, err := session.DefaultStore().Load(args[0])
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if outputFile != "" {
+			file, err := os.Create(outputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if format == "markdown" {
+			err = writeSessionMarkdown(out, sess)
+		} else {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(sess.Messages)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export session: %w", err)
+		}
+
+		if outputFile != "" {
+			output.Default.SuccessPrintf("Session '%s' exported to '%s'\n", output.Highlight(sess.Name), output.Highlight(outputFile))
+		}
+		return nil
+	},
+}
+
+// writeSessionMarkdown writes a session as a Markdown transcript
+func writeSessionMarkdown(out io.Writer, sess *session.Session) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", sess.Name)
+	fmt.Fprintf(&b, "- Model: %s\n", getOrDefault(sess.Model, "N/A"))
+	fmt.Fprintf(&b, "- Created: %s\n", sess.CreatedAt.Local().Format(sessionTimeFormat))
+	fmt.Fprintf(&b, "- Updated: %s\n", sess.UpdatedAt.Local().Format(sessionTimeFormat))
+	fmt.Fprintf(&b, "- Tokens: %d prompt, %d response\n", sess.PromptTokens, sess.ResponseTokens)
+
+	for _, msg := range sess.Messages {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", messageRoleLabel(msg.Role), strings.TrimSpace(msg.Content))
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// messageRoleLabel returns the display label of a message role
+func messageRoleLabel(role string) string {
+	if role == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// formatSessionOptions formats model options as sorted key=value pairs
+func formatSessionOptions(options map[string]interface{}) string {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, options[key]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsShowCmd)
+	sessionsCmd.AddCommand(sessionsRmCmd)
+	sessionsCmd.AddCommand(sessionsRenameCmd)
+	sessionsCmd.AddCommand(sessionsExportCmd)
+
+	sessionsListCmd.Flags().StringP("output", "o", "table", "Output format (table, json)")
+	sessionsShowCmd.Flags().StringP("output", "o", "text", "Output format (text, json)")
+	sessionsExportCmd.Flags().String("format", "json", "Export format (json, markdown)")
+	sessionsExportCmd.Flags().String("output-file", "", "File to write the export to instead of stdout")
+}
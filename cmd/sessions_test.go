@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/history"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/session"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// runSessionTestCommand executes a command line against a fresh root command
+func runSessionTestCommand(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(cmd)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+
+	var buf bytes.Buffer
+	root := &cobra.Command{Use: "ollama-cli"}
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.AddCommand(cmd)
+	root.SetArgs(args)
+
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestChatSession(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origGetConfigDir := config.GetConfigDir
+	defer func() { config.GetConfigDir = origGetConfigDir }()
+	configDir := t.TempDir()
+	config.GetConfigDir = func() string { return configDir }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	mockClient := client.NewMockClient()
+	client.SetClientFactory(func() (client.Client, error) {
+		return mockClient, nil
+	})
+	defer client.ResetClientFactory()
+
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 2
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "Nice to meet you, Sam."},
+		Metrics: api.Metrics{PromptEvalCount: 20, EvalCount: 8},
+	}, nil).Once()
+
+	// The second chat resumes the history and the options of the session
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 4 &&
+			req.Messages[0].Role == "system" &&
+			req.Messages[1].Content == "My name is Sam" &&
+			req.Messages[3].Content == "What is my name?" &&
+			req.Options["temperature"] == 0.2
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "Your name is Sam."},
+		Metrics: api.Metrics{PromptEvalCount: 40, EvalCount: 6},
+	}, nil).Once()
+
+	_, err := runSessionTestCommand(t, chatCmd, "chat", "test-model", "--session", "work", "-p", "My name is Sam", "-t", "0.2", "--no-stream")
+	require.NoError(t, err)
+	assert.Contains(t, status.String(), "Starting new session 'work'")
+
+	out, err := runSessionTestCommand(t, chatCmd, "chat", "test-model", "--session", "work", "-p", "What is my name?", "--no-stream")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Your name is Sam.")
+	assert.Contains(t, status.String(), "Resuming session 'work' (2 messages)")
+	mockClient.AssertExpectations(t)
+
+	sess, err := session.DefaultStore().Load("work")
+	require.NoError(t, err)
+	assert.Equal(t, "test-model", sess.Model)
+	assert.Equal(t, 60, sess.PromptTokens)
+	assert.Equal(t, 14, sess.ResponseTokens)
+	assert.Len(t, sess.Messages, 4)
+	_, err = os.Stat(filepath.Join(configDir, "sessions", "work.json"))
+	assert.NoError(t, err)
+
+	_, err = runSessionTestCommand(t, chatCmd, "chat", "test-model", "--session", "../escape", "-p", "Hi")
+	assert.ErrorContains(t, err, "invalid session name")
+}
+
+func TestChatSessionBranches(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+	config.Current.ChatEnabled = true
+
+	origGetConfigDir := config.GetConfigDir
+	defer func() { config.GetConfigDir = origGetConfigDir }()
+	configDir := t.TempDir()
+	config.GetConfigDir = func() string { return configDir }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+	mockClient := client.NewMockClient()
+	client.SetClientFactory(func() (client.Client, error) {
+		return mockClient, nil
+	})
+	defer client.ResetClientFactory()
+
+	// A session with a branch editing its first message
+	conversation := history.FromMessages([]api.Message{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hello"}})
+	require.NoError(t, conversation.Fork("polite", 0))
+	conversation.Append(api.Message{Role: "user", Content: "Good morning"}, api.Message{Role: "assistant", Content: "Good morning to you"})
+	sess := session.New("work", "test-model")
+	sess.Messages = conversation.Messages()
+	sess.Tree = conversation
+	require.NoError(t, session.DefaultStore().Save(sess))
+
+	// The message is added to the current branch
+	mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+		return len(req.Messages) == 4 && req.Messages[1].Content == "Good morning"
+	}), mock.Anything).Return(&api.ChatResponse{
+		Message: api.Message{Role: "assistant", Content: "Fine, thanks"},
+	}, nil).Once()
+
+	_, err := runSessionTestCommand(t, chatCmd, "chat", "test-model", "--session", "work", "-p", "How are you?", "--no-stream")
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+
+	sess, err = session.DefaultStore().Load("work")
+	require.NoError(t, err)
+	assert.Len(t, sess.Messages, 4)
+	require.NotNil(t, sess.Tree)
+	branches := sess.Tree.Branches()
+	require.Len(t, branches, 2)
+	assert.Len(t, branches[0].Messages, 2)
+	assert.Equal(t, sess.Messages, branches[1].Messages)
+
+	out, err := runSessionTestCommand(t, sessionsCmd, "sessions", "show", "work")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Branches: 2, showing 'polite'")
+}
+
+func TestSessionsCommand(t *testing.T) {
+	origGetConfigDir := config.GetConfigDir
+	defer func() { config.GetConfigDir = origGetConfigDir }()
+	configDir := t.TempDir()
+	config.GetConfigDir = func() string { return configDir }
+
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	var status bytes.Buffer
+	output.Default = output.NewColorWriter(&status)
+
+	out, err := runSessionTestCommand(t, sessionsCmd, "sessions", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No sessions found.")
+
+	sess := session.New("work", "llama3.2")
+	sess.Options = map[string]interface{}{"temperature": 0.5}
+	sess.Messages = []api.Message{{Role: "user", Content: "Hello"}, {Role: "assistant", Content: "Hi there"}}
+	sess.AddUsage(api.Metrics{PromptEvalCount: 12, EvalCount: 3})
+	require.NoError(t, session.DefaultStore().Save(sess))
+
+	t.Run("List", func(t *testing.T) {
+		out, err := runSessionTestCommand(t, sessionsCmd, "sessions", "list")
+		require.NoError(t, err)
+		assert.Contains(t, out, "work")
+		assert.Contains(t, out, "llama3.2")
+		assert.Contains(t, out, "15")
+
+		out, err = runSessionTestCommand(t, sessionsCmd, "sessions", "list", "-o", "json")
+		require.NoError(t, err)
+		var summaries []sessionSummary
+		require.NoError(t, json.Unmarshal([]byte(out), &summaries))
+		require.Len(t, summaries, 1)
+		assert.Equal(t, 2, summaries[0].Messages)
+		assert.Equal(t, 12, summaries[0].PromptTokens)
+	})
+
+	t.Run("Show", func(t *testing.T) {
+		out, err := runSessionTestCommand(t, sessionsCmd, "sessions", "show", "work")
+		require.NoError(t, err)
+		assert.Contains(t, out, "temperature=0.5")
+		assert.Contains(t, out, "12 prompt, 3 response")
+		assert.Contains(t, out, "User:\nHello")
+		assert.Contains(t, out, "Assistant:\nHi there")
+
+		_, err = runSessionTestCommand(t, sessionsCmd, "sessions", "show", "missing")
+		assert.ErrorIs(t, err, session.ErrNotFound)
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		out, err := runSessionTestCommand(t, sessionsCmd, "sessions", "export", "work")
+		require.NoError(t, err)
+		var messages []api.Message
+		require.NoError(t, json.Unmarshal([]byte(out), &messages))
+		assert.Len(t, messages, 2)
+
+		file := filepath.Join(t.TempDir(), "work.md")
+		_, err = runSessionTestCommand(t, sessionsCmd, "sessions", "export", "work", "--format", "markdown", "--output-file", file)
+		require.NoError(t, err)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "# work")
+		assert.Contains(t, string(data), "## Assistant\n\nHi there")
+
+		_, err = runSessionTestCommand(t, sessionsCmd, "sessions", "export", "work", "--format", "html")
+		assert.EqualError(t, err, "invalid export format: html")
+	})
+
+	t.Run("Rename and remove", func(t *testing.T) {
+		_, err := runSessionTestCommand(t, sessionsCmd, "sessions", "rename", "work", "project-x")
+		require.NoError(t, err)
+		assert.Contains(t, status.String(), "Session 'work' renamed to 'project-x'")
+		assert.False(t, session.DefaultStore().Exists("work"))
+
+		_, err = runSessionTestCommand(t, sessionsCmd, "sessions", "rm", "project-x")
+		require.NoError(t, err)
+		assert.False(t, session.DefaultStore().Exists("project-x"))
+
+		_, err = runSessionTestCommand(t, sessionsCmd, "sessions", "rm", "project-x")
+		assert.ErrorIs(t, err, session.ErrNotFound)
+	})
+}
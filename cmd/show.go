@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// showSections lists the sections of the show command in display order
+var showSections = []string{"info", "capabilities", "parameters", "template", "system", "license", "modelfile"}
+
+// modelShowOutput is the structured form of the show command output
+type modelShowOutput struct {
+	Name            string                 `json:"name" yaml:"name"`
+	Architecture    string                 `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	ParameterSize   string                 `json:"parameter_size,omitempty" yaml:"parameter_size,omitempty"`
+	Quantization    string                 `json:"quantization,omitempty" yaml:"quantization,omitempty"`
+	ContextLength   int                    `json:"context_length,omitempty" yaml:"context_length,omitempty"`
+	EmbeddingLength int                    `json:"embedding_length,omitempty" yaml:"embedding_length,omitempty"`
+	ModelInfo       map[string]interface{} `json:"model_info,omitempty" yaml:"model_info,omitempty"`
+	Capabilities    []string               `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	Parameters      string                 `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Template        string                 `json:"template,omitempty" yaml:"template,omitempty"`
+	System          string                 `json:"system,omitempty" yaml:"system,omitempty"`
+	License         string                 `json:"license,omitempty" yaml:"license,omitempty"`
+	Modelfile       string                 `json:"modelfile,omitempty" yaml:"modelfile,omitempty"`
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show [model]",
+	Short: "Show information about a model",
+	Long: `Show information about a model on the remote Ollama server, including its architecture,
+context length, capabilities, parameters, template, system prompt, license and Modelfile.
+
+By default all sections except the Modelfile are displayed. Use the section flags to select
+only the sections you need.
+
+Examples:
+  # Show an overview of a model
+  ollama-cli show llama3.2
+
+  # Show only the Modelfile
+  ollama-cli show llama3.2 --modelfile
+
+  # Show the context length and capabilities as JSON
+  ollama-cli show llama3.2 --info --capabilities -o json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeModelNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modelName := args[0]
+		format, _ := cmd.Flags().GetString("output")
+
+		// Collect the selected sections, defaulting to everything but the Modelfile
+		selected := make(map[string]bool)
+		for _, section := range showSections {
+			if enabled, _ := cmd.Flags().GetBool(section); enabled {
+				selected[section] = true
+			}
+		}
+		if len(selected) == 0 {
+			for _, section := range showSections {
+				selected[section] = section != "modelfile"
+			}
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		details, err := ollamaClient.GetModelDetails(context.Background(), modelName)
+		if err != nil {
+			return fmt.Errorf("failed to show model: %w", err)
+		}
+
+		result := buildModelShowOutput(modelName, details, selected)
+
+		switch strings.ToLower(format) {
+		case "json":
+			return outputShowJSON(cmd.OutOrStdout(), result)
+		case "yaml":
+			return outputShowYAML(cmd.OutOrStdout(), result)
+		case "table":
+			return outputShowTable(cmd.OutOrStdout(), result, selected)
+		default:
+			return fmt.Errorf("invalid output format: %s", format)
+		}
+	},
+}
+
+// buildModelShowOutput extracts the selected sections from a show response
+func buildModelShowOutput(modelName string, details *api.ShowResponse, selected map[string]bool) *modelShowOutput {
+	result := &modelShowOutput{Name: modelName}
+
+	if selected["info"] {
+		result.Architecture = modelArchitecture(details)
+		result.ParameterSize = details.Details.ParameterSize
+		result.Quantization = details.Details.QuantizationLevel
+		result.ContextLength = modelContextLength(details)
+		result.EmbeddingLength = modelInfoInt(details, "embedding_length")
+		if result.EmbeddingLength == 0 {
+			result.EmbeddingLength = details.Details.EmbeddingLength
+		}
+		result.ModelInfo = details.ModelInfo
+	}
+	if selected["capabilities"] {
+		for _, capability := range details.Capabilities {
+			result.Capabilities = append(result.Capabilities, string(capability))
+		}
+	}
+	if selected["parameters"] {
+		result.Parameters = details.Parameters
+	}
+	if selected["template"] {
+		result.Template = details.Template
+	}
+	if selected["system"] {
+		result.System = details.System
+	}
+	if selected["license"] {
+		result.License = details.License
+	}
+	if selected["modelfile"] {
+		result.Modelfile = details.Modelfile
+	}
+
+	return result
+}
+
+// modelArchitecture returns the architecture of a model from its model info
+func modelArchitecture(details *api.ShowResponse) string {
+	if details == nil {
+		return ""
+	}
+	if arch, ok := details.ModelInfo["general.architecture"].(string); ok && arch != "" {
+		return arch
+	}
+	return details.Details.Family
+}
+
+// modelInfoInt returns an architecture specific integer value from the model info,
+// e.g. "llama.context_length" for key "context_length"
+func modelInfoInt(details *api.ShowResponse, key string) int {
+	if details == nil {
+		return 0
+	}
+
+	arch := modelArchitecture(details)
+	value, ok := details.ModelInfo[arch+"."+key]
+	if !ok {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+// modelContextLength returns the maximum context length supported by a model
+func modelContextLength(details *api.ShowResponse) int {
+	if contextLength := modelInfoInt(details, "context_length"); contextLength > 0 {
+		return contextLength
+	}
+	if details == nil {
+		return 0
+	}
+	return details.Details.ContextLength
+}
+
+// outputShowJSON outputs the model information in JSON format
+func outputShowJSON(out io.Writer, result *modelShowOutput) error {
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal model information to JSON: %w", err)
+	}
+
+	fmt.Fprintln(out, string(jsonData))
+	return nil
+}
+
+// outputShowYAML outputs the model information in YAML format
+func outputShowYAML(out io.Writer, result *modelShowOutput) error {
+	yamlData, err := yaml.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal model information to YAML: %w", err)
+	}
+
+	fmt.Fprint(out, string(yamlData))
+	return nil
+}
+
+// outputShowTable outputs the model information as human readable sections
+func outputShowTable(out io.Writer, result *modelShowOutput, selected map[string]bool) error {
+	first := true
+	printSection := func(title string) {
+		if !first {
+			fmt.Fprintln(out)
+		}
+		first = false
+		fmt.Fprintln(out, output.MakeHeader(title))
+	}
+
+	if selected["info"] {
+		printSection("Model")
+		w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(w, "  architecture\t%s\n", output.Highlight(getOrDefault(result.Architecture, "N/A")))
+		fmt.Fprintf(w, "  parameters\t%s\n", output.Info(getOrDefault(result.ParameterSize, "N/A")))
+		fmt.Fprintf(w, "  context length\t%s\n", output.Info(formatOptionalInt(result.ContextLength)))
+		fmt.Fprintf(w, "  embedding length\t%s\n", output.Info(formatOptionalInt(result.EmbeddingLength)))
+		fmt.Fprintf(w, "  quantization\t%s\n", output.Info(getOrDefault(result.Quantization, "N/A")))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if selected["capabilities"] && len(result.Capabilities) > 0 {
+		printSection("Capabilities")
+		capabilities := append([]string(nil), result.Capabilities...)
+		sort.Strings(capabilities)
+		for _, capability := range capabilities {
+			fmt.Fprintf(out, "  %s\n", output.Highlight(capability))
+		}
+	}
+
+	textSections := []struct {
+		key   string
+		title string
+		text  string
+	}{
+		{"parameters", "Parameters", result.Parameters},
+		{"template", "Template", result.Template},
+		{"system", "System", result.System},
+		{"license", "License", result.License},
+		{"modelfile", "Modelfile", result.Modelfile},
+	}
+	for _, section := range textSections {
+		if !selected[section.key] || strings.TrimSpace(section.text) == "" {
+			continue
+		}
+		printSection(section.title)
+		for _, line := range strings.Split(strings.TrimRight(section.text, "\n"), "\n") {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+// formatOptionalInt formats an integer, using N/A for zero values
+func formatOptionalInt(value int) string {
+	if value == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringP("output", "o", "table", "Output format (table, json, yaml)")
+	showCmd.Flags().Bool("info", false, "Show model info (architecture, context length, embedding length)")
+	showCmd.Flags().Bool("capabilities", false, "Show the model's capabilities")
+	showCmd.Flags().Bool("parameters", false, "Show the model's parameters")
+	showCmd.Flags().Bool("template", false, "Show the model's prompt template")
+	showCmd.Flags().Bool("system", false, "Show the model's system prompt")
+	showCmd.Flags().Bool("license", false, "Show the model's license")
+	showCmd.Flags().Bool("modelfile", false, "Show the model's Modelfile")
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/ollama/ollama/api"
+	"github.com/ollama/ollama/types/model"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShowCommand(t *testing.T) {
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	mockDetails := &api.ShowResponse{
+		Modelfile:  "FROM llama3.2\nPARAMETER temperature 0.7",
+		Parameters: "temperature 0.7",
+		Template:   "{{ .Prompt }}",
+		System:     "You are a helpful assistant.",
+		License:    "MIT License",
+		Details: api.ModelDetails{
+			Family:            "llama",
+			ParameterSize:     "3.2B",
+			QuantizationLevel: "Q4_K_M",
+		},
+		ModelInfo: map[string]interface{}{
+			"general.architecture":   "llama",
+			"llama.context_length":   float64(131072),
+			"llama.embedding_length": float64(3072),
+		},
+		Capabilities: []model.Capability{model.CapabilityCompletion, model.CapabilityTools},
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		setupMock      func(*client.MockClientTestify)
+		wantErr        string
+		wantContain    []string
+		wantNotContain []string
+	}{
+		{
+			name: "Default sections",
+			args: []string{"show", "llama3.2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "llama3.2").Return(mockDetails, nil)
+			},
+			wantContain:    []string{"131072", "3072", "Q4_K_M", "completion", "tools", "temperature 0.7", "{{ .Prompt }}", "You are a helpful assistant.", "MIT License"},
+			wantNotContain: []string{"Modelfile", "FROM llama3.2"},
+		},
+		{
+			name: "Modelfile only",
+			args: []string{"show", "llama3.2", "--modelfile"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "llama3.2").Return(mockDetails, nil)
+			},
+			wantContain:    []string{"FROM llama3.2"},
+			wantNotContain: []string{"131072", "MIT License"},
+		},
+		{
+			name: "JSON output with selected sections",
+			args: []string{"show", "llama3.2", "--info", "--capabilities", "-o", "json"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "llama3.2").Return(mockDetails, nil)
+			},
+			wantContain:    []string{`"context_length": 131072`, `"embedding_length": 3072`, `"architecture": "llama"`, `"tools"`},
+			wantNotContain: []string{`"license"`},
+		},
+		{
+			name: "YAML output",
+			args: []string{"show", "llama3.2", "--system", "-o", "yaml"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "llama3.2").Return(mockDetails, nil)
+			},
+			wantContain: []string{"name: llama3.2", "system: You are a helpful assistant."},
+		},
+		{
+			name: "Invalid output format",
+			args: []string{"show", "llama3.2", "-o", "xml"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "llama3.2").Return(mockDetails, nil)
+			},
+			wantErr: "invalid output format: xml",
+		},
+		{
+			name: "Error from client",
+			args: []string{"show", "missing"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("GetModelDetails", mock.Anything, "missing").Return(nil, errors.New("model not found"))
+			},
+			wantErr: "failed to show model: model not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(showCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(showCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			for _, notWant := range tt.wantNotContain {
+				assert.NotContains(t, buf.String(), notWant)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestModelContextLengthFallback(t *testing.T) {
+	details := &api.ShowResponse{
+		Details: api.ModelDetails{ContextLength: 8192},
+	}
+	assert.Equal(t, 8192, modelContextLength(details))
+	assert.Equal(t, 0, modelContextLength(nil))
+}
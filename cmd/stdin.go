@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/masgari/ollama-cli/pkg/security"
+)
+
+// stdinArg is the file argument that stands for stdin, or stdout for output files
+const stdinArg = "-"
+
+// defaultStdinTemplate attaches piped input to a prompt, delimited so the model can
+// tell the input from the instructions
+const defaultStdinTemplate = "{{.Prompt}}\n\n<input>\n{{.Input}}\n</input>"
+
+// stdinPrompt holds the values of the template that attaches piped input to a prompt
+type stdinPrompt struct {
+	Prompt string
+	Input  string
+}
+
+// parseStdinTemplate parses a template that attaches piped input to a prompt
+func parseStdinTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("stdin").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stdin template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// stdinTemplateText returns the template of a configuration, or the default one
+func stdinTemplateText(cfg *config.Config) string {
+	if cfg != nil && cfg.StdinTemplate != "" {
+		return cfg.StdinTemplate
+	}
+	return defaultStdinTemplate
+}
+
+// composeStdinPrompt attaches piped input to a prompt with a template, which refers
+// to them as {{.Prompt}} and {{.Input}}
+func composeStdinPrompt(text, prompt, input string) (string, error) {
+	tmpl, err := parseStdinTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, stdinPrompt{Prompt: prompt, Input: strings.TrimRight(input, "\r\n")}); err != nil {
+		return "", fmt.Errorf("invalid stdin template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// checkInput checks input attached to a message, such as piped input or files, for
+// prompt injection. The input is data rather than a prompt, so it is not truncated,
+// but suspicious patterns are reported and filtered out in strict mode.
+func checkInput(status *output.ColorWriter, source, input string, strictSecurity bool) string {
+	if !security.IsPromptInjectionAttempt(input) {
+		return input
+	}
+
+	status.WarningPrintf("%s contains patterns that may be interpreted as prompt injection attempts.\n", source)
+	if !strictSecurity {
+		return input
+	}
+	filtered, warnings := security.FilterInput(input)
+	for _, warning := range warnings {
+		status.WarningPrintf("%s\n", warning)
+	}
+	return filtered
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+)
+
+// stopCmd represents the stop command
+var stopCmd = &cobra.Command{
+	Use:   "stop [model...]",
+	Short: "Unload models from memory",
+	Long: `Unload one or more models from memory on the remote Ollama server.
+
+Examples:
+  # Unload a single model
+  ollama-cli stop llama3.2
+
+  # Unload every model that is currently loaded
+  ollama-cli stop --all`,
+	ValidArgsFunction: completeRunningModelNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stopAll, _ := cmd.Flags().GetBool("all")
+
+		if stopAll && len(args) > 0 {
+			return fmt.Errorf("cannot specify models together with --all")
+		}
+		if !stopAll && len(args) == 0 {
+			return fmt.Errorf("specify at least one model or use --all")
+		}
+
+		ollamaClient, err := createOllamaClient()
+		if err != nil {
+			return err
+		}
+
+		// Check which models are loaded
+		running, err := ollamaClient.ListRunning(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to list running models: %w", err)
+		}
+
+		modelNames := args
+		if stopAll {
+			modelNames = nil
+			for _, model := range running.Models {
+				modelNames = append(modelNames, model.Name)
+			}
+			if len(modelNames) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No models are currently loaded.")
+				return nil
+			}
+		}
+
+		failed := 0
+		for _, modelName := range modelNames {
+			name, ok := runningModelName(running.Models, modelName)
+			if !ok {
+				output.Default.ErrorPrintf("Model '%s' is not loaded.\n", modelName)
+				failed++
+				continue
+			}
+			modelName = name
+
+			if err := ollamaClient.UnloadModel(context.Background(), modelName); err != nil {
+				output.Default.ErrorPrintf("Failed to stop model '%s': %v\n", modelName, err)
+				failed++
+				continue
+			}
+
+			output.Default.SuccessPrintf("Model '%s' stopped.\n", output.Highlight(modelName))
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("failed to stop %d of %d models", failed, len(modelNames))
+		}
+
+		return nil
+	},
+}
+
+// runningModelName returns the name the server uses for a running model, matching
+// both the name and the model of each entry, so that "llama3.2" finds "llama3.2:latest"
+func runningModelName(models []api.ProcessModelResponse, name string) (string, bool) {
+	for _, model := range models {
+		if sameModelName(model.Name, name) || sameModelName(model.Model, name) {
+			return model.Name, true
+		}
+	}
+	return "", false
+}
+
+func init() {
+	rootCmd.AddCommand(stopCmd)
+
+	stopCmd.Flags().BoolP("all", "a", false, "Unload all models currently loaded in memory")
+}
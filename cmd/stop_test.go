@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStopCommand(t *testing.T) {
+	// Save original output and restore it after the test
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+
+	origCfg := config.Current
+	defer func() { config.Current = origCfg }()
+	config.Current = config.DefaultConfig()
+
+	running := &api.ProcessResponse{
+		Models: []api.ProcessModelResponse{
+			{Name: "model1"},
+			{Name: "model2"},
+			{Name: "llama3.2:latest", Model: "llama3.2:latest"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		setupMock   func(*client.MockClientTestify)
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name: "Stop a single model",
+			args: []string{"stop", "model1"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(running, nil)
+				m.On("UnloadModel", mock.Anything, "model1").Return(nil)
+			},
+			wantContain: []string{"Model 'model1' stopped."},
+		},
+		{
+			name: "Stop all models",
+			args: []string{"stop", "--all"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(running, nil)
+				m.On("UnloadModel", mock.Anything, "model1").Return(nil)
+				m.On("UnloadModel", mock.Anything, "model2").Return(nil)
+				m.On("UnloadModel", mock.Anything, "llama3.2:latest").Return(nil)
+			},
+			wantContain: []string{"Model 'model1' stopped.", "Model 'model2' stopped.", "Model 'llama3.2:latest' stopped."},
+		},
+		{
+			name: "Stop a model without its latest tag",
+			args: []string{"stop", "llama3.2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(running, nil)
+				m.On("UnloadModel", mock.Anything, "llama3.2:latest").Return(nil)
+			},
+			wantContain: []string{"Model 'llama3.2:latest' stopped."},
+		},
+		{
+			name: "Stop all with nothing loaded",
+			args: []string{"stop", "--all"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(&api.ProcessResponse{}, nil)
+			},
+			wantContain: []string{"No models are currently loaded."},
+		},
+		{
+			name: "Model not loaded",
+			args: []string{"stop", "model3"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(running, nil)
+			},
+			wantErr:     "failed to stop 1 of 1 models",
+			wantContain: []string{"Model 'model3' is not loaded."},
+		},
+		{
+			name: "Partial failure",
+			args: []string{"stop", "model1", "model2"},
+			setupMock: func(m *client.MockClientTestify) {
+				m.On("ListRunning", mock.Anything).Return(running, nil)
+				m.On("UnloadModel", mock.Anything, "model1").Return(errors.New("connection error"))
+				m.On("UnloadModel", mock.Anything, "model2").Return(nil)
+			},
+			wantErr:     "failed to stop 1 of 2 models",
+			wantContain: []string{"Failed to stop model 'model1': connection error", "Model 'model2' stopped."},
+		},
+		{
+			name:      "No models and no --all",
+			args:      []string{"stop"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "specify at least one model or use --all",
+		},
+		{
+			name:      "Models together with --all",
+			args:      []string{"stop", "model1", "--all"},
+			setupMock: func(m *client.MockClientTestify) {},
+			wantErr:   "cannot specify models together with --all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(stopCmd)
+
+			mockClient := client.NewMockClient()
+			tt.setupMock(mockClient)
+			client.SetClientFactory(func() (client.Client, error) {
+				return mockClient, nil
+			})
+			defer client.ResetClientFactory()
+
+			var buf bytes.Buffer
+			output.Default = output.NewColorWriter(&buf)
+
+			root := &cobra.Command{Use: "ollama-cli"}
+			root.SetOut(&buf)
+			root.SetErr(&buf)
+			root.AddCommand(stopCmd)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, want := range tt.wantContain {
+				assert.Contains(t, buf.String(), want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
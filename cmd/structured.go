@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/masgari/ollama-cli/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// structuredOutput constrains model replies to JSON, optionally matching a schema
+type structuredOutput struct {
+	// format is sent as the format of the request
+	format json.RawMessage
+	// schema validates replies locally, nil only checks for valid JSON
+	schema *schema.Schema
+	// retries is how often an invalid reply is retried
+	retries int
+}
+
+// addStructuredOutputFlags adds the flags for structured output to a command
+func addStructuredOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", "", "Format of the reply (json), only the validated reply is written to stdout")
+	cmd.Flags().String("schema", "", "JSON schema file the reply must match (implies --format json)")
+	cmd.Flags().Int("retries", 0, "Number of retries when the reply is invalid, sending the validation errors to the model")
+}
+
+// structuredOutputFromFlags returns the structured output settings of a command,
+// or nil when neither --format nor --schema is set
+func structuredOutputFromFlags(cmd *cobra.Command) (*structuredOutput, error) {
+	format, _ := cmd.Flags().GetString("format")
+	schemaFile, _ := cmd.Flags().GetString("schema")
+	retries, _ := cmd.Flags().GetInt("retries")
+
+	format = strings.ToLower(format)
+	if format != "" && format != "json" {
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+	if format == "" && schemaFile == "" {
+		return nil, nil
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("retries cannot be negative")
+	}
+
+	structured := &structuredOutput{format: json.RawMessage(`"json"`), retries: retries}
+	if schemaFile != "" {
+		s, err := schema.Load(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		structured.schema = s
+		structured.format = s.Raw()
+	}
+
+	return structured, nil
+}
+
+// validate checks a reply and returns it without surrounding whitespace
+func (s *structuredOutput) validate(reply string) (string, error) {
+	reply = strings.TrimSpace(reply)
+	if err := s.schema.Validate([]byte(reply)); err != nil {
+		return "", err
+	}
+	return reply, nil
+}
+
+// retryPrompt asks the model to correct a reply that failed validation
+func (s *structuredOutput) retryPrompt(err error) string {
+	var b strings.Builder
+	b.WriteString("Your reply is invalid:\n")
+
+	var validationErr *schema.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, problem := range validationErr.Problems {
+			fmt.Fprintf(&b, "- %s\n", problem)
+		}
+	} else {
+		fmt.Fprintf(&b, "- %s\n", err)
+	}
+
+	if s.schema != nil {
+		b.WriteString("Reply again with only JSON that matches the schema.")
+	} else {
+		b.WriteString("Reply again with only valid JSON.")
+	}
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+)
+
+// Display modes for thinking traces
+const (
+	thinkingCollapse = "collapse"
+	thinkingShow     = "show"
+	thinkingHide     = "hide"
+)
+
+// thinkingPreviewWidth limits the preview of a collapsed thinking trace
+const thinkingPreviewWidth = 60
+
+// parseThinkFlag converts the value of --think to the think option of a request.
+// It returns nil when the flag is not set, so the server default applies.
+func parseThinkFlag(value string) (*api.ThinkValue, error) {
+	value = strings.ToLower(value)
+	switch value {
+	case "":
+		return nil, nil
+	case "true":
+		return &api.ThinkValue{Value: true}, nil
+	case "false":
+		return &api.ThinkValue{Value: false}, nil
+	case "low", "medium", "high", "max":
+		return &api.ThinkValue{Value: value}, nil
+	default:
+		return nil, fmt.Errorf("invalid think value: %s (must be true, false, low, medium, high or max)", value)
+	}
+}
+
+// validateThinkingDisplay checks the value of --thinking-display
+func validateThinkingDisplay(mode string) error {
+	switch mode {
+	case thinkingCollapse, thinkingShow, thinkingHide:
+		return nil
+	default:
+		return fmt.Errorf("invalid thinking display: %s (must be collapse, show or hide)", mode)
+	}
+}
+
+// thinkingRenderer displays the thinking trace of a response separately from the
+// answer. On a terminal a collapsed trace is previewed on a single dimmed line that
+// is replaced by a summary once the answer starts. A trace shown in full is written
+// dimmed between markers. Otherwise nothing is written, so answers can be piped.
+type thinkingRenderer struct {
+	out    io.Writer
+	mode   string
+	tty    bool
+	start  time.Time
+	text   strings.Builder
+	active bool
+}
+
+// newThinkingRenderer creates a renderer for the thinking trace of one response
+func newThinkingRenderer(out io.Writer, mode string, tty bool) *thinkingRenderer {
+	return &thinkingRenderer{out: out, mode: mode, tty: tty}
+}
+
+// visible reports whether the renderer writes anything
+func (r *thinkingRenderer) visible() bool {
+	return r.mode == thinkingShow || (r.mode == thinkingCollapse && r.tty)
+}
+
+// shown reports whether a thinking trace was written, the answer then starts on
+// its own line
+func (r *thinkingRenderer) shown() bool {
+	return !r.start.IsZero() && r.visible()
+}
+
+// Thinking renders a chunk of the thinking trace
+func (r *thinkingRenderer) Thinking(chunk string) {
+	if chunk == "" {
+		return
+	}
+
+	if !r.active {
+		r.active = true
+		if r.start.IsZero() {
+			r.start = timeNow()
+		}
+		// Start on a new line, after any "Assistant:" prompt
+		if r.visible() {
+			fmt.Fprintln(r.out)
+		}
+		if r.mode == thinkingShow {
+			fmt.Fprintln(r.out, output.Dim("Thinking..."))
+		}
+	}
+	r.text.WriteString(chunk)
+
+	switch {
+	case r.mode == thinkingShow:
+		fmt.Fprint(r.out, output.Dim(chunk))
+	case r.mode == thinkingCollapse && r.tty:
+		fmt.Fprintf(r.out, "\r\033[2K%s", output.Dim("Thinking... "+thinkingPreview(r.text.String())))
+	}
+}
+
+// Done ends the thinking section, it is called when the answer starts or the
+// response is complete
+func (r *thinkingRenderer) Done() {
+	if !r.active {
+		return
+	}
+	r.active = false
+
+	switch {
+	case r.mode == thinkingShow:
+		fmt.Fprintf(r.out, "\n%s\n\n", output.Dim("...done thinking."))
+	case r.mode == thinkingCollapse && r.tty:
+		duration := timeNow().Sub(r.start)
+		summary := fmt.Sprintf("Thought for %s (%d words)", formatDuration(float64(duration)/1e6), countWords(r.text.String()))
+		fmt.Fprintf(r.out, "\r\033[2K%s\n", output.Dim(summary))
+	}
+}
+
+// thinkingPreview returns the end of a thinking trace on a single line
+func thinkingPreview(text string) string {
+	preview := []rune(strings.Join(strings.Fields(text), " "))
+	if len(preview) > thinkingPreviewWidth {
+		return "..." + string(preview[len(preview)-thinkingPreviewWidth:])
+	}
+	return string(preview)
+}
+
+// countWords counts the words of a text
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// withoutThinking returns a copy of the messages without thinking traces, which are
+// kept for transcripts but not sent back to the model
+func withoutThinking(messages []api.Message) []api.Message {
+	stripped := make([]api.Message, len(messages))
+	for i, msg := range messages {
+		msg.Thinking = ""
+		stripped[i] = msg
+	}
+	return stripped
+}
+
+// displayThinkingStats adds the size of the thinking traces of a reply to the statistics
+func displayThinkingStats(messages []api.Message) {
+	words := 0
+	for _, msg := range messages {
+		words += countWords(msg.Thinking)
+	}
+	if words > 0 {
+		output.GetStdErr().InfoPrintf("  Thinking: %d words\n", words)
+	}
+}
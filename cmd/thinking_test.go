@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/client"
+	"github.com/masgari/ollama-cli/pkg/output"
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseThinkFlag(t *testing.T) {
+	think, err := parseThinkFlag("")
+	assert.NoError(t, err)
+	assert.Nil(t, think)
+
+	think, err = parseThinkFlag("true")
+	assert.NoError(t, err)
+	assert.Equal(t, true, think.Value)
+
+	think, err = parseThinkFlag("HIGH")
+	assert.NoError(t, err)
+	assert.Equal(t, "high", think.Value)
+
+	_, err = parseThinkFlag("extreme")
+	assert.EqualError(t, err, "invalid think value: extreme (must be true, false, low, medium, high or max)")
+
+	assert.NoError(t, validateThinkingDisplay(thinkingShow))
+	assert.Error(t, validateThinkingDisplay("expand"))
+}
+
+func TestThinkingRenderer(t *testing.T) {
+	origTimeNow := timeNow
+	defer func() { timeNow = origTimeNow }()
+	now := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	render := func(mode string, tty bool) string {
+		var out bytes.Buffer
+		r := newThinkingRenderer(&out, mode, tty)
+		r.Thinking("Let me ")
+		r.Thinking("think about it.")
+		r.Done()
+		r.Done()
+		return out.String()
+	}
+
+	assert.Equal(t, "\nThinking...\nLet me think about it.\n...done thinking.\n\n", render(thinkingShow, false))
+	assert.Empty(t, render(thinkingHide, true))
+	// A collapsed trace is only displayed on a terminal
+	assert.Empty(t, render(thinkingCollapse, false))
+
+	collapsed := render(thinkingCollapse, true)
+	assert.Contains(t, collapsed, "\r\033[2KThinking... Let me think about it.")
+	assert.True(t, strings.HasSuffix(collapsed, "\r\033[2KThought for 1.00 s (5 words)\n"))
+}
+
+func TestThinkingPreview(t *testing.T) {
+	assert.Equal(t, "first second", thinkingPreview("first\n\nsecond"))
+
+	long := strings.Repeat("a", thinkingPreviewWidth) + "end"
+	preview := thinkingPreview(long)
+	assert.True(t, strings.HasPrefix(preview, "..."))
+	assert.True(t, strings.HasSuffix(preview, "end"))
+	assert.Len(t, []rune(preview), thinkingPreviewWidth+3)
+}
+
+func TestSendChatMessageWithThinking(t *testing.T) {
+	origOutput := output.Default
+	defer func() { output.Default = origOutput }()
+	output.Default = output.NewColorWriter(&bytes.Buffer{})
+
+	origIsStdoutTerminal := isStdoutTerminal
+	defer func() { isStdoutTerminal = origIsStdoutTerminal }()
+	isStdoutTerminal = func() bool { return false }
+
+	history := []api.Message{
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello", Thinking: "The user greets me."},
+		{Role: "user", Content: "What is 2+2?"},
+	}
+	response := &api.ChatResponse{Message: api.Message{Role: "assistant", Content: "4", Thinking: "Simple addition."}, Done: true}
+
+	tests := []struct {
+		name         string
+		keepThinking bool
+	}{
+		{name: "Thinking is removed from the history"},
+		{name: "Thinking is kept in the history", keepThinking: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := client.NewMockClient()
+			mockClient.On("ChatWithModel", mock.Anything, mock.MatchedBy(func(req *api.ChatRequest) bool {
+				return req.Think != nil && req.Think.Value == "high" &&
+					(req.Messages[1].Thinking != "") == tt.keepThinking
+			}), mock.Anything).
+				Run(func(args mock.Arguments) {
+					fn := args.Get(2).(client.ChatChunkFunc)
+					_ = fn(client.ChatChunk{Thinking: "Simple addition."})
+					_ = fn(client.ChatChunk{Content: "4", Done: true})
+				}).
+				Return(response, nil)
+
+			var out bytes.Buffer
+			settings := &chatSettings{
+				model:           "test-model",
+				stream:          true,
+				think:           &api.ThinkValue{Value: "high"},
+				thinkingDisplay: thinkingShow,
+				keepThinking:    tt.keepThinking,
+			}
+			_, added, err := sendChatMessage(context.Background(), mockClient, &out, settings, history)
+			assert.NoError(t, err)
+			assert.Equal(t, "\nThinking...\nSimple addition.\n...done thinking.\n\n4\n", out.String())
+
+			// The transcript keeps the thinking trace
+			assert.Equal(t, "Simple addition.", added[0].Thinking)
+			assert.Equal(t, "The user greets me.", history[1].Thinking)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// Package attach collects text files to inline in chat messages. Directories and
+// glob patterns are expanded, files ignored by .gitignore and binary files are
+// skipped, and the total size is kept within a budget.
+package attach
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLength is the number of bytes checked to detect binary files
+const sniffLength = 8000
+
+// Reasons for skipping files
+const (
+	SkipBinary = "binary file"
+	SkipBudget = "over the size budget"
+	SkipError  = "cannot be read"
+)
+
+// File is a text file to inline in a message
+type File struct {
+	Path    string
+	Content string
+}
+
+// Skipped is a file that was not attached
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// Collector collects the files to attach to a message
+type Collector struct {
+	// budget is the maximum total size of the files in bytes, zero for no limit
+	budget int
+	used   int
+	seen   map[string]bool
+
+	Files   []File
+	Skipped []Skipped
+}
+
+// NewCollector creates a collector for files up to a total size in bytes, zero for
+// no limit
+func NewCollector(budget int) *Collector {
+	return &Collector{budget: budget, seen: make(map[string]bool)}
+}
+
+// Size returns the total size of the collected files in bytes
+func (c *Collector) Size() int {
+	return c.used
+}
+
+// Budget returns the maximum total size of the files in bytes, zero for no limit
+func (c *Collector) Budget() int {
+	return c.budget
+}
+
+// Add collects a file, the files under a directory or the files matching a glob
+// pattern, in which ** matches any number of directories. Files named directly are
+// always attached, others are skipped when ignored by .gitignore.
+func (c *Collector) Add(pattern string) error {
+	if !hasMeta(pattern) {
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return fmt.Errorf("failed to attach %s: %w", pattern, err)
+		}
+		if info.IsDir() {
+			return c.addDir(pattern, newIgnorer(absPath(pattern)))
+		}
+		c.addFile(pattern, info)
+		return nil
+	}
+
+	base := globBase(pattern)
+	matches, err := glob(base, pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no files match %s", pattern)
+	}
+
+	ig := newIgnorer(absPath(base))
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			c.skip(match, SkipError)
+			continue
+		}
+		if ig.ignored(absPath(match), info.IsDir()) {
+			continue
+		}
+		if info.IsDir() {
+			if err := c.addDir(match, ig); err != nil {
+				return err
+			}
+			continue
+		}
+		c.addFile(match, info)
+	}
+	return nil
+}
+
+// addDir collects the files under a directory that are not ignored
+func (c *Collector) addDir(dir string, ig *ignorer) error {
+	return filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			c.skip(name, SkipError)
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name != dir && ig.ignored(absPath(name), entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			c.skip(name, SkipError)
+			return nil
+		}
+		c.addFile(name, info)
+		return nil
+	})
+}
+
+// addFile collects a text file if it fits in the budget
+func (c *Collector) addFile(name string, info fs.FileInfo) {
+	name = displayPath(name)
+	if c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+
+	if c.budget > 0 && c.used+int(info.Size()) > c.budget {
+		c.skip(name, SkipBudget)
+		return
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		c.skip(name, SkipError)
+		return
+	}
+	if isBinary(data) {
+		c.skip(name, SkipBinary)
+		return
+	}
+
+	c.used += len(data)
+	c.Files = append(c.Files, File{Path: name, Content: string(data)})
+}
+
+func (c *Collector) skip(name, reason string) {
+	c.Skipped = append(c.Skipped, Skipped{Path: displayPath(name), Reason: reason})
+}
+
+// Format returns the collected files as Markdown code blocks, each preceded by the
+// path of the file
+func (c *Collector) Format() string {
+	blocks := make([]string, 0, len(c.Files))
+	for _, file := range c.Files {
+		content := strings.TrimRight(file.Content, "\r\n")
+		fence := codeFence(content)
+		lang := strings.TrimPrefix(filepath.Ext(file.Path), ".")
+		blocks = append(blocks, fmt.Sprintf("File: %s\n%s%s\n%s\n%s", file.Path, fence, lang, content, fence))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// codeFence returns a fence longer than any run of backticks in content
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// isBinary reports whether data looks like the content of a binary file
+func isBinary(data []byte) bool {
+	sniff := data[:min(len(data), sniffLength)]
+	return bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(data)
+}
+
+// hasMeta reports whether a path contains glob characters
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globBase returns the directory of a pattern before its first glob character
+func globBase(pattern string) string {
+	pattern = filepath.ToSlash(pattern)
+	i := strings.IndexAny(pattern, "*?[")
+	base := pattern[:strings.LastIndex(pattern[:i], "/")+1]
+	if base == "" {
+		return "."
+	}
+	return filepath.FromSlash(base)
+}
+
+// glob returns the paths matching a pattern, searching the directory tree under base
+// when the pattern contains **
+func glob(base, pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	slashed := strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	var matches []string
+	err := filepath.WalkDir(base, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if matchGlob(slashed, strings.TrimPrefix(filepath.ToSlash(name), "./")) {
+			matches = append(matches, name)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// absPath returns the absolute form of a path, or the path itself if it fails
+func absPath(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return name
+}
+
+// displayPath returns a clean path with forward slashes
+func displayPath(name string) string {
+	return filepath.ToSlash(filepath.Clean(name))
+}
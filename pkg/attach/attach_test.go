@@ -0,0 +1,173 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree creates files with their content under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// chdir changes the working directory for the duration of a test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(orig) })
+}
+
+// paths returns the paths of the collected files
+func paths(c *Collector) []string {
+	var names []string
+	for _, file := range c.Files {
+		names = append(names, file.Path)
+	}
+	return names
+}
+
+func TestCollectorAdd(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".git/config":           "[core]",
+		".gitignore":            "*.log\n/build/\nvendor\n!keep.log\n# comment\n",
+		"main.go":               "package main\n",
+		"debug.log":             "noise",
+		"keep.log":              "kept",
+		"logo.png":              "\x89PNG\x00\x00",
+		"build/out.txt":         "built",
+		"cmd/run.go":            "package cmd\n",
+		"cmd/build/notes.txt":   "not the root build",
+		"cmd/vendor/lib.go":     "package lib\n",
+		"docs/.gitignore":       "draft.md\n",
+		"docs/guide.md":         "# Guide\n",
+		"docs/draft.md":         "wip",
+		"docs/deep/nested.md":   "deep",
+		"docs/deep/invalid.txt": "\xff\xfe",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		name        string
+		patterns    []string
+		budget      int
+		wantFiles   []string
+		wantSkipped []Skipped
+		wantErr     string
+	}{
+		{
+			name:     "Directory respects .gitignore and skips binaries",
+			patterns: []string{"."},
+			wantFiles: []string{
+				".gitignore", "cmd/build/notes.txt", "cmd/run.go", "docs/.gitignore",
+				"docs/deep/nested.md", "docs/guide.md", "keep.log", "main.go",
+			},
+			wantSkipped: []Skipped{
+				{Path: "docs/deep/invalid.txt", Reason: SkipBinary},
+				{Path: "logo.png", Reason: SkipBinary},
+			},
+		},
+		{
+			name:      "Subdirectory uses the rules of the repository",
+			patterns:  []string{"cmd"},
+			wantFiles: []string{"cmd/build/notes.txt", "cmd/run.go"},
+		},
+		{
+			name:      "Files named directly are always attached",
+			patterns:  []string{"debug.log", "main.go", "./main.go"},
+			wantFiles: []string{"debug.log", "main.go"},
+		},
+		{
+			name:      "Glob",
+			patterns:  []string{"*.log"},
+			wantFiles: []string{"keep.log"},
+		},
+		{
+			name:      "Recursive glob",
+			patterns:  []string{"docs/**/*.md"},
+			wantFiles: []string{"docs/deep/nested.md", "docs/guide.md"},
+		},
+		{
+			name:      "Budget",
+			patterns:  []string{"main.go", "cmd/run.go", "keep.log"},
+			budget:    len("package main\n") + len("kept"),
+			wantFiles: []string{"main.go", "keep.log"},
+			wantSkipped: []Skipped{
+				{Path: "cmd/run.go", Reason: SkipBudget},
+			},
+		},
+		{
+			name:     "Missing file",
+			patterns: []string{"missing.go"},
+			wantErr:  "failed to attach missing.go",
+		},
+		{
+			name:     "Glob without matches",
+			patterns: []string{"*.rs"},
+			wantErr:  "no files match *.rs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector(tt.budget)
+			for _, pattern := range tt.patterns {
+				err := c.Add(pattern)
+				if tt.wantErr != "" {
+					assert.ErrorContains(t, err, tt.wantErr)
+					return
+				}
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantFiles, paths(c))
+			assert.Equal(t, tt.wantSkipped, c.Skipped)
+		})
+	}
+}
+
+func TestCollectorFormat(t *testing.T) {
+	c := &Collector{Files: []File{
+		{Path: "main.go", Content: "package main\n"},
+		{Path: "README.md", Content: "Run:\n```sh\nmake\n```\n"},
+	}}
+
+	want := strings.Join([]string{
+		"File: main.go\n```go\npackage main\n```",
+		"File: README.md\n````md\nRun:\n```sh\nmake\n```\n````",
+	}, "\n\n")
+	assert.Equal(t, want, c.Format())
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/*.go", "cmd/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/main.go", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**", "a/x/y", true},
+		{"a/**/b", "a/x/c", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchGlob(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
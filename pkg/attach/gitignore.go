@@ -0,0 +1,167 @@
+package attach
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns match paths relative to the directory of the .gitignore file,
+	// others match the name of files at any depth
+	anchored bool
+}
+
+// parseIgnoreRule parses a line of a .gitignore file, it returns false for blank
+// lines and comments
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	rule.pattern = line
+	return rule, line != ""
+}
+
+// match reports whether the rule matches a path relative to its directory
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchGlob(r.pattern, rel)
+	}
+	ok, _ := path.Match(r.pattern, path.Base(rel))
+	return ok
+}
+
+// ignorer applies the .gitignore files found from a top directory down to the files
+// it is asked about
+type ignorer struct {
+	top   string
+	rules map[string][]ignoreRule
+}
+
+// newIgnorer creates an ignorer for the files under dir. The rules of the repository
+// that contains dir apply from its root, otherwise only the rules from dir down.
+func newIgnorer(dir string) *ignorer {
+	top := dir
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			top = d
+			break
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	return &ignorer{top: top, rules: make(map[string][]ignoreRule)}
+}
+
+// load returns the rules of the .gitignore file of a directory
+func (ig *ignorer) load(dir string) []ignoreRule {
+	if rules, ok := ig.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	if file, err := os.Open(filepath.Join(dir, ".gitignore")); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		file.Close()
+	}
+	ig.rules[dir] = rules
+	return rules
+}
+
+// ignored reports whether a path, or one of its parent directories, is ignored.
+// Paths outside of the top directory are never ignored.
+func (ig *ignorer) ignored(name string, isDir bool) bool {
+	rel, err := filepath.Rel(ig.top, name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		if ig.matches(parts[:i+1], isDir || i < len(parts)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches applies the rules of the directories above a path, the last matching
+// rule decides whether it is ignored
+func (ig *ignorer) matches(parts []string, isDir bool) bool {
+	if parts[len(parts)-1] == ".git" {
+		return true
+	}
+
+	ignored := false
+	dir := ig.top
+	for i := range parts {
+		rel := strings.Join(parts[i:], "/")
+		for _, rule := range ig.load(dir) {
+			if rule.match(rel, isDir) {
+				ignored = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// matchGlob matches a slash separated path against a pattern in which ** matches
+// any number of directories
+func matchGlob(pattern, name string) bool {
+	return matchParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
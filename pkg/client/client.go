@@ -3,16 +3,16 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"errors"
 	"net"
 
 	"github.com/masgari/ollama-cli/pkg/config"
-	"github.com/masgari/ollama-cli/pkg/output"
-	"github.com/masgari/ollama-cli/pkg/security"
 	"github.com/ollama/ollama/api"
 )
 
@@ -21,10 +21,45 @@ type Client interface {
 	ListModels(ctx context.Context) (*api.ListResponse, error)
 	GetModelDetails(ctx context.Context, modelName string) (*api.ShowResponse, error)
 	DeleteModel(ctx context.Context, modelName string) error
-	PullModel(ctx context.Context, modelName string) error
-	ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error)
+	CopyModel(ctx context.Context, source, destination string) error
+	CreateModel(ctx context.Context, req *api.CreateRequest, fn ProgressFunc) error
+	HasBlob(ctx context.Context, digest string) (bool, error)
+	CreateBlob(ctx context.Context, digest string, r io.Reader) error
+	PullModel(ctx context.Context, modelName string, fn ProgressFunc) error
+	PushModel(ctx context.Context, modelName string, insecure bool, fn ProgressFunc) error
+	ChatWithModel(ctx context.Context, req *api.ChatRequest, fn ChatChunkFunc) (*api.ChatResponse, error)
+	Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error)
+	Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error)
+	ListRunning(ctx context.Context) (*api.ProcessResponse, error)
+	LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error
+	UnloadModel(ctx context.Context, modelName string) error
 }
 
+// ProgressEvent is a progress update of a pull, push or create operation
+type ProgressEvent struct {
+	Model     string `json:"model"`
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ProgressFunc is called for every progress update of a long running operation.
+// Returning an error aborts the operation.
+type ProgressFunc func(ProgressEvent) error
+
+// ChatChunk is a piece of a streamed chat response
+type ChatChunk struct {
+	Content   string
+	Thinking  string
+	ToolCalls []api.ToolCall
+	Done      bool
+}
+
+// ChatChunkFunc is called for every chunk of a chat response. Returning an error
+// aborts the request.
+type ChatChunkFunc func(ChatChunk) error
+
 // OllamaClient represents an Ollama API client implementation
 type OllamaClient struct {
 	serverURL *url.URL
@@ -91,8 +126,13 @@ func New(cfg *config.Config) (Client, error) {
 	}, nil
 }
 
-// createClient creates a new HTTP client with the specified timeout
+// createClient creates a new API client with the specified timeout
 func (c *OllamaClient) createClient(timeout time.Duration, forPull bool) *api.Client {
+	return api.NewClient(c.serverURL, c.createHTTPClient(timeout, forPull))
+}
+
+// createHTTPClient creates a new HTTP client with the specified timeout
+func (c *OllamaClient) createHTTPClient(timeout time.Duration, forPull bool) *http.Client {
 	transport := &http.Transport{
 		DisableKeepAlives: !forPull, // Enable keep-alive for pull operations
 		// Add other necessary transport settings
@@ -114,7 +154,7 @@ func (c *OllamaClient) createClient(timeout time.Duration, forPull bool) *api.Cl
 		}
 	}
 
-	return api.NewClient(c.serverURL, httpClient)
+	return httpClient
 }
 
 // headerTransport wraps the base transport to add custom headers
@@ -148,6 +188,23 @@ func (c *OllamaClient) ListModels(ctx context.Context) (*api.ListResponse, error
 	return models, nil
 }
 
+// ListRunning lists the models currently loaded into memory on the Ollama server
+func (c *OllamaClient) ListRunning(ctx context.Context) (*api.ProcessResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	client := c.createClient(30*time.Second, false)
+	models, err := client.ListRunning(ctx)
+	if err != nil {
+		if isTimeoutError(err) {
+			return nil, fmt.Errorf("timeout while listing running models: %w", err)
+		}
+		return nil, fmt.Errorf("failed to list running models: %w", err)
+	}
+
+	return models, nil
+}
+
 // GetModelDetails gets details for a specific model
 func (c *OllamaClient) GetModelDetails(ctx context.Context, modelName string) (*api.ShowResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -189,8 +246,29 @@ func (c *OllamaClient) DeleteModel(ctx context.Context, modelName string) error
 	return nil
 }
 
-// PullModel pulls a model from the Ollama server
-func (c *OllamaClient) PullModel(ctx context.Context, modelName string) error {
+// CopyModel copies a model on the Ollama server to a new name
+func (c *OllamaClient) CopyModel(ctx context.Context, source, destination string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	client := c.createClient(30*time.Second, false)
+	req := &api.CopyRequest{
+		Source:      source,
+		Destination: destination,
+	}
+
+	if err := client.Copy(ctx, req); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while copying model: %w", err)
+		}
+		return fmt.Errorf("failed to copy model: %w", err)
+	}
+
+	return nil
+}
+
+// PullModel pulls a model from the Ollama server, calling fn (if not nil) for every progress update
+func (c *OllamaClient) PullModel(ctx context.Context, modelName string, fn ProgressFunc) error {
 	// Use a very long timeout for pull operations (4 hours)
 	ctx, cancel := context.WithTimeout(ctx, 4*time.Hour)
 	defer cancel()
@@ -200,24 +278,7 @@ func (c *OllamaClient) PullModel(ctx context.Context, modelName string) error {
 		Name: modelName,
 	}
 
-	if err := client.Pull(ctx, req, func(progress api.ProgressResponse) error {
-		if progress.Status != "" {
-			// Calculate percentage if total is available
-			var percentStr string
-			var sizeStr string
-			if progress.Total > 0 {
-				percent := float64(progress.Completed) / float64(progress.Total) * 100
-				percentStr = fmt.Sprintf("[%s] ", output.Info(fmt.Sprintf("%.1f%%", percent)))
-				sizeStr = fmt.Sprintf("[%s] ", output.Warning(fmt.Sprintf("%.1f/%.1f MB", float64(progress.Completed)/1024/1024, float64(progress.Total)/1024/1024)))
-			}
-
-			fmt.Printf("\r%s: %s%s%s", output.Highlight(modelName), percentStr, sizeStr, output.Info(progress.Status))
-			if progress.Total > 0 && progress.Completed == progress.Total {
-				fmt.Println() // Add newline when complete
-			}
-		}
-		return nil
-	}); err != nil {
+	if err := client.Pull(ctx, req, progressCallback(modelName, fn)); err != nil {
 		if isTimeoutError(err) {
 			return fmt.Errorf("timeout while pulling model (operation took longer than 4 hours): %w", err)
 		}
@@ -227,39 +288,142 @@ func (c *OllamaClient) PullModel(ctx context.Context, modelName string) error {
 	return nil
 }
 
-// ChatWithModel sends a chat request to the Ollama server
-func (c *OllamaClient) ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error) {
-	// Use a reasonable timeout for chat operations (2 minutes)
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+// PushModel pushes a model to a registry, calling fn (if not nil) for every progress update
+func (c *OllamaClient) PushModel(ctx context.Context, modelName string, insecure bool, fn ProgressFunc) error {
+	// Use a very long timeout for push operations (4 hours)
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Hour)
 	defer cancel()
 
-	client := c.createClient(30*time.Minute, false)
-	req := &api.ChatRequest{
+	client := c.createClient(4*time.Hour, true) // Enable keep-alive for push
+	req := &api.PushRequest{
 		Model:    modelName,
-		Messages: messages,
-		Stream:   &stream,
-		Options:  options,
+		Insecure: insecure,
+	}
+
+	if err := client.Push(ctx, req, progressCallback(modelName, fn)); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while pushing model (operation took longer than 4 hours): %w", err)
+		}
+		return fmt.Errorf("failed to push model: %w", err)
 	}
 
+	return nil
+}
+
+// progressCallback adapts a ProgressFunc to the progress callback of the Ollama API
+func progressCallback(modelName string, fn ProgressFunc) func(api.ProgressResponse) error {
+	return func(progress api.ProgressResponse) error {
+		if fn == nil {
+			return nil
+		}
+		return fn(ProgressEvent{
+			Model:     modelName,
+			Status:    progress.Status,
+			Digest:    progress.Digest,
+			Total:     progress.Total,
+			Completed: progress.Completed,
+		})
+	}
+}
+
+// CreateModel creates a model on the Ollama server from a create request, calling fn
+// (if not nil) for every progress update. Files referenced by the request must already
+// have been uploaded as blobs.
+func (c *OllamaClient) CreateModel(ctx context.Context, req *api.CreateRequest, fn ProgressFunc) error {
+	// Creating a model may convert or quantize large files (4 hours)
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Hour)
+	defer cancel()
+
+	client := c.createClient(4*time.Hour, true)
+	if err := client.Create(ctx, req, progressCallback(req.Model, fn)); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while creating model (operation took longer than 4 hours): %w", err)
+		}
+		return fmt.Errorf("failed to create model: %w", err)
+	}
+
+	return nil
+}
+
+// HasBlob checks whether a blob with the given digest already exists on the Ollama server
+func (c *OllamaClient) HasBlob(ctx context.Context, digest string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.serverURL.JoinPath("api", "blobs", digest).String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.createHTTPClient(10*time.Second, false).Do(req)
+	if err != nil {
+		if isTimeoutError(err) {
+			return false, fmt.Errorf("timeout while checking blob: %w", err)
+		}
+		return false, fmt.Errorf("failed to check blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check blob: unexpected status %s", resp.Status)
+	}
+}
+
+// CreateBlob uploads the content of r as a blob with the given digest
+func (c *OllamaClient) CreateBlob(ctx context.Context, digest string, r io.Reader) error {
+	// Model weights can be several gigabytes (4 hours)
+	ctx, cancel := context.WithTimeout(ctx, 4*time.Hour)
+	defer cancel()
+
+	client := c.createClient(4*time.Hour, true)
+	if err := client.CreateBlob(ctx, digest, r); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while uploading blob: %w", err)
+		}
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	return nil
+}
+
+// ChatWithModel sends a chat request to the Ollama server.
+// If fn is not nil it is called for every chunk received from the server.
+// The returned response carries the accumulated message and the final metrics.
+func (c *OllamaClient) ChatWithModel(ctx context.Context, req *api.ChatRequest, fn ChatChunkFunc) (*api.ChatResponse, error) {
+	// Use a long timeout for chat operations (30 minutes)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	client := c.createClient(30*time.Minute, false)
+
 	var finalResponse *api.ChatResponse
-	var accumulatedContent string
+	var content, thinking strings.Builder
+	var toolCalls []api.ToolCall
 
 	err := client.Chat(ctx, req, func(response api.ChatResponse) error {
-		if stream {
-			// Accumulate the content
-			accumulatedContent += response.Message.Content
-
-			// Print the response content as it comes in
-			fmt.Print(response.Message.Content)
+		// Accumulate the message across chunks
+		content.WriteString(response.Message.Content)
+		thinking.WriteString(response.Message.Thinking)
+		toolCalls = append(toolCalls, response.Message.ToolCalls...)
+
+		if fn != nil {
+			if err := fn(ChatChunk{
+				Content:   response.Message.Content,
+				Thinking:  response.Message.Thinking,
+				ToolCalls: response.Message.ToolCalls,
+				Done:      response.Done,
+			}); err != nil {
+				return err
+			}
 		}
 
 		if response.Done {
 			finalResponse = &response
-
-			// If streaming was enabled, update the final response with the accumulated content
-			if stream && finalResponse != nil {
-				finalResponse.Message.Content = accumulatedContent
-			}
 		}
 
 		return nil
@@ -272,39 +436,140 @@ func (c *OllamaClient) ChatWithModel(ctx context.Context, modelName string, mess
 		return nil, fmt.Errorf("failed to chat with model: %w", err)
 	}
 
-	if stream {
-		fmt.Println() // Add a newline at the end of streaming output
+	// If we didn't get a final response with Done=true, create one with the accumulated content
+	if finalResponse == nil {
+		finalResponse = &api.ChatResponse{
+			Model: req.Model,
+			Message: api.Message{
+				Role: "assistant",
+			},
+			Done: true,
+		}
+	}
+	finalResponse.Message.Content = content.String()
+	finalResponse.Message.Thinking = thinking.String()
+	finalResponse.Message.ToolCalls = toolCalls
+
+	return finalResponse, nil
+}
 
-		// If we didn't get a final response with Done=true, create one with the accumulated content
-		if finalResponse == nil {
-			finalResponse = &api.ChatResponse{
-				Message: api.Message{
-					Role:    "assistant",
-					Content: accumulatedContent,
-				},
-				Done: true,
-			}
+// LoadModel preloads a model into memory by sending an empty generate request.
+// The model stays loaded for keepAlive; a negative value keeps it loaded indefinitely.
+func (c *OllamaClient) LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error {
+	// Loading large models may take a while (30 minutes)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	client := c.createClient(30*time.Minute, false)
+	stream := false
+	req := &api.GenerateRequest{
+		Model:     modelName,
+		Stream:    &stream,
+		KeepAlive: &api.Duration{Duration: keepAlive},
+	}
+
+	if err := client.Generate(ctx, req, func(api.GenerateResponse) error { return nil }); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while loading model: %w", err)
 		}
+		return fmt.Errorf("failed to load model: %w", err)
 	}
 
-	// Validate the response for security issues
-	if finalResponse != nil {
-		validationResult := security.ValidateChatResponse(finalResponse)
+	return nil
+}
 
-		// Display warnings if any
-		for _, warning := range validationResult.Warnings {
-			output.Default.WarningPrintf("%s\n", warning)
+// UnloadModel evicts a model from memory by sending an empty generate request with keep_alive 0
+func (c *OllamaClient) UnloadModel(ctx context.Context, modelName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+
+	client := c.createClient(1*time.Minute, false)
+	stream := false
+	req := &api.GenerateRequest{
+		Model:     modelName,
+		Stream:    &stream,
+		KeepAlive: &api.Duration{Duration: 0},
+	}
+
+	if err := client.Generate(ctx, req, func(api.GenerateResponse) error { return nil }); err != nil {
+		if isTimeoutError(err) {
+			return fmt.Errorf("timeout while unloading model: %w", err)
+		}
+		return fmt.Errorf("failed to unload model: %w", err)
+	}
+
+	return nil
+}
+
+// Generate sends a generate (completion) request to the Ollama server.
+// If fn is not nil it is called for every chunk received from the server.
+// The returned response carries the accumulated text and the final metrics.
+func (c *OllamaClient) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error) {
+	// Use the same timeout as chat operations (30 minutes)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	client := c.createClient(30*time.Minute, false)
+
+	var finalResponse *api.GenerateResponse
+	var accumulatedResponse strings.Builder
+	var accumulatedThinking strings.Builder
+
+	err := client.Generate(ctx, req, func(response api.GenerateResponse) error {
+		accumulatedResponse.WriteString(response.Response)
+		accumulatedThinking.WriteString(response.Thinking)
+
+		if fn != nil {
+			if err := fn(response); err != nil {
+				return err
+			}
 		}
 
-		// If suspicious, display a warning
-		if validationResult.IsSuspicious {
-			output.Default.WarningPrintf("%s\n", security.GetOutputWarningMessage())
+		if response.Done {
+			finalResponse = &response
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isTimeoutError(err) {
+			return nil, fmt.Errorf("timeout while generating response: %w", err)
+		}
+		return nil, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	// If we didn't get a final response with Done=true, create one with the accumulated content
+	if finalResponse == nil {
+		finalResponse = &api.GenerateResponse{
+			Model: req.Model,
+			Done:  true,
 		}
 	}
+	finalResponse.Response = accumulatedResponse.String()
+	finalResponse.Thinking = accumulatedThinking.String()
 
 	return finalResponse, nil
 }
 
+// Embed generates embeddings for the inputs of the request
+func (c *OllamaClient) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	// Embedding large batches may take a while (10 minutes)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	client := c.createClient(10*time.Minute, false)
+	response, err := client.Embed(ctx, req)
+	if err != nil {
+		if isTimeoutError(err) {
+			return nil, fmt.Errorf("timeout while generating embeddings: %w", err)
+		}
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	return response, nil
+}
+
 // isTimeoutError checks if the error is a timeout error
 func isTimeoutError(err error) bool {
 	if err == nil {
@@ -336,10 +601,50 @@ func (c *errorClient) DeleteModel(ctx context.Context, modelName string) error {
 	return c.err
 }
 
-func (c *errorClient) PullModel(ctx context.Context, modelName string) error {
+func (c *errorClient) CopyModel(ctx context.Context, source, destination string) error {
+	return c.err
+}
+
+func (c *errorClient) CreateModel(ctx context.Context, req *api.CreateRequest, fn ProgressFunc) error {
+	return c.err
+}
+
+func (c *errorClient) HasBlob(ctx context.Context, digest string) (bool, error) {
+	return false, c.err
+}
+
+func (c *errorClient) CreateBlob(ctx context.Context, digest string, r io.Reader) error {
+	return c.err
+}
+
+func (c *errorClient) PullModel(ctx context.Context, modelName string, fn ProgressFunc) error {
 	return c.err
 }
 
-func (c *errorClient) ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error) {
+func (c *errorClient) PushModel(ctx context.Context, modelName string, insecure bool, fn ProgressFunc) error {
+	return c.err
+}
+
+func (c *errorClient) ChatWithModel(ctx context.Context, req *api.ChatRequest, fn ChatChunkFunc) (*api.ChatResponse, error) {
+	return nil, c.err
+}
+
+func (c *errorClient) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error) {
+	return nil, c.err
+}
+
+func (c *errorClient) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	return nil, c.err
+}
+
+func (c *errorClient) ListRunning(ctx context.Context) (*api.ProcessResponse, error) {
 	return nil, c.err
 }
+
+func (c *errorClient) LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error {
+	return c.err
+}
+
+func (c *errorClient) UnloadModel(ctx context.Context, modelName string) error {
+	return c.err
+}
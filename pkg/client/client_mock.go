@@ -2,6 +2,8 @@ package client
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/ollama/ollama/api"
 	"github.com/stretchr/testify/mock"
@@ -36,21 +38,90 @@ func (m *MockClientTestify) DeleteModel(ctx context.Context, modelName string) e
 	return args.Error(0)
 }
 
+// CopyModel implements the Client interface
+func (m *MockClientTestify) CopyModel(ctx context.Context, source, destination string) error {
+	args := m.Called(ctx, source, destination)
+	return args.Error(0)
+}
+
+// CreateModel implements the Client interface
+func (m *MockClientTestify) CreateModel(ctx context.Context, req *api.CreateRequest, fn ProgressFunc) error {
+	args := m.Called(ctx, req, fn)
+	return args.Error(0)
+}
+
+// HasBlob implements the Client interface
+func (m *MockClientTestify) HasBlob(ctx context.Context, digest string) (bool, error) {
+	args := m.Called(ctx, digest)
+	return args.Bool(0), args.Error(1)
+}
+
+// CreateBlob implements the Client interface
+func (m *MockClientTestify) CreateBlob(ctx context.Context, digest string, r io.Reader) error {
+	args := m.Called(ctx, digest, r)
+	return args.Error(0)
+}
+
 // PullModel implements the Client interface
-func (m *MockClientTestify) PullModel(ctx context.Context, modelName string) error {
-	args := m.Called(ctx, modelName)
+func (m *MockClientTestify) PullModel(ctx context.Context, modelName string, fn ProgressFunc) error {
+	args := m.Called(ctx, modelName, fn)
+	return args.Error(0)
+}
+
+// PushModel implements the Client interface
+func (m *MockClientTestify) PushModel(ctx context.Context, modelName string, insecure bool, fn ProgressFunc) error {
+	args := m.Called(ctx, modelName, insecure, fn)
 	return args.Error(0)
 }
 
 // ChatWithModel implements the Client interface
-func (m *MockClientTestify) ChatWithModel(ctx context.Context, modelName string, messages []api.Message, stream bool, options map[string]interface{}) (*api.ChatResponse, error) {
-	args := m.Called(ctx, modelName, messages, stream, options)
+func (m *MockClientTestify) ChatWithModel(ctx context.Context, req *api.ChatRequest, fn ChatChunkFunc) (*api.ChatResponse, error) {
+	args := m.Called(ctx, req, fn)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*api.ChatResponse), args.Error(1)
 }
 
+// Generate implements the Client interface
+func (m *MockClientTestify) Generate(ctx context.Context, req *api.GenerateRequest, fn api.GenerateResponseFunc) (*api.GenerateResponse, error) {
+	args := m.Called(ctx, req, fn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.GenerateResponse), args.Error(1)
+}
+
+// Embed implements the Client interface
+func (m *MockClientTestify) Embed(ctx context.Context, req *api.EmbedRequest) (*api.EmbedResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.EmbedResponse), args.Error(1)
+}
+
+// ListRunning implements the Client interface
+func (m *MockClientTestify) ListRunning(ctx context.Context) (*api.ProcessResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.ProcessResponse), args.Error(1)
+}
+
+// LoadModel implements the Client interface
+func (m *MockClientTestify) LoadModel(ctx context.Context, modelName string, keepAlive time.Duration) error {
+	args := m.Called(ctx, modelName, keepAlive)
+	return args.Error(0)
+}
+
+// UnloadModel implements the Client interface
+func (m *MockClientTestify) UnloadModel(ctx context.Context, modelName string) error {
+	args := m.Called(ctx, modelName)
+	return args.Error(0)
+}
+
 // NewMockClient creates a new testify mock client
 func NewMockClient() *MockClientTestify {
 	return &MockClientTestify{}
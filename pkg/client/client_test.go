@@ -2,12 +2,15 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/ollama/ollama/api"
 )
 
 func TestCustomHeaders(t *testing.T) {
@@ -104,3 +107,305 @@ func TestNoCustomHeaders(t *testing.T) {
 		t.Errorf("Expected no Authorization header, got '%s'", auth)
 	}
 }
+
+// newTestClient creates an OllamaClient pointing at the given test server
+func newTestClient(t *testing.T, serverURL string) *OllamaClient {
+	t.Helper()
+
+	client, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ollamaClient := client.(*OllamaClient)
+	ollamaClient.serverURL, _ = url.Parse(serverURL)
+	return ollamaClient
+}
+
+func TestGenerate(t *testing.T) {
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("Expected path /api/generate, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&capturedBody)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"response":"Hello"}` + "\n"))
+		w.Write([]byte(`{"response":" world"}` + "\n"))
+		w.Write([]byte(`{"response":"","done":true,"context":[1,2],"eval_count":2}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	var chunks []string
+	stream := true
+	response, err := client.Generate(context.Background(), &api.GenerateRequest{
+		Model:  "test-model",
+		Prompt: "Hi",
+		Suffix: "!",
+		Raw:    true,
+		Stream: &stream,
+	}, func(r api.GenerateResponse) error {
+		chunks = append(chunks, r.Response)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if response.Response != "Hello world" {
+		t.Errorf("Expected accumulated response 'Hello world', got '%s'", response.Response)
+	}
+	if len(chunks) != 3 {
+		t.Errorf("Expected 3 chunks, got %d", len(chunks))
+	}
+	if len(response.Context) != 2 || response.EvalCount != 2 {
+		t.Errorf("Expected final context and metrics to be preserved, got %+v", response)
+	}
+	if capturedBody["suffix"] != "!" || capturedBody["raw"] != true {
+		t.Errorf("Expected suffix and raw to be sent, got %v", capturedBody)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	var capturedBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("Expected path /api/embed, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&capturedBody)
+		w.Write([]byte(`{"model":"embed-model","embeddings":[[0.1,0.2],[0.3,0.4]]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	response, err := client.Embed(context.Background(), &api.EmbedRequest{
+		Model:      "embed-model",
+		Input:      []string{"a", "b"},
+		Dimensions: 2,
+	})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	if len(response.Embeddings) != 2 || response.Embeddings[1][1] != 0.4 {
+		t.Errorf("Unexpected embeddings: %v", response.Embeddings)
+	}
+	if inputs, ok := capturedBody["input"].([]interface{}); !ok || len(inputs) != 2 {
+		t.Errorf("Expected 2 inputs to be sent, got %v", capturedBody["input"])
+	}
+	if capturedBody["dimensions"] != float64(2) {
+		t.Errorf("Expected dimensions to be sent, got %v", capturedBody["dimensions"])
+	}
+}
+
+func TestListRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ps" {
+			t.Errorf("Expected path /api/ps, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest","size":100,"size_vram":50,"context_length":4096}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	response, err := client.ListRunning(context.Background())
+	if err != nil {
+		t.Fatalf("ListRunning failed: %v", err)
+	}
+
+	if len(response.Models) != 1 || response.Models[0].SizeVRAM != 50 || response.Models[0].ContextLength != 4096 {
+		t.Errorf("Unexpected running models: %+v", response.Models)
+	}
+}
+
+func TestLoadAndUnloadModel(t *testing.T) {
+	var keepAlives []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		keepAlives = append(keepAlives, body["keep_alive"])
+		w.Write([]byte(`{"model":"test-model","response":"","done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	if err := client.LoadModel(context.Background(), "test-model", 30*time.Minute); err != nil {
+		t.Fatalf("LoadModel failed: %v", err)
+	}
+	if err := client.LoadModel(context.Background(), "test-model", -1); err != nil {
+		t.Fatalf("LoadModel failed: %v", err)
+	}
+	if err := client.UnloadModel(context.Background(), "test-model"); err != nil {
+		t.Fatalf("UnloadModel failed: %v", err)
+	}
+
+	expected := []interface{}{"30m0s", float64(-1), "0s"}
+	for i, want := range expected {
+		if keepAlives[i] != want {
+			t.Errorf("Request %d: expected keep_alive %v, got %v", i, want, keepAlives[i])
+		}
+	}
+}
+
+func TestCopyModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/copy" {
+			t.Errorf("Expected path /api/copy, got %s", r.URL.Path)
+		}
+		var req api.CopyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Source != "team/assistant:rc1" || req.Destination != "team/assistant:stable" {
+			t.Errorf("Unexpected copy request: %+v", req)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	if err := client.CopyModel(context.Background(), "team/assistant:rc1", "team/assistant:stable"); err != nil {
+		t.Fatalf("CopyModel failed: %v", err)
+	}
+}
+
+func TestHasBlob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD request, got %s", r.Method)
+		}
+		if r.URL.Path == "/api/blobs/sha256:abc" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	exists, err := client.HasBlob(context.Background(), "sha256:abc")
+	if err != nil || !exists {
+		t.Errorf("Expected existing blob, got exists=%v err=%v", exists, err)
+	}
+
+	exists, err = client.HasBlob(context.Background(), "sha256:def")
+	if err != nil || exists {
+		t.Errorf("Expected missing blob, got exists=%v err=%v", exists, err)
+	}
+}
+
+func TestPushModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/push" {
+			t.Errorf("Expected path /api/push, got %s", r.URL.Path)
+		}
+		var req api.PushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "team/assistant" || !req.Insecure {
+			t.Errorf("Unexpected push request: %+v", req)
+		}
+		w.Write([]byte(`{"status":"pushing manifest"}` + "\n" + `{"status":"success"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	var statuses []string
+	err := client.PushModel(context.Background(), "team/assistant", true, func(event ProgressEvent) error {
+		if event.Model != "team/assistant" {
+			t.Errorf("Expected model team/assistant, got %s", event.Model)
+		}
+		statuses = append(statuses, event.Status)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PushModel failed: %v", err)
+	}
+
+	if len(statuses) != 2 || statuses[1] != "success" {
+		t.Errorf("Unexpected progress updates: %v", statuses)
+	}
+}
+
+func TestPullModelProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"pulling ad361f123f77","digest":"sha256:ad361f123f77","total":100,"completed":100}` + "\n" + `{"status":"success"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	var events []ProgressEvent
+	err := client.PullModel(context.Background(), "llama3.2", func(event ProgressEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PullModel failed: %v", err)
+	}
+
+	expected := ProgressEvent{Model: "llama3.2", Status: "pulling ad361f123f77", Digest: "sha256:ad361f123f77", Total: 100, Completed: 100}
+	if len(events) != 2 || events[0] != expected {
+		t.Errorf("Unexpected progress events: %+v", events)
+	}
+
+	// A nil progress function is allowed
+	if err := client.PullModel(context.Background(), "llama3.2", nil); err != nil {
+		t.Fatalf("PullModel without progress function failed: %v", err)
+	}
+}
+
+func TestChatWithModelStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected path /api/chat, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","thinking":"Let me check."},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_weather","arguments":{"city":"Paris"}}}]},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"It is "},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"sunny."},"done":true,"eval_count":5}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+
+	stream := true
+	req := &api.ChatRequest{
+		Model:    "test-model",
+		Messages: []api.Message{{Role: "user", Content: "Weather in Paris?"}},
+		Stream:   &stream,
+	}
+
+	var chunks []ChatChunk
+	response, err := client.ChatWithModel(context.Background(), req, func(chunk ChatChunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatWithModel failed: %v", err)
+	}
+
+	if len(chunks) != 4 || chunks[0].Thinking != "Let me check." || len(chunks[1].ToolCalls) != 1 || !chunks[3].Done {
+		t.Errorf("Unexpected chunks: %+v", chunks)
+	}
+	if response.Message.Content != "It is sunny." {
+		t.Errorf("Expected accumulated content 'It is sunny.', got %q", response.Message.Content)
+	}
+	if response.Message.Thinking != "Let me check." {
+		t.Errorf("Expected accumulated thinking, got %q", response.Message.Thinking)
+	}
+	if len(response.Message.ToolCalls) != 1 || response.Message.ToolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("Unexpected tool calls: %+v", response.Message.ToolCalls)
+	}
+	if response.EvalCount != 5 {
+		t.Errorf("Expected eval count 5, got %d", response.EvalCount)
+	}
+}
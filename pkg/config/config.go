@@ -28,6 +28,10 @@ type Config struct {
 	ChatEnabled  bool              `mapstructure:"chat_enabled"`
 	CheckUpdates bool              `mapstructure:"check_updates"`
 	Headers      map[string]string `mapstructure:"headers"`
+	// StdinTemplate attaches piped input to a chat prompt, empty for the default
+	StdinTemplate string `mapstructure:"stdin_template"`
+	// ContextStrategy keeps long chats within the context window, empty for the default
+	ContextStrategy string `mapstructure:"context_strategy"`
 }
 
 // DefaultConfig returns the default configuration
@@ -91,6 +95,8 @@ func LoadConfig(configName ...string) (*Config, error) {
 		viper.Set("chat_enabled", defaultConfig.ChatEnabled)
 		viper.Set("check_updates", defaultConfig.CheckUpdates)
 		viper.Set("headers", defaultConfig.Headers)
+		viper.Set("stdin_template", defaultConfig.StdinTemplate)
+		viper.Set("context_strategy", defaultConfig.ContextStrategy)
 		if err := viper.WriteConfig(); err != nil {
 			return nil, fmt.Errorf("failed to write default config: %w", err)
 		}
@@ -133,6 +139,8 @@ func SaveConfig(config *Config, configName ...string) error {
 	viper.Set("chat_enabled", config.ChatEnabled)
 	viper.Set("check_updates", config.CheckUpdates)
 	viper.Set("headers", config.Headers)
+	viper.Set("stdin_template", config.StdinTemplate)
+	viper.Set("context_strategy", config.ContextStrategy)
 
 	return viper.WriteConfig()
 }
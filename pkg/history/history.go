@@ -0,0 +1,295 @@
+// Package history keeps the messages of a chat as a tree, so that a message can be
+// edited and answered again without losing the original conversation. Each branch
+// is a named path from the first message of the chat to one of its messages.
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/ollama/ollama/api"
+)
+
+// DefaultBranch is the branch of a new history
+const DefaultBranch = "main"
+
+// transcriptVersion is the version of the transcript format
+const transcriptVersion = 1
+
+// validName matches the names allowed for branches. Names start with a letter, so
+// they are not mistaken for branch numbers.
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
+// node is a message of the tree
+type node struct {
+	// parent is the index of the previous message plus one, zero for a first message
+	parent  int
+	message api.Message
+}
+
+// branch is a named path of the tree
+type branch struct {
+	name string
+	// head is the index of the last message of the branch plus one, zero when the
+	// branch is empty
+	head int
+}
+
+// Branch is a branch of the history with its messages
+type Branch struct {
+	Name string
+	// Messages are the messages of the branch, from the first one
+	Messages []api.Message
+}
+
+// Tree is the history of a chat. Messages are added to the current branch, and
+// messages removed from a branch are kept as long as another branch includes them.
+type Tree struct {
+	nodes    []node
+	branches []branch
+	current  int
+}
+
+// New creates an empty history with the default branch
+func New() *Tree {
+	return &Tree{branches: []branch{{name: DefaultBranch}}}
+}
+
+// FromMessages creates a history with a single branch holding messages
+func FromMessages(messages []api.Message) *Tree {
+	t := New()
+	t.Append(messages...)
+	return t
+}
+
+// Current returns the name of the current branch
+func (t *Tree) Current() string {
+	return t.branches[t.current].name
+}
+
+// Branched reports whether the history has several branches
+func (t *Tree) Branched() bool {
+	return len(t.branches) > 1
+}
+
+// Len returns the number of messages of the current branch
+func (t *Tree) Len() int {
+	return len(t.path(t.branches[t.current].head))
+}
+
+// Messages returns the messages of the current branch, from the first one
+func (t *Tree) Messages() []api.Message {
+	return t.messages(t.branches[t.current].head)
+}
+
+// Branches returns the branches of the history, in the order they were created
+func (t *Tree) Branches() []Branch {
+	branches := make([]Branch, len(t.branches))
+	for i, b := range t.branches {
+		branches[i] = Branch{Name: b.name, Messages: t.messages(b.head)}
+	}
+	return branches
+}
+
+// Append adds messages to the end of the current branch
+func (t *Tree) Append(messages ...api.Message) {
+	b := &t.branches[t.current]
+	for _, msg := range messages {
+		t.nodes = append(t.nodes, node{parent: b.head, message: msg})
+		b.head = len(t.nodes)
+	}
+}
+
+// Truncate keeps the first n messages of the current branch
+func (t *Tree) Truncate(n int) {
+	b := &t.branches[t.current]
+	b.head = t.ancestor(b.head, max(0, n))
+}
+
+// Fork creates a branch with the first n messages of the current branch, and makes
+// it the current branch
+func (t *Tree) Fork(name string, n int) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid branch name %q, use letters, digits, '.', '_' and '-', starting with a letter", name)
+	}
+	if t.find(name) >= 0 {
+		return fmt.Errorf("branch '%s' already exists", name)
+	}
+	head := t.branches[t.current].head
+	if length := len(t.path(head)); n < 0 || n > length {
+		return fmt.Errorf("no message %d on branch '%s', which has %d messages", n, t.Current(), length)
+	}
+
+	t.branches = append(t.branches, branch{name: name, head: t.ancestor(head, n)})
+	t.current = len(t.branches) - 1
+	return nil
+}
+
+// Checkout makes a branch the current branch
+func (t *Tree) Checkout(name string) error {
+	i := t.find(name)
+	if i < 0 {
+		return fmt.Errorf("unknown branch '%s'", name)
+	}
+	t.current = i
+	return nil
+}
+
+// NewBranchName returns a name for a new branch, such as branch-2
+func (t *Tree) NewBranchName() string {
+	for n := len(t.branches) + 1; ; n++ {
+		name := fmt.Sprintf("branch-%d", n)
+		if t.find(name) < 0 {
+			return name
+		}
+	}
+}
+
+// find returns the index of a branch, or -1 if there is none
+func (t *Tree) find(name string) int {
+	for i, b := range t.branches {
+		if b.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// path returns the nodes from the first message to head, as indexes plus one
+func (t *Tree) path(head int) []int {
+	var ids []int
+	for id := head; id != 0; id = t.nodes[id-1].parent {
+		ids = append(ids, id)
+	}
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids
+}
+
+// messages returns the messages from the first message to head
+func (t *Tree) messages(head int) []api.Message {
+	ids := t.path(head)
+	messages := make([]api.Message, len(ids))
+	for i, id := range ids {
+		messages[i] = t.nodes[id-1].message
+	}
+	return messages
+}
+
+// ancestor returns the n-th message of the path to head, zero for none
+func (t *Tree) ancestor(head, n int) int {
+	ids := t.path(head)
+	if n >= len(ids) {
+		return head
+	}
+	if n == 0 {
+		return 0
+	}
+	return ids[n-1]
+}
+
+// IsTranscript reports whether JSON data is a transcript of a tree, rather than an
+// array of messages
+func IsTranscript(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// transcript is the JSON form of a tree
+type transcript struct {
+	Version  int                `json:"version"`
+	Current  string             `json:"current"`
+	Branches []transcriptBranch `json:"branches"`
+	Nodes    []transcriptNode   `json:"nodes"`
+}
+
+// transcriptBranch is a branch in a transcript, head is the ID of its last message
+type transcriptBranch struct {
+	Name string `json:"name"`
+	Head int    `json:"head,omitempty"`
+}
+
+// transcriptNode is a message in a transcript, parent is the ID of the previous
+// message
+type transcriptNode struct {
+	ID      int         `json:"id"`
+	Parent  int         `json:"parent,omitempty"`
+	Message api.Message `json:"message"`
+}
+
+// MarshalJSON writes the tree as a transcript. Messages no branch includes are left
+// out, and the messages are numbered from 1.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	reachable := make([]bool, len(t.nodes)+1)
+	for _, b := range t.branches {
+		for id := b.head; id != 0 && !reachable[id]; id = t.nodes[id-1].parent {
+			reachable[id] = true
+		}
+	}
+
+	// Parents come before their children, so they are numbered first
+	ids := make([]int, len(t.nodes)+1)
+	out := transcript{Version: transcriptVersion, Current: t.Current(), Nodes: []transcriptNode{}}
+	for i, n := range t.nodes {
+		if !reachable[i+1] {
+			continue
+		}
+		ids[i+1] = len(out.Nodes) + 1
+		out.Nodes = append(out.Nodes, transcriptNode{ID: ids[i+1], Parent: ids[n.parent], Message: n.message})
+	}
+	for _, b := range t.branches {
+		out.Branches = append(out.Branches, transcriptBranch{Name: b.name, Head: ids[b.head]})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a transcript written by MarshalJSON, checking that it forms
+// a tree
+func (t *Tree) UnmarshalJSON(data []byte) error {
+	var in transcript
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Version != transcriptVersion {
+		return fmt.Errorf("unsupported transcript version %d", in.Version)
+	}
+
+	// Messages refer to previous messages, so the transcript cannot have cycles
+	tree := Tree{}
+	index := make(map[int]int, len(in.Nodes))
+	for _, n := range in.Nodes {
+		if _, ok := index[n.ID]; ok || n.ID <= 0 {
+			return fmt.Errorf("invalid transcript: duplicate or invalid message ID %d", n.ID)
+		}
+		parent, ok := index[n.Parent]
+		if !ok && n.Parent != 0 {
+			return fmt.Errorf("invalid transcript: message %d follows unknown message %d", n.ID, n.Parent)
+		}
+		tree.nodes = append(tree.nodes, node{parent: parent, message: n.Message})
+		index[n.ID] = len(tree.nodes)
+	}
+
+	if len(in.Branches) == 0 {
+		return fmt.Errorf("invalid transcript: no branches")
+	}
+	for _, b := range in.Branches {
+		if !validName.MatchString(b.Name) || tree.find(b.Name) >= 0 {
+			return fmt.Errorf("invalid transcript: duplicate or invalid branch name %q", b.Name)
+		}
+		head, ok := index[b.Head]
+		if !ok && b.Head != 0 {
+			return fmt.Errorf("invalid transcript: branch '%s' ends with unknown message %d", b.Name, b.Head)
+		}
+		tree.branches = append(tree.branches, branch{name: b.Name, head: head})
+	}
+	if in.Current != "" {
+		if tree.current = tree.find(in.Current); tree.current < 0 {
+			return fmt.Errorf("invalid transcript: unknown current branch '%s'", in.Current)
+		}
+	}
+
+	*t = tree
+	return nil
+}
@@ -0,0 +1,151 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contents returns the contents of messages
+func contents(messages []api.Message) []string {
+	var texts []string
+	for _, msg := range messages {
+		texts = append(texts, msg.Content)
+	}
+	return texts
+}
+
+// testTree returns a history with a branch editing the second question of main
+func testTree(t *testing.T) *Tree {
+	t.Helper()
+	tree := FromMessages([]api.Message{
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello"},
+		{Role: "user", Content: "Capital of France?"},
+		{Role: "assistant", Content: "Lyon"},
+	})
+	require.NoError(t, tree.Fork(tree.NewBranchName(), 2))
+	tree.Append(api.Message{Role: "user", Content: "What is the capital of France?"}, api.Message{Role: "assistant", Content: "Paris"})
+	return tree
+}
+
+func TestTree(t *testing.T) {
+	tree := New()
+	assert.Equal(t, DefaultBranch, tree.Current())
+	assert.Empty(t, tree.Messages())
+	assert.False(t, tree.Branched())
+
+	tree = testTree(t)
+	assert.Equal(t, "branch-2", tree.Current())
+	assert.True(t, tree.Branched())
+	assert.Equal(t, []string{"Hi", "Hello", "What is the capital of France?", "Paris"}, contents(tree.Messages()))
+
+	// The original messages stay on their branch
+	require.NoError(t, tree.Checkout(DefaultBranch))
+	assert.Equal(t, []string{"Hi", "Hello", "Capital of France?", "Lyon"}, contents(tree.Messages()))
+	assert.EqualError(t, tree.Checkout("missing"), "unknown branch 'missing'")
+
+	// Truncating a branch leaves the others as they are
+	tree.Truncate(2)
+	assert.Equal(t, 2, tree.Len())
+	tree.Append(api.Message{Role: "user", Content: "Bye"})
+	branches := tree.Branches()
+	require.Len(t, branches, 2)
+	assert.Equal(t, []string{"Hi", "Hello", "Bye"}, contents(branches[0].Messages))
+	assert.Equal(t, "branch-2", branches[1].Name)
+	assert.Len(t, branches[1].Messages, 4)
+
+	assert.EqualError(t, tree.Fork("branch-2", 1), "branch 'branch-2' already exists")
+	assert.EqualError(t, tree.Fork("2nd", 1), `invalid branch name "2nd", use letters, digits, '.', '_' and '-', starting with a letter`)
+	assert.EqualError(t, tree.Fork("later", 4), "no message 4 on branch 'main', which has 3 messages")
+	require.NoError(t, tree.Fork("empty", 0))
+	assert.Empty(t, tree.Messages())
+	assert.Equal(t, "branch-4", tree.NewBranchName())
+}
+
+func TestTreeJSON(t *testing.T) {
+	tree := testTree(t)
+	// The answer removed from main is only kept by branch-2
+	require.NoError(t, tree.Checkout(DefaultBranch))
+	tree.Truncate(3)
+	require.NoError(t, tree.Checkout("branch-2"))
+	tree.Truncate(3)
+
+	data, err := json.Marshal(tree)
+	require.NoError(t, err)
+	assert.True(t, IsTranscript(data))
+	assert.JSONEq(t, `{
+		"version": 1,
+		"current": "branch-2",
+		"branches": [{"name": "main", "head": 3}, {"name": "branch-2", "head": 4}],
+		"nodes": [
+			{"id": 1, "message": {"role": "user", "content": "Hi"}},
+			{"id": 2, "parent": 1, "message": {"role": "assistant", "content": "Hello"}},
+			{"id": 3, "parent": 2, "message": {"role": "user", "content": "Capital of France?"}},
+			{"id": 4, "parent": 2, "message": {"role": "user", "content": "What is the capital of France?"}}
+		]
+	}`, string(data))
+
+	var loaded Tree
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, "branch-2", loaded.Current())
+	assert.Equal(t, []string{"Hi", "Hello", "What is the capital of France?"}, contents(loaded.Messages()))
+	require.NoError(t, loaded.Checkout(DefaultBranch))
+	assert.Equal(t, []string{"Hi", "Hello", "Capital of France?"}, contents(loaded.Messages()))
+
+	assert.False(t, IsTranscript([]byte(` [{"role": "user", "content": "Hi"}]`)))
+}
+
+func TestTreeUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "Unknown version",
+			data:    `{"version": 2, "branches": [{"name": "main"}]}`,
+			wantErr: "unsupported transcript version 2",
+		},
+		{
+			name:    "No branches",
+			data:    `{"version": 1}`,
+			wantErr: "invalid transcript: no branches",
+		},
+		{
+			name:    "Duplicate message",
+			data:    `{"version": 1, "branches": [{"name": "main"}], "nodes": [{"id": 1}, {"id": 1}]}`,
+			wantErr: "invalid transcript: duplicate or invalid message ID 1",
+		},
+		{
+			name:    "Later parent",
+			data:    `{"version": 1, "branches": [{"name": "main"}], "nodes": [{"id": 1, "parent": 2}, {"id": 2}]}`,
+			wantErr: "invalid transcript: message 1 follows unknown message 2",
+		},
+		{
+			name:    "Unknown head",
+			data:    `{"version": 1, "branches": [{"name": "main", "head": 3}], "nodes": [{"id": 1}]}`,
+			wantErr: "invalid transcript: branch 'main' ends with unknown message 3",
+		},
+		{
+			name:    "Duplicate branch",
+			data:    `{"version": 1, "branches": [{"name": "main"}, {"name": "main"}]}`,
+			wantErr: `invalid transcript: duplicate or invalid branch name "main"`,
+		},
+		{
+			name:    "Unknown current branch",
+			data:    `{"version": 1, "current": "other", "branches": [{"name": "main"}]}`,
+			wantErr: "invalid transcript: unknown current branch 'other'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tree Tree
+			assert.EqualError(t, json.Unmarshal([]byte(tt.data), &tree), tt.wantErr)
+		})
+	}
+}
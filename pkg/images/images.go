@@ -0,0 +1,262 @@
+// Package images loads the images sent to multimodal models from files, URLs and
+// data URIs. The format of an image is checked from its content, and images larger
+// than a maximum size can be downscaled before they are sent.
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported image formats
+const (
+	PNG  = "image/png"
+	JPEG = "image/jpeg"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+)
+
+// defaultMaxBytes limits the size of images fetched from URLs
+const defaultMaxBytes = 20 << 20
+
+// defaultTimeout limits how long fetching an image from a URL may take
+const defaultTimeout = 30 * time.Second
+
+// jpegQuality is the quality of downscaled JPEG images
+const jpegQuality = 90
+
+// Image is an image to send to a model
+type Image struct {
+	// Source is the path, URL or data URI the image was loaded from
+	Source string
+	// MIME is the format of the image, detected from its content
+	MIME string
+	Data []byte
+	// Width and Height are the size of the image in pixels, zero when the format
+	// cannot be decoded
+	Width  int
+	Height int
+	// Resized is set when the image was downscaled
+	Resized bool
+}
+
+// Loader loads images and checks their format
+type Loader struct {
+	// Client fetches images from URLs, a client with a 30 second timeout if nil
+	Client *http.Client
+	// MaxSize is the maximum width and height of images in pixels, larger images are
+	// downscaled. Zero sends images as they are.
+	MaxSize int
+	// MaxBytes limits the size of images fetched from URLs, 20 MB if zero
+	MaxBytes int64
+}
+
+// Load loads an image from a file, an http or https URL, or a data URI
+func (l *Loader) Load(ctx context.Context, source string) (*Image, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(source, "data:"):
+		data, err = decodeDataURI(source)
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		data, err = l.fetch(ctx, source)
+	default:
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %s: %w", displaySource(source), err)
+	}
+	return l.Decode(source, data)
+}
+
+// Decode checks the format of image data read from source, and downscales the image
+// if it is larger than the maximum size
+func (l *Loader) Decode(source string, data []byte) (*Image, error) {
+	img := &Image{Source: source, MIME: DetectMIME(data), Data: data}
+	if img.MIME == "" {
+		return nil, fmt.Errorf("%s is not a supported image, expected PNG, JPEG, GIF or WebP", displaySource(source))
+	}
+
+	// WebP cannot be decoded by the standard library, it is sent as it is
+	if img.MIME == WebP {
+		return img, nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image %s: %w", displaySource(source), err)
+	}
+	img.Width, img.Height = cfg.Width, cfg.Height
+
+	if l.MaxSize > 0 && (img.Width > l.MaxSize || img.Height > l.MaxSize) {
+		if err := img.downscale(l.MaxSize); err != nil {
+			return nil, fmt.Errorf("failed to downscale image %s: %w", displaySource(source), err)
+		}
+	}
+	return img, nil
+}
+
+// DetectMIME returns the format of image data from its magic bytes, or an empty
+// string if it is not a supported image
+func DetectMIME(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return JPEG
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return WebP
+	}
+	return ""
+}
+
+// fetch downloads an image from a URL
+func (l *Loader) fetch(ctx context.Context, target string) ([]byte, error) {
+	httpClient := l.Client
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	maxBytes := l.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultMaxBytes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxBytes)
+	}
+	return data, nil
+}
+
+// decodeDataURI returns the data of a data URI, such as data:image/png;base64,...
+func decodeDataURI(uri string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URI")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		// Data URIs are often wrapped over several lines
+		payload = strings.Join(strings.Fields(payload), "")
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(payload)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URI: %w", err)
+		}
+		return data, nil
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URI: %w", err)
+	}
+	return []byte(data), nil
+}
+
+// displaySource shortens data URIs in messages
+func displaySource(source string) string {
+	if strings.HasPrefix(source, "data:") {
+		if header, _, ok := strings.Cut(source, ","); ok {
+			return header + ",..."
+		}
+	}
+	return source
+}
+
+// downscale resizes the image so that its width and height fit in maxSize, keeping
+// its aspect ratio. JPEG images stay JPEG, other formats are encoded as PNG.
+func (img *Image) downscale(maxSize int) error {
+	src, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return err
+	}
+
+	width, height := img.Width, img.Height
+	if width >= height {
+		width, height = maxSize, max(1, height*maxSize/width)
+	} else {
+		width, height = max(1, width*maxSize/height), maxSize
+	}
+	dst := resize(src, width, height)
+
+	var buf bytes.Buffer
+	if img.MIME == JPEG {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, dst)
+		img.MIME = PNG
+	}
+	if err != nil {
+		return err
+	}
+
+	img.Data = buf.Bytes()
+	img.Width, img.Height = width, height
+	img.Resized = true
+	return nil
+}
+
+// resize scales an image down by averaging the source pixels covered by each pixel
+// of the result
+func resize(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
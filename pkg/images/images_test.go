@@ -0,0 +1,154 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodePNG returns a PNG image of the given size in a single color
+func encodePNG(t *testing.T, width, height int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDetectMIME(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"\x89PNG\r\n\x1a\n\x00\x00", PNG},
+		{"\xff\xd8\xff\xe0", JPEG},
+		{"GIF89a", GIF},
+		{"GIF87a", GIF},
+		{"RIFF\x24\x00\x00\x00WEBPVP8 ", WebP},
+		{"RIFF\x24\x00\x00\x00WAVEfmt ", ""},
+		{"BM\x36\x00", ""},
+		{"\x89PNG", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectMIME([]byte(tt.data)), "%q", tt.data)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	pngData := encodePNG(t, 4, 2, color.White)
+
+	dir := t.TempDir()
+	pngFile := filepath.Join(dir, "white.png")
+	require.NoError(t, os.WriteFile(pngFile, pngData, 0644))
+	textFile := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(textFile, []byte("not an image"), 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/white.png":
+			w.Write(pngData)
+		case "/large.png":
+			w.Write(make([]byte, 64))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{name: "File", source: pngFile},
+		{name: "URL", source: server.URL + "/white.png"},
+		{name: "Base64 data URI", source: "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)},
+		{name: "Not an image", source: textFile, wantErr: "is not a supported image"},
+		{name: "Missing file", source: filepath.Join(dir, "missing.png"), wantErr: "failed to read image"},
+		{name: "URL not found", source: server.URL + "/missing.png", wantErr: "unexpected status: 404"},
+		{name: "URL too large", source: server.URL + "/large.png", wantErr: "image is larger than 32 bytes"},
+		{name: "Invalid data URI", source: "data:image/png;base64,***", wantErr: "invalid data URI"},
+		{name: "Data URI that is not an image", source: "data:text/plain,hello", wantErr: "data:text/plain,... is not a supported image"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := &Loader{Client: server.Client()}
+			if tt.name == "URL too large" {
+				loader.MaxBytes = 32
+			}
+			img, err := loader.Load(context.Background(), tt.source)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, PNG, img.MIME)
+			assert.Equal(t, pngData, img.Data)
+			assert.Equal(t, 4, img.Width)
+			assert.Equal(t, 2, img.Height)
+			assert.False(t, img.Resized)
+		})
+	}
+}
+
+func TestDecodeDownscale(t *testing.T) {
+	loader := &Loader{MaxSize: 100}
+
+	// Images that fit are sent as they are
+	small := encodePNG(t, 100, 50, color.White)
+	img, err := loader.Decode("small.png", small)
+	require.NoError(t, err)
+	assert.False(t, img.Resized)
+	assert.Equal(t, small, img.Data)
+
+	// Larger images keep their aspect ratio and format
+	img, err = loader.Decode("tall.png", encodePNG(t, 150, 300, color.RGBA{R: 255, A: 255}))
+	require.NoError(t, err)
+	assert.True(t, img.Resized)
+	assert.Equal(t, PNG, img.MIME)
+	decoded, err := png.Decode(bytes.NewReader(img.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 50, 100), decoded.Bounds())
+	r, g, b, a := decoded.At(25, 50).RGBA()
+	assert.Equal(t, [4]uint32{0xffff, 0, 0, 0xffff}, [4]uint32{r, g, b, a})
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 400, 200)), nil))
+	img, err = loader.Decode("wide.jpg", buf.Bytes())
+	require.NoError(t, err)
+	assert.True(t, img.Resized)
+	assert.Equal(t, JPEG, img.MIME)
+	assert.Equal(t, [2]int{100, 50}, [2]int{img.Width, img.Height})
+
+	// WebP images cannot be decoded and are sent as they are
+	webp := []byte("RIFF\x24\x00\x00\x00WEBPVP8 ")
+	img, err = loader.Decode("photo.webp", webp)
+	require.NoError(t, err)
+	assert.Equal(t, WebP, img.MIME)
+	assert.False(t, img.Resized)
+	assert.Equal(t, webp, img.Data)
+
+	// A corrupt image is an error
+	_, err = loader.Decode("broken.png", []byte("\x89PNG\r\n\x1a\nbroken"))
+	assert.ErrorContains(t, err, "invalid image broken.png")
+}
@@ -0,0 +1,249 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Styles of highlighted code
+var (
+	keywordStyle = sgr("95", "39")
+	stringStyle  = sgr("32", "39")
+	numberStyle  = sgr("33", "39")
+	commentStyle = sgr("2;3", "22;23")
+)
+
+// language describes what is highlighted in the code of a language
+type language struct {
+	keywords     map[string]bool
+	lineComments []string
+	// blockComment holds the opening and closing delimiters of block comments
+	blockComment [2]string
+	// quotes are the characters that delimit strings
+	quotes string
+}
+
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	cStyle = language{
+		keywords: words(`auto break case char const continue default do double else enum extern
+			float for goto if inline int long register return short signed sizeof static struct
+			switch typedef union unsigned void volatile while bool true false NULL nullptr class
+			namespace template typename public private protected virtual override new delete
+			this throw try catch using`),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+	}
+	shellStyle = language{
+		keywords: words(`if then else elif fi for while until do done case esac in function
+			return local export readonly set unset shift exit echo source alias cd`),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	}
+
+	languages = map[string]language{
+		"go": {
+			keywords: words(`break case chan const continue default defer else fallthrough for func
+				go goto if import interface map package range return select struct switch type var
+				nil true false iota error string int int8 int16 int32 int64 uint uint8 uint16
+				uint32 uint64 uintptr float32 float64 byte rune bool any complex64 complex128
+				append cap close copy delete len make new panic print println recover max min clear`),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"python": {
+			keywords: words(`and as assert async await break class continue def del elif else except
+				finally for from global if import in is lambda nonlocal not or pass raise return try
+				while with yield None True False self print len range int str float list dict set
+				tuple bool`),
+			lineComments: []string{"#"},
+			quotes:       `"'`,
+		},
+		"javascript": {
+			keywords: words(`async await break case catch class const continue debugger default
+				delete do else export extends finally for from function if import in instanceof let
+				new of return static super switch this throw try typeof var void while with yield
+				null undefined true false interface type enum implements readonly private public
+				protected abstract declare namespace keyof as string number boolean any unknown never`),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       "\"'`",
+		},
+		"rust": {
+			keywords: words(`as async await break const continue crate dyn else enum extern false fn
+				for if impl in let loop match mod move mut pub ref return self Self static struct super
+				trait true type unsafe use where while i8 i16 i32 i64 i128 isize u8 u16 u32 u64 u128
+				usize f32 f64 bool char str String Vec Option Some None Result Ok Err`),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       `"`,
+		},
+		"java": {
+			keywords: words(`abstract assert boolean break byte case catch char class const continue
+				default do double else enum extends final finally float for if implements import
+				instanceof int interface long native new package private protected public return
+				short static super switch synchronized this throw throws try void volatile while var
+				record true false null fun val when object override`),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       `"'`,
+		},
+		"sql": {
+			keywords: words(`select from where and or not insert into values update set delete create
+				table drop alter index primary key foreign references join left right inner outer
+				full on as group by order having limit offset union all distinct null is in like
+				between case when then else end count sum avg min max
+				SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP
+				ALTER INDEX PRIMARY KEY FOREIGN REFERENCES JOIN LEFT RIGHT INNER OUTER FULL ON AS
+				GROUP BY ORDER HAVING LIMIT OFFSET UNION ALL DISTINCT NULL IS IN LIKE BETWEEN CASE
+				WHEN THEN ELSE END COUNT SUM AVG MIN MAX`),
+			lineComments: []string{"--"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       `'"`,
+		},
+		"json": {
+			keywords: words(`true false null`),
+			quotes:   `"`,
+		},
+		"yaml": {
+			keywords:     words(`true false null yes no on off`),
+			lineComments: []string{"#"},
+			quotes:       `"'`,
+		},
+		"c":     cStyle,
+		"shell": shellStyle,
+	}
+
+	// languageAliases maps the names used in fences to the languages above
+	languageAliases = map[string]string{
+		"golang": "go", "py": "python", "python3": "python", "js": "javascript",
+		"jsx": "javascript", "ts": "javascript", "tsx": "javascript", "typescript": "javascript",
+		"rs": "rust", "kotlin": "java", "kt": "java", "cpp": "c", "c++": "c", "h": "c",
+		"hpp": "c", "cc": "c", "cs": "c", "csharp": "c", "sh": "shell", "bash": "shell",
+		"zsh": "shell", "console": "shell", "yml": "yaml",
+	}
+)
+
+// highlighter highlights the lines of a code block
+type highlighter struct {
+	lang language
+	// inComment is set while a block comment spans lines
+	inComment bool
+}
+
+// newHighlighter creates a highlighter for the language named in a fence. The code
+// of unknown languages is not highlighted.
+func newHighlighter(name string) *highlighter {
+	name = strings.ToLower(name)
+	if alias, ok := languageAliases[name]; ok {
+		name = alias
+	}
+	return &highlighter{lang: languages[name]}
+}
+
+// line highlights a line of code
+func (h *highlighter) line(line string) string {
+	lang := h.lang
+	if lang.keywords == nil {
+		return line
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		if h.inComment {
+			end := strings.Index(rest, lang.blockComment[1])
+			if end < 0 {
+				b.WriteString(commentStyle(rest))
+				break
+			}
+			end += len(lang.blockComment[1])
+			b.WriteString(commentStyle(rest[:end]))
+			h.inComment = false
+			i += end
+			continue
+		}
+
+		if lang.blockComment[0] != "" && strings.HasPrefix(rest, lang.blockComment[0]) {
+			h.inComment = true
+			b.WriteString(commentStyle(lang.blockComment[0]))
+			i += len(lang.blockComment[0])
+			continue
+		}
+		if isLineComment(lang, line, i) {
+			b.WriteString(commentStyle(rest))
+			break
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(lang.quotes, c) >= 0:
+			end := stringEnd(line, i)
+			b.WriteString(stringStyle(line[i:end]))
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(line) && isWordByte(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			switch {
+			case lang.keywords[word]:
+				b.WriteString(keywordStyle(word))
+			case unicode.IsDigit(rune(word[0])):
+				b.WriteString(numberStyle(word))
+			default:
+				b.WriteString(word)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isLineComment reports whether a line comment starts at position i. A shell
+// comment must start a word, so that $# and similar are not taken for comments.
+func isLineComment(lang language, line string, i int) bool {
+	for _, prefix := range lang.lineComments {
+		if !strings.HasPrefix(line[i:], prefix) {
+			continue
+		}
+		if prefix == "#" && i > 0 && !unicode.IsSpace(rune(line[i-1])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// stringEnd returns the position after the string starting at i, or the end of
+// the line for strings that are not closed on it
+func stringEnd(line string, i int) int {
+	quote := line[i]
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1
+		}
+	}
+	return len(line)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
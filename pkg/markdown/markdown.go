@@ -0,0 +1,401 @@
+// Package markdown renders Markdown as styled terminal text. Text is rendered line
+// by line as it is written, so answers can be rendered while they are streamed.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// ruleWidth is the width of horizontal rules
+const ruleWidth = 40
+
+var (
+	headingLine   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	fenceLine     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([^`\\s]*)")
+	ruleLine      = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	bulletLine    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedLine   = regexp.MustCompile(`^(\s*)(\d{1,9}[.)])\s+(.*)$`)
+	quoteLine     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	delimiterCell = regexp.MustCompile(`^:?-+:?$`)
+	ansiSequence  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// sgr returns a style that sets a terminal attribute and resets only that attribute,
+// so styles can be nested. Nothing is added when colors are disabled.
+func sgr(on, off string) func(string) string {
+	return func(text string) string {
+		if color.NoColor || text == "" {
+			return text
+		}
+		return "\x1b[" + on + "m" + text + "\x1b[" + off + "m"
+	}
+}
+
+// Styles of the rendered elements
+var (
+	bold      = sgr("1", "22")
+	dim       = sgr("2", "22")
+	italic    = sgr("3", "23")
+	underline = sgr("4", "24")
+	strike    = sgr("9", "29")
+	heading   = sgr("1;95", "22;39")
+	codeSpan  = sgr("96", "39")
+	bullet    = sgr("96", "39")
+)
+
+// Renderer renders the Markdown written to it. Complete lines are rendered as soon
+// as they are written, except for tables, which are rendered once complete.
+type Renderer struct {
+	out io.Writer
+	// width is the width of the terminal. When it is set, the line being written is
+	// shown as it is, then replaced by its rendering once complete.
+	width int
+	// column is the column of the cursor before the first line, after a prompt
+	column int
+
+	line    bytes.Buffer
+	preview string
+
+	// fence is the fence of the code block being written, empty outside of blocks
+	fence     string
+	highlight *highlighter
+	table     []string
+}
+
+// NewRenderer creates a renderer that writes to out. A width above zero enables
+// the preview of incomplete lines on a terminal of that width.
+func NewRenderer(out io.Writer, width int) *Renderer {
+	return &Renderer{out: out, width: width}
+}
+
+// SetColumn sets the column of the cursor when rendering starts, so the preview of
+// the first line can be replaced without erasing what precedes it on the line
+func (r *Renderer) SetColumn(column int) {
+	r.column = column
+}
+
+// Render renders a complete Markdown text
+func Render(text string) string {
+	var b strings.Builder
+	r := NewRenderer(&b, 0)
+	r.Write([]byte(text))
+	r.Flush()
+	return b.String()
+}
+
+// Write renders the complete lines of p and keeps the rest until the line is complete
+func (r *Renderer) Write(p []byte) (int, error) {
+	r.line.Write(p)
+	for {
+		i := bytes.IndexByte(r.line.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(r.line.Next(i + 1)[:i]), "\r")
+		if err := r.renderLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), r.showPreview()
+}
+
+// Flush renders the incomplete last line and any pending table. Every rendered
+// line ends with a newline.
+func (r *Renderer) Flush() error {
+	if r.line.Len() > 0 {
+		line := r.line.String()
+		r.line.Reset()
+		if err := r.renderLine(line); err != nil {
+			return err
+		}
+	}
+	return r.flushTable()
+}
+
+// showPreview shows the incomplete line as it is written
+func (r *Renderer) showPreview() error {
+	if r.width <= 0 {
+		return nil
+	}
+	text := r.line.String()
+	if !strings.HasPrefix(text, r.preview) {
+		if err := r.clearPreview(); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(r.out, text[len(r.preview):])
+	r.preview = text
+	return err
+}
+
+// clearPreview erases the preview of the incomplete line
+func (r *Renderer) clearPreview() error {
+	if r.preview == "" {
+		return nil
+	}
+
+	// The cursor stays on the last column when a row is full
+	rows := 0
+	if n := r.column + utf8.RuneCountInString(r.preview); n > 0 {
+		rows = (n - 1) / r.width
+	}
+	r.preview = ""
+
+	seq := "\r"
+	if rows > 0 {
+		seq += fmt.Sprintf("\x1b[%dA", rows)
+	}
+	if r.column > 0 {
+		seq += fmt.Sprintf("\x1b[%dC", r.column)
+	}
+	_, err := io.WriteString(r.out, seq+"\x1b[J")
+	return err
+}
+
+// renderLine renders a complete line
+func (r *Renderer) renderLine(line string) error {
+	if err := r.clearPreview(); err != nil {
+		return err
+	}
+
+	// Lines of code blocks are highlighted up to the closing fence
+	if r.fence != "" {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, r.fence) && strings.Trim(trimmed, r.fence[:1]) == "" {
+			r.fence = ""
+			return r.writeLine(dim(line))
+		}
+		return r.writeLine(r.highlight.line(line))
+	}
+
+	// Table rows are kept until the table is complete
+	if strings.HasPrefix(strings.TrimSpace(line), "|") {
+		r.table = append(r.table, line)
+		return nil
+	}
+	if err := r.flushTable(); err != nil {
+		return err
+	}
+
+	if m := fenceLine.FindStringSubmatch(line); m != nil {
+		r.fence = m[1]
+		r.highlight = newHighlighter(m[2])
+		return r.writeLine(dim(line))
+	}
+	return r.writeLine(renderBlock(line))
+}
+
+// writeLine writes a rendered line
+func (r *Renderer) writeLine(line string) error {
+	r.column = 0
+	_, err := io.WriteString(r.out, line+"\n")
+	return err
+}
+
+// flushTable renders the pending table
+func (r *Renderer) flushTable() error {
+	if len(r.table) == 0 {
+		return nil
+	}
+	rendered := renderTable(r.table)
+	r.table = nil
+	r.column = 0
+	_, err := io.WriteString(r.out, rendered)
+	return err
+}
+
+// renderBlock renders a line outside of code blocks and tables
+func renderBlock(line string) string {
+	if m := headingLine.FindStringSubmatch(line); m != nil {
+		text := heading(renderInline(m[2]))
+		if len(m[1]) == 1 {
+			text = underline(text)
+		}
+		return text
+	}
+	if ruleLine.MatchString(line) {
+		return dim(strings.Repeat("─", ruleWidth))
+	}
+	if m := bulletLine.FindStringSubmatch(line); m != nil {
+		return m[1] + bullet("•") + " " + renderInline(m[2])
+	}
+	if m := orderedLine.FindStringSubmatch(line); m != nil {
+		return m[1] + bullet(m[2]) + " " + renderInline(m[3])
+	}
+	if m := quoteLine.FindStringSubmatch(line); m != nil {
+		return dim("│ ") + italic(renderInline(m[1]))
+	}
+	return renderInline(line)
+}
+
+// Inline elements, code spans are handled separately since their content is literal
+var (
+	codeSpanPattern = regexp.MustCompile("(`+)(.+?)(`+)")
+	inlineRules     = []struct {
+		pattern *regexp.Regexp
+		render  func(m []string) string
+	}{
+		{regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`), func(m []string) string {
+			if m[1] == m[2] {
+				return underline(m[1])
+			}
+			return underline(m[1]) + dim(" ("+m[2]+")")
+		}},
+		{regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`), func(m []string) string { return bold(m[1]) }},
+		{regexp.MustCompile(`\b__(\S(?:.*?\S)?)__\b`), func(m []string) string { return bold(m[1]) }},
+		{regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`), func(m []string) string { return strike(m[1]) }},
+		{regexp.MustCompile(`\*([^\s*](?:[^*]*?[^\s*])?)\*`), func(m []string) string { return italic(m[1]) }},
+		{regexp.MustCompile(`\b_([^\s_](?:[^_]*?[^\s_])?)_\b`), func(m []string) string { return italic(m[1]) }},
+	}
+)
+
+// renderInline renders the emphasis, links and code spans of a line
+func renderInline(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpanPattern.FindAllStringSubmatchIndex(text, -1) {
+		// Only spans closed by as many backticks as they are opened with
+		if m[3]-m[2] != m[7]-m[6] {
+			continue
+		}
+		b.WriteString(renderEmphasis(text[last:m[0]]))
+		b.WriteString(codeSpan(strings.TrimSpace(text[m[4]:m[5]])))
+		last = m[1]
+	}
+	b.WriteString(renderEmphasis(text[last:]))
+	return b.String()
+}
+
+// renderEmphasis renders the inline elements of text without code spans
+func renderEmphasis(text string) string {
+	for _, rule := range inlineRules {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(s string) string {
+			return rule.render(rule.pattern.FindStringSubmatch(s))
+		})
+	}
+	return text
+}
+
+// renderTable renders the rows of a table with aligned columns. Lines that do not
+// form a table, with a delimiter row after the header, are rendered as text.
+func renderTable(lines []string) string {
+	var rows [][]string
+	for _, line := range lines {
+		rows = append(rows, splitRow(line))
+	}
+
+	if len(rows) < 2 || !isDelimiterRow(rows[1]) {
+		var b strings.Builder
+		for _, line := range lines {
+			b.WriteString(renderInline(line) + "\n")
+		}
+		return b.String()
+	}
+
+	alignments := rows[1]
+	rows = append(rows[:1], rows[2:]...)
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, columns)
+		for j := range columns {
+			if j < len(row) {
+				cells[i][j] = renderInline(row[j])
+			}
+			widths[j] = max(widths[j], visibleWidth(cells[i][j]))
+		}
+	}
+
+	var b strings.Builder
+	separator := dim(" │ ")
+	for i, row := range cells {
+		for j, cell := range row {
+			if j > 0 {
+				b.WriteString(separator)
+			}
+			if i == 0 {
+				cell = bold(cell)
+			}
+			align := ""
+			if j < len(alignments) {
+				align = alignments[j]
+			}
+			b.WriteString(pad(cell, widths[j], align))
+		}
+		b.WriteString("\n")
+
+		if i == 0 {
+			var rule []string
+			for _, width := range widths {
+				rule = append(rule, strings.Repeat("─", width))
+			}
+			b.WriteString(dim(strings.Join(rule, "─┼─")) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// splitRow splits a table row into its trimmed cells
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isDelimiterRow reports whether a row separates the header of a table from its body
+func isDelimiterRow(row []string) bool {
+	for _, cell := range row {
+		if !delimiterCell.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// pad pads a cell to a width, following the alignment of its delimiter cell
+func pad(cell string, width int, align string) string {
+	space := width - visibleWidth(cell)
+	switch {
+	case strings.HasPrefix(align, ":") && strings.HasSuffix(align, ":"):
+		return strings.Repeat(" ", space/2) + cell + strings.Repeat(" ", space-space/2)
+	case strings.HasSuffix(align, ":"):
+		return strings.Repeat(" ", space) + cell
+	default:
+		return cell + strings.Repeat(" ", space)
+	}
+}
+
+// visibleWidth returns the number of columns of rendered text
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(text, ""))
+}
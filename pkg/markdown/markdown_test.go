@@ -0,0 +1,161 @@
+package markdown
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+// withColors sets whether colors are enabled for the duration of a test
+func withColors(t *testing.T, enabled bool) {
+	orig := color.NoColor
+	color.NoColor = !enabled
+	t.Cleanup(func() { color.NoColor = orig })
+}
+
+func TestRender(t *testing.T) {
+	withColors(t, false)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Paragraph", input: "Hello world", want: "Hello world\n"},
+		{name: "Heading", input: "## Install ##\ntext", want: "Install\ntext\n"},
+		{name: "Emphasis", input: "**bold**, *italic*, _it_, ~~gone~~ and snake_case_name", want: "bold, italic, it, gone and snake_case_name\n"},
+		{name: "Arithmetic is not emphasis", input: "2 * 3 * 4", want: "2 * 3 * 4\n"},
+		{name: "Code span", input: "Run `go **test**` now", want: "Run go **test** now\n"},
+		{name: "Link", input: "See [the docs](https://ollama.com) or [https://x.y](https://x.y)", want: "See the docs (https://ollama.com) or https://x.y\n"},
+		{name: "Lists", input: "- one\n  * two\n3. three", want: "• one\n  • two\n3. three\n"},
+		{name: "Quote", input: "> quoted **text**", want: "│ quoted text\n"},
+		{name: "Rule", input: "---", want: "────────────────────────────────────────\n"},
+		{
+			name:  "Code block",
+			input: "```go\nx := **y**\n- z\n```\nafter",
+			want:  "```go\nx := **y**\n- z\n```\nafter\n",
+		},
+		{
+			name:  "Table",
+			input: "| Name | Size |\n|:-----|-----:|\n| llama3.2 | 2 GB |\n| **qwen3** | 10 GB |\nafter",
+			want:  "Name     │  Size\n─────────┼──────\nllama3.2 │  2 GB\nqwen3    │ 10 GB\nafter\n",
+		},
+		{
+			name:  "Centered column and missing cells",
+			input: "| a | b |\n|:-:|---|\n| wide | x |\n| c |",
+			want:  " a   │ b\n─────┼──\nwide │ x\n c   │  \n",
+		},
+		{name: "Pipes without a table", input: "| not a table", want: "| not a table\n"},
+		{name: "Escaped pipe", input: "| a | b |\n|---|---|\n| x \\| y | z |", want: "a     │ b\n──────┼──\nx | y │ z\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.input))
+		})
+	}
+}
+
+func TestRenderStyles(t *testing.T) {
+	withColors(t, true)
+
+	assert.Equal(t, "\x1b[1;95mTitle\x1b[22;39m\n", Render("### Title"))
+	assert.Equal(t, "\x1b[1mbold \x1b[3mboth\x1b[23m end\x1b[22m\n", Render("**bold *both* end**"))
+	assert.Equal(t, "a \x1b[96mcode\x1b[39m\n", Render("a `code`"))
+	assert.Equal(t, "\x1b[96m•\x1b[39m item\n", Render("- item"))
+}
+
+func TestHighlight(t *testing.T) {
+	withColors(t, true)
+
+	tests := []struct {
+		name  string
+		lang  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "Keywords, strings and numbers",
+			lang:  "golang",
+			lines: []string{`return "a \" b", 42`},
+			want:  []string{"\x1b[95mreturn\x1b[39m \x1b[32m\"a \\\" b\"\x1b[39m, \x1b[33m42\x1b[39m"},
+		},
+		{
+			name:  "Line comment",
+			lang:  "py",
+			lines: []string{"x = None  # not None"},
+			want:  []string{"x = \x1b[95mNone\x1b[39m  \x1b[2;3m# not None\x1b[22;23m"},
+		},
+		{
+			name:  "Block comment across lines",
+			lang:  "js",
+			lines: []string{"let a /* one", "two */ b"},
+			want:  []string{"\x1b[95mlet\x1b[39m a \x1b[2;3m/*\x1b[22;23m\x1b[2;3m one\x1b[22;23m", "\x1b[2;3mtwo */\x1b[22;23m b"},
+		},
+		{
+			name:  "Shell variables are not comments",
+			lang:  "bash",
+			lines: []string{"echo $# # count"},
+			want:  []string{"\x1b[95mecho\x1b[39m $# \x1b[2;3m# count\x1b[22;23m"},
+		},
+		{
+			name:  "Unknown language",
+			lang:  "brainfuck",
+			lines: []string{"if 1"},
+			want:  []string{"if 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHighlighter(tt.lang)
+			for i, line := range tt.lines {
+				assert.Equal(t, tt.want[i], h.line(line))
+			}
+		})
+	}
+}
+
+func TestRendererStreaming(t *testing.T) {
+	withColors(t, false)
+
+	text := "# Title\n\nSome **bold** text\n| a | b |\n|---|---|\n| 1 | 2 |\n```sh\necho hi\n```\n- last"
+
+	// Rendering chunks of any size gives the rendering of the whole text
+	for _, size := range []int{1, 3, 7, len(text)} {
+		var out bytes.Buffer
+		r := NewRenderer(&out, 0)
+		for i := 0; i < len(text); i += size {
+			_, err := r.Write([]byte(text[i:min(i+size, len(text))]))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, r.Flush())
+		assert.Equal(t, Render(text), out.String(), "chunks of %d bytes", size)
+	}
+}
+
+func TestRendererPreview(t *testing.T) {
+	withColors(t, false)
+
+	var out bytes.Buffer
+	r := NewRenderer(&out, 10)
+	r.SetColumn(4)
+
+	// The incomplete line is shown as it is written
+	r.Write([]byte("- **abc"))
+	r.Write([]byte("**"))
+	assert.Equal(t, "- **abc**", out.String())
+
+	// It is replaced by its rendering once complete, keeping what precedes it on the
+	// first line, which wrapped once
+	out.Reset()
+	r.Write([]byte("\nnext"))
+	assert.Equal(t, "\r\x1b[1A\x1b[4C\x1b[J• abc\nnext", out.String())
+
+	// Later lines start on the first column
+	out.Reset()
+	r.Flush()
+	assert.Equal(t, "\r\x1b[Jnext\n", out.String())
+}
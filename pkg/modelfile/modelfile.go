@@ -0,0 +1,312 @@
+package modelfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ollama/ollama/api"
+)
+
+// Command is a single instruction of a Modelfile
+type Command struct {
+	// Name is the lower case instruction, e.g. "from" or "parameter"
+	Name string
+	// Key is the parameter name for PARAMETER and the role for MESSAGE
+	Key string
+	// Args is the value of the instruction
+	Args string
+	// Line is the line number the instruction starts on
+	Line int
+}
+
+// Modelfile is a parsed Modelfile
+type Modelfile struct {
+	Commands []Command
+}
+
+// BlobUploader uploads a local file as a blob and returns its digest
+type BlobUploader func(path string) (string, error)
+
+// instructions lists the supported Modelfile instructions
+var instructions = map[string]bool{
+	"from":      true,
+	"parameter": true,
+	"template":  true,
+	"system":    true,
+	"adapter":   true,
+	"license":   true,
+	"message":   true,
+}
+
+// messageRoles lists the roles allowed in MESSAGE instructions
+var messageRoles = map[string]bool{
+	"system":    true,
+	"user":      true,
+	"assistant": true,
+}
+
+// modelFileExtensions lists the files uploaded when FROM points to a directory
+var modelFileExtensions = []string{".gguf", ".safetensors", ".json", ".model", ".tiktoken"}
+
+// Parse reads a Modelfile. Instruction names are case insensitive, lines starting
+// with # are comments and values can span multiple lines when wrapped in """.
+func Parse(r io.Reader) (*Modelfile, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Modelfile: %w", err)
+	}
+
+	f := &Modelfile{}
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest := splitWord(line)
+		name = strings.ToLower(name)
+		if !instructions[name] {
+			return nil, fmt.Errorf("line %d: unknown instruction %q", lineNum, name)
+		}
+
+		cmd := Command{Name: name, Line: lineNum}
+		if name == "parameter" || name == "message" {
+			cmd.Key, rest = splitWord(rest)
+			if cmd.Key == "" {
+				return nil, fmt.Errorf("line %d: missing %s name", lineNum, name)
+			}
+			if name == "message" {
+				cmd.Key = strings.ToLower(cmd.Key)
+				if !messageRoles[cmd.Key] {
+					return nil, fmt.Errorf("line %d: invalid message role %q, must be one of system, user or assistant", lineNum, cmd.Key)
+				}
+			}
+		}
+
+		value, next, err := parseValue(rest, lines, i)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		i = next
+		if value == "" && name != "system" && name != "template" && name != "message" {
+			return nil, fmt.Errorf("line %d: missing value for %s", lineNum, strings.ToUpper(name))
+		}
+
+		cmd.Args = value
+		f.Commands = append(f.Commands, cmd)
+	}
+
+	return f, nil
+}
+
+// ParseFile reads and parses the Modelfile at path
+func ParseFile(path string) (*Modelfile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Modelfile: %w", err)
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// splitWord splits s into its first word and the trimmed remainder
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if idx := strings.IndexAny(s, " \t"); idx >= 0 {
+		return s[:idx], strings.TrimSpace(s[idx:])
+	}
+	return s, ""
+}
+
+// parseValue parses the value starting on line i, which may be wrapped in quotes or
+// triple quotes. It returns the value and the index of the last line consumed.
+func parseValue(rest string, lines []string, i int) (string, int, error) {
+	if !strings.HasPrefix(rest, `"""`) {
+		if len(rest) >= 2 && strings.HasPrefix(rest, `"`) && strings.HasSuffix(rest, `"`) {
+			return strings.ReplaceAll(rest[1:len(rest)-1], `\"`, `"`), i, nil
+		}
+		return rest, i, nil
+	}
+
+	// Multi-line value, possibly closed on the same line
+	rest = strings.TrimPrefix(rest, `"""`)
+	var parts []string
+	for {
+		if idx := strings.Index(rest, `"""`); idx >= 0 {
+			if trailing := strings.TrimSpace(rest[idx+3:]); trailing != "" {
+				return "", i, fmt.Errorf("unexpected %q after closing quotes", trailing)
+			}
+			parts = append(parts, rest[:idx])
+			return strings.Join(parts, "\n"), i, nil
+		}
+
+		parts = append(parts, rest)
+		i++
+		if i >= len(lines) {
+			return "", i, fmt.Errorf(`unterminated """ block`)
+		}
+		rest = lines[i]
+	}
+}
+
+// CreateRequest converts the Modelfile into a create request for the named model.
+// Relative paths are resolved against dir, and local files referenced by FROM and
+// ADAPTER are passed to upload, which returns the digest of the uploaded blob.
+func (f *Modelfile) CreateRequest(name, dir string, upload BlobUploader) (*api.CreateRequest, error) {
+	req := &api.CreateRequest{Model: name}
+	params := make(map[string][]string)
+	var licenses []string
+	hasFrom := false
+
+	for _, cmd := range f.Commands {
+		switch cmd.Name {
+		case "from":
+			if hasFrom {
+				return nil, fmt.Errorf("line %d: only one FROM instruction is allowed", cmd.Line)
+			}
+			hasFrom = true
+
+			files, err := localFiles(cmd.Args, dir)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", cmd.Line, err)
+			}
+			if files == nil {
+				// Not a local path, so it names an existing model
+				req.From = cmd.Args
+				continue
+			}
+			if req.Files, err = uploadFiles(files, upload); err != nil {
+				return nil, err
+			}
+		case "adapter":
+			files, err := localFiles(cmd.Args, dir)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", cmd.Line, err)
+			}
+			if files == nil {
+				return nil, fmt.Errorf("line %d: adapter %q not found", cmd.Line, cmd.Args)
+			}
+			adapters, err := uploadFiles(files, upload)
+			if err != nil {
+				return nil, err
+			}
+			if req.Adapters == nil {
+				req.Adapters = make(map[string]string)
+			}
+			for file, digest := range adapters {
+				req.Adapters[file] = digest
+			}
+		case "parameter":
+			params[cmd.Key] = append(params[cmd.Key], cmd.Args)
+		case "template":
+			req.Template = cmd.Args
+		case "system":
+			req.System = cmd.Args
+		case "license":
+			licenses = append(licenses, cmd.Args)
+		case "message":
+			req.Messages = append(req.Messages, api.Message{Role: cmd.Key, Content: cmd.Args})
+		}
+	}
+
+	if !hasFrom {
+		return nil, fmt.Errorf("no FROM instruction found")
+	}
+
+	if len(params) > 0 {
+		formatted, err := api.FormatParams(params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter: %w", err)
+		}
+		req.Parameters = formatted
+	}
+	if len(licenses) > 0 {
+		req.License = licenses
+	}
+
+	return req, nil
+}
+
+// localFiles resolves a FROM or ADAPTER value to local files. It returns nil if the
+// value does not look like a path and does not exist, i.e. it names a model.
+func localFiles(value, dir string) ([]string, error) {
+	path := value
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if looksLikePath(value) {
+			return nil, fmt.Errorf("file %q not found", value)
+		}
+		return nil, nil
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %q: %w", value, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		for _, ext := range modelFileExtensions {
+			if strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+				files = append(files, filepath.Join(path, entry.Name()))
+				break
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no model files found in %q", value)
+	}
+
+	return files, nil
+}
+
+// looksLikePath reports whether a FROM or ADAPTER value is meant to be a local path
+func looksLikePath(value string) bool {
+	return strings.HasPrefix(value, ".") ||
+		strings.HasPrefix(value, "/") ||
+		strings.HasPrefix(value, "~") ||
+		filepath.IsAbs(value) ||
+		strings.HasSuffix(strings.ToLower(value), ".gguf") ||
+		strings.HasSuffix(strings.ToLower(value), ".safetensors")
+}
+
+// uploadFiles uploads files and maps their base names to the blob digests
+func uploadFiles(files []string, upload BlobUploader) (map[string]string, error) {
+	digests := make(map[string]string, len(files))
+	for _, file := range files {
+		digest, err := upload(file)
+		if err != nil {
+			return nil, err
+		}
+		digests[filepath.Base(file)] = digest
+	}
+	return digests, nil
+}
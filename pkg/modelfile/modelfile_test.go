@@ -0,0 +1,136 @@
+package modelfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleModelfile = `# Assistant used by the team
+FROM llama3.2
+PARAMETER temperature 0.7
+PARAMETER num_ctx 8192
+PARAMETER stop "<|eot_id|>"
+PARAMETER stop "User:"
+template """{{ if .System }}{{ .System }}
+{{ end }}{{ .Prompt }}"""
+SYSTEM """
+You are a helpful assistant.
+"""
+LICENSE MIT
+MESSAGE user "Is the sky blue?"
+MESSAGE assistant Yes.
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(exampleModelfile))
+	require.NoError(t, err)
+	require.Len(t, f.Commands, 10)
+
+	assert.Equal(t, Command{Name: "from", Args: "llama3.2", Line: 2}, f.Commands[0])
+	assert.Equal(t, Command{Name: "parameter", Key: "stop", Args: "<|eot_id|>", Line: 5}, f.Commands[3])
+	assert.Equal(t, "{{ if .System }}{{ .System }}\n{{ end }}{{ .Prompt }}", f.Commands[5].Args)
+	assert.Equal(t, "\nYou are a helpful assistant.\n", f.Commands[6].Args)
+	assert.Equal(t, Command{Name: "message", Key: "user", Args: "Is the sky blue?", Line: 13}, f.Commands[8])
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"Unknown instruction", "FROM llama3.2\nRUN echo", `line 2: unknown instruction "run"`},
+		{"Invalid message role", "MESSAGE tool hi", `line 1: invalid message role "tool"`},
+		{"Unterminated block", "SYSTEM \"\"\"\nhello", `line 1: unterminated """ block`},
+		{"Trailing content", `SYSTEM """hi""" there`, `unexpected "there" after closing quotes`},
+		{"Missing value", "FROM", "line 1: missing value for FROM"},
+		{"Missing parameter name", "PARAMETER", "line 1: missing parameter name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCreateRequestFromModel(t *testing.T) {
+	f, err := Parse(strings.NewReader(exampleModelfile))
+	require.NoError(t, err)
+
+	upload := func(path string) (string, error) {
+		t.Fatalf("unexpected upload of %s", path)
+		return "", nil
+	}
+
+	req, err := f.CreateRequest("team/assistant", t.TempDir(), upload)
+	require.NoError(t, err)
+
+	assert.Equal(t, "team/assistant", req.Model)
+	assert.Equal(t, "llama3.2", req.From)
+	assert.Equal(t, float32(0.7), req.Parameters["temperature"])
+	assert.Equal(t, int64(8192), req.Parameters["num_ctx"])
+	assert.Equal(t, []string{"<|eot_id|>", "User:"}, req.Parameters["stop"])
+	assert.Equal(t, []string{"MIT"}, req.License)
+	assert.Len(t, req.Messages, 2)
+	assert.Equal(t, "assistant", req.Messages[1].Role)
+}
+
+func TestCreateRequestUploadsLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.gguf"), []byte("weights"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "lora"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lora", "adapter.safetensors"), []byte("adapter"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lora", "README.md"), []byte("ignored"), 0o644))
+
+	f, err := Parse(strings.NewReader("FROM ./model.gguf\nADAPTER ./lora\n"))
+	require.NoError(t, err)
+
+	var uploaded []string
+	upload := func(path string) (string, error) {
+		uploaded = append(uploaded, path)
+		return "sha256:" + filepath.Base(path), nil
+	}
+
+	req, err := f.CreateRequest("custom", dir, upload)
+	require.NoError(t, err)
+
+	assert.Empty(t, req.From)
+	assert.Equal(t, map[string]string{"model.gguf": "sha256:model.gguf"}, req.Files)
+	assert.Equal(t, map[string]string{"adapter.safetensors": "sha256:adapter.safetensors"}, req.Adapters)
+	assert.Equal(t, []string{filepath.Join(dir, "model.gguf"), filepath.Join(dir, "lora", "adapter.safetensors")}, uploaded)
+}
+
+func TestCreateRequestErrors(t *testing.T) {
+	upload := func(path string) (string, error) { return "sha256:x", nil }
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"Missing FROM", "SYSTEM hi", "no FROM instruction found"},
+		{"Multiple FROM", "FROM a\nFROM b", "line 2: only one FROM instruction is allowed"},
+		{"Missing local file", "FROM ./missing.gguf", `line 1: file "./missing.gguf" not found`},
+		{"Unknown parameter", "FROM a\nPARAMETER bogus 1", "unknown parameter 'bogus'"},
+		{"Invalid parameter value", "FROM a\nPARAMETER temperature hot", "invalid float value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			_, err = f.CreateRequest("custom", t.TempDir(), upload)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
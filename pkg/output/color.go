@@ -25,6 +25,8 @@ var (
 	Underline = color.New(color.Underline).SprintFunc()
 	// Header prints text in magenta and bold
 	Header = color.New(color.FgHiMagenta, color.Bold).SprintFunc()
+	// Dim prints text faint, for secondary output such as thinking traces
+	Dim = color.New(color.Faint).SprintFunc()
 )
 
 // ColorWriter is a wrapper around io.Writer that supports color output
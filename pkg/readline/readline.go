@@ -0,0 +1,611 @@
+// Package readline reads lines of input from a terminal with line editing,
+// history, tab completion and bracketed paste. When the input is not a terminal,
+// lines are read as they are.
+package readline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupt = errors.New("interrupted")
+
+// defaultWidth is the terminal width used when it cannot be determined
+const defaultWidth = 80
+
+// tabWidth is the distance between the tab stops used to display tabs
+const tabWidth = 8
+
+// maxHistory limits the number of lines kept in the history
+const maxHistory = 1000
+
+// Escape sequences that enable and disable bracketed paste, and that mark the start
+// and the end of pasted text
+const (
+	enableBracketedPaste  = "\x1b[?2004h"
+	disableBracketedPaste = "\x1b[?2004l"
+	pasteStart            = "200~"
+	pasteEnd              = "201~"
+)
+
+// Control keys
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// ansiSequence matches the escape sequences of colored prompts
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// Completer returns the completions of the text before the cursor. Each completion
+// replaces that text.
+type Completer func(text string) []string
+
+// Editor reads lines from its input
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+	fd  int
+	tty bool
+
+	// Completer is called when Tab is pressed, nil disables completion
+	Completer Completer
+
+	history     []string
+	historyFile string
+}
+
+// New creates an editor that reads from in and echoes to out. Line editing is
+// enabled when in is a terminal.
+func New(in *os.File, out io.Writer) *Editor {
+	fd := int(in.Fd())
+	return &Editor{
+		in:  bufio.NewReader(in),
+		out: out,
+		fd:  fd,
+		tty: term.IsTerminal(fd),
+	}
+}
+
+// NewReader creates an editor that reads lines from a reader without line editing
+func NewReader(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out, fd: -1}
+}
+
+// History returns the lines of the history, oldest first
+func (e *Editor) History() []string {
+	return e.history
+}
+
+// SetHistoryFile loads the history from a file, which is created when the first
+// line is added. Lines added to the history of a terminal are appended to the file.
+func (e *Editor) SetHistoryFile(path string) error {
+	e.historyFile = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	// Each line of the file is a JSON string, so entries can span several lines
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		var entry string
+		if line != "" && json.Unmarshal([]byte(line), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	e.history = entries
+
+	if len(entries) > maxHistory {
+		e.history = entries[len(entries)-maxHistory:]
+		return e.writeHistory()
+	}
+	return nil
+}
+
+// AddHistory adds a line to the history, unless it is empty or repeats the last line
+func (e *Editor) AddHistory(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return nil
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	if e.historyFile == "" || !e.tty {
+		return nil
+	}
+	file, err := e.openHistory(os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeHistoryEntries(file, []string{line})
+}
+
+// writeHistory replaces the history file with the history
+func (e *Editor) writeHistory() error {
+	file, err := e.openHistory(os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeHistoryEntries(file, e.history)
+}
+
+// openHistory opens the history file for writing, creating it if needed
+func (e *Editor) openHistory(flag int) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(e.historyFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_CREATE|os.O_WRONLY|flag, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	return file, nil
+}
+
+// writeHistoryEntries writes entries to a history file, one JSON string per line
+func writeHistoryEntries(w io.Writer, entries []string) error {
+	var b strings.Builder
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// ReadLine displays the prompt and reads a line. Pasted text is inserted as it is,
+// so the line may contain newlines. It returns io.EOF at the end of the input, or
+// when Ctrl-D is pressed on an empty line, and ErrInterrupt when Ctrl-C is pressed.
+// Lines are not added to the history, see AddHistory.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.tty {
+		return e.readPlain(prompt)
+	}
+
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer term.Restore(e.fd, state)
+
+	fmt.Fprint(e.out, enableBracketedPaste)
+	defer fmt.Fprint(e.out, disableBracketedPaste)
+
+	return e.edit(prompt)
+}
+
+// readPlain reads a line without line editing
+func (e *Editor) readPlain(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineState is the state of the line being edited
+type lineState struct {
+	prompt string
+	buf    []rune
+	pos    int
+	// row is the row of the cursor, relative to the first row of the prompt
+	row int
+}
+
+// edit reads a line with line editing, the terminal must be in raw mode
+func (e *Editor) edit(prompt string) (string, error) {
+	l := &lineState{prompt: prompt}
+	historyPos := len(e.history)
+	pending := ""
+	e.refresh(l)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(l.buf) > 0 {
+				e.finish(l)
+				return string(l.buf), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			e.finish(l)
+			return string(l.buf), nil
+		case keyCtrlC:
+			l.pos = len(l.buf)
+			e.refresh(l)
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupt
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			l.delete()
+		case keyTab:
+			e.complete(l)
+		case keyCtrlA:
+			l.pos = 0
+		case keyCtrlE:
+			l.pos = len(l.buf)
+		case keyCtrlB:
+			l.left()
+		case keyCtrlF:
+			l.right()
+		case keyBackspace, keyCtrlH:
+			l.backspace()
+		case keyCtrlK:
+			l.buf = l.buf[:l.pos]
+		case keyCtrlU:
+			l.buf = append([]rune{}, l.buf[l.pos:]...)
+			l.pos = 0
+		case keyCtrlW:
+			l.deleteWord()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+			l.row = 0
+		case keyCtrlP, keyCtrlN:
+			historyPos, pending = e.browseHistory(l, r == keyCtrlP, historyPos, pending)
+		case keyEscape:
+			key, err := e.readEscape()
+			if err != nil {
+				return "", err
+			}
+			switch key {
+			case "A":
+				historyPos, pending = e.browseHistory(l, true, historyPos, pending)
+			case "B":
+				historyPos, pending = e.browseHistory(l, false, historyPos, pending)
+			case "C":
+				l.right()
+			case "D":
+				l.left()
+			case "H", "1~", "7~":
+				l.pos = 0
+			case "F", "4~", "8~":
+				l.pos = len(l.buf)
+			case "3~":
+				l.delete()
+			case pasteStart:
+				if err := e.paste(l); err != nil {
+					return "", err
+				}
+			case "b":
+				l.wordLeft()
+			case "f":
+				l.wordRight()
+			}
+		default:
+			if r >= ' ' {
+				l.insert(r)
+			}
+		}
+		e.refresh(l)
+	}
+}
+
+// readEscape reads the rest of an escape sequence and returns it without the
+// escape and the bracket, e.g. "A" for the up arrow or "3~" for delete
+func (e *Editor) readEscape() (string, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	if r != '[' && r != 'O' {
+		// Alt combined with a key
+		return string(r), nil
+	}
+
+	var seq strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		seq.WriteRune(r)
+		if r >= 0x40 && r <= 0x7e {
+			return seq.String(), nil
+		}
+	}
+}
+
+// paste inserts pasted text up to the end of the paste, keeping its newlines and
+// tabs instead of handling them as keys
+func (e *Editor) paste(l *lineState) error {
+	previous := rune(0)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case r == keyEscape:
+			key, err := e.readEscape()
+			if err != nil {
+				return err
+			}
+			if key == pasteEnd {
+				return nil
+			}
+		case r == '\r':
+			l.insert('\n')
+		case r == '\n':
+			if previous != '\r' {
+				l.insert('\n')
+			}
+		case r == keyTab || r >= ' ':
+			l.insert(r)
+		}
+		previous = r
+	}
+}
+
+// browseHistory replaces the line with the previous or next line of the history.
+// The line being typed is kept as pending, and restored after the last entry.
+func (e *Editor) browseHistory(l *lineState, previous bool, pos int, pending string) (int, string) {
+	if pos == len(e.history) {
+		pending = string(l.buf)
+	}
+	if previous && pos > 0 {
+		pos--
+	} else if !previous && pos < len(e.history) {
+		pos++
+	} else {
+		return pos, pending
+	}
+
+	if pos == len(e.history) {
+		l.set(pending)
+	} else {
+		l.set(e.history[pos])
+	}
+	return pos, pending
+}
+
+// complete replaces the text before the cursor with its completion. When there
+// are several completions, it is extended to their common prefix, or the
+// completions are listed if it cannot be extended.
+func (e *Editor) complete(l *lineState) {
+	if e.Completer == nil {
+		return
+	}
+
+	text := string(l.buf[:l.pos])
+	completions := e.Completer(text)
+	if len(completions) == 0 {
+		return
+	}
+
+	prefix := completions[0]
+	if len(completions) > 1 {
+		prefix = commonPrefix(completions)
+	}
+	if len(prefix) > len(text) {
+		rest := string(l.buf[l.pos:])
+		l.set(prefix + rest)
+		l.pos = utf8.RuneCountInString(prefix)
+		return
+	}
+
+	if len(completions) > 1 {
+		pos := l.pos
+		e.finish(l)
+		fmt.Fprintf(e.out, "%s\r\n", strings.Join(completions, "  "))
+		l.row = 0
+		l.pos = pos
+	}
+}
+
+// finish moves the cursor after the end of the line and starts a new line
+func (e *Editor) finish(l *lineState) {
+	l.pos = len(l.buf)
+	e.refresh(l)
+	fmt.Fprint(e.out, "\r\n")
+}
+
+// refresh redraws the prompt and the line, which may wrap or contain newlines and
+// span several rows
+func (e *Editor) refresh(l *lineState) {
+	width := e.width()
+
+	var b strings.Builder
+	if l.row > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", l.row)
+	}
+	b.WriteString("\r\x1b[J")
+	b.WriteString(l.prompt)
+
+	// Follow the position of the terminal cursor as the line is written. A cursor
+	// at the edge of the terminal only wraps when the next character is written.
+	row, col := 0, 0
+	advance := func() {
+		if col == width {
+			row++
+			col = 0
+		}
+		col++
+	}
+	for i := 0; i < displayWidth(l.prompt); i++ {
+		advance()
+	}
+
+	cursorRow, cursorCol := 0, 0
+	for i, r := range l.buf {
+		if i == l.pos {
+			cursorRow, cursorCol = row, col
+		}
+		switch r {
+		case '\n':
+			b.WriteString("\r\n")
+			row++
+			col = 0
+		case '\t':
+			// Tabs are displayed as spaces up to the next tab stop
+			for n := tabWidth - col%tabWidth; n > 0 && col < width; n-- {
+				b.WriteByte(' ')
+				col++
+			}
+		default:
+			advance()
+			b.WriteRune(r)
+		}
+	}
+	if l.pos == len(l.buf) {
+		cursorRow, cursorCol = row, col
+	}
+
+	// Move to the next row when the line ends at the edge of the terminal
+	if col == width {
+		b.WriteString("\r\n")
+		row++
+		col = 0
+	}
+	if cursorCol == width {
+		cursorRow++
+		cursorCol = 0
+	}
+
+	if up := row - cursorRow; up > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", up)
+	}
+	b.WriteString("\r")
+	if cursorCol > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", cursorCol)
+	}
+	l.row = cursorRow
+
+	fmt.Fprint(e.out, b.String())
+}
+
+// width returns the width of the terminal
+func (e *Editor) width() int {
+	width, _, err := term.GetSize(e.fd)
+	if err != nil || width <= 0 {
+		return defaultWidth
+	}
+	return width
+}
+
+func (l *lineState) set(text string) {
+	l.buf = []rune(text)
+	l.pos = len(l.buf)
+}
+
+func (l *lineState) insert(r rune) {
+	l.buf = append(l.buf, 0)
+	copy(l.buf[l.pos+1:], l.buf[l.pos:])
+	l.buf[l.pos] = r
+	l.pos++
+}
+
+func (l *lineState) backspace() {
+	if l.pos > 0 {
+		l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+		l.pos--
+	}
+}
+
+func (l *lineState) delete() {
+	if l.pos < len(l.buf) {
+		l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+	}
+}
+
+func (l *lineState) deleteWord() {
+	end := l.pos
+	l.wordLeft()
+	l.buf = append(l.buf[:l.pos], l.buf[end:]...)
+}
+
+func (l *lineState) left() {
+	if l.pos > 0 {
+		l.pos--
+	}
+}
+
+func (l *lineState) right() {
+	if l.pos < len(l.buf) {
+		l.pos++
+	}
+}
+
+func (l *lineState) wordLeft() {
+	for l.pos > 0 && l.buf[l.pos-1] == ' ' {
+		l.pos--
+	}
+	for l.pos > 0 && l.buf[l.pos-1] != ' ' {
+		l.pos--
+	}
+}
+
+func (l *lineState) wordRight() {
+	for l.pos < len(l.buf) && l.buf[l.pos] == ' ' {
+		l.pos++
+	}
+	for l.pos < len(l.buf) && l.buf[l.pos] != ' ' {
+		l.pos++
+	}
+}
+
+// displayWidth returns the number of columns a prompt takes, without its colors
+func displayWidth(s string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(s, ""))
+}
+
+// commonPrefix returns the longest common prefix of strings
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,197 @@
+package readline
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEditor creates an editor that edits the keys of input as if it was a terminal
+func newTestEditor(input string) (*Editor, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &Editor{in: bufio.NewReader(strings.NewReader(input)), out: &out, fd: -1, tty: true}, &out
+}
+
+func TestEdit(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "Plain text", input: "hello\r", want: "hello"},
+		{name: "Backspace", input: "helpp\x7f\x7flo\r", want: "hello"},
+		{name: "Insert in the middle", input: "hllo\x1b[D\x1b[D\x1b[De\r", want: "hello"},
+		{name: "Home and end", input: "ello\x01h\x05!\r", want: "hello!"},
+		{name: "Home and delete keys", input: "xhello\x1b[H\x1b[3~\r", want: "hello"},
+		{name: "Kill to end of line", input: "hello world\x1bb\x0b\r", want: "hello "},
+		{name: "Kill to start of line", input: "hello world\x1bb\x15\r", want: "world"},
+		{name: "Delete word", input: "hello big world\x17\x17world\r", want: "hello world"},
+		{name: "Unicode", input: "héllo wörld\x1b[D\x7f\r", want: "héllo wörd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestEditor(tt.input)
+			line, err := e.edit("> ")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, line)
+		})
+	}
+}
+
+func TestEditKeys(t *testing.T) {
+	e, out := newTestEditor("\x03")
+	_, err := e.edit("> ")
+	assert.ErrorIs(t, err, ErrInterrupt)
+	assert.Contains(t, out.String(), "^C")
+
+	e, _ = newTestEditor("\x04")
+	_, err = e.edit("> ")
+	assert.ErrorIs(t, err, io.EOF)
+
+	// Ctrl-D deletes a character on a line that is not empty
+	e, _ = newTestEditor("ab\x01\x04\r")
+	line, err := e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "b", line)
+}
+
+func TestEditHistory(t *testing.T) {
+	e, _ := newTestEditor("\x1b[A\x1b[A\r" + "draft\x1b[A\x1b[B\r")
+	e.AddHistory("first")
+	e.AddHistory("second")
+	e.AddHistory("second")
+	e.AddHistory("  ")
+	assert.Equal(t, []string{"first", "second"}, e.History())
+
+	line, err := e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "first", line)
+
+	// The line being typed is restored after browsing the history
+	line, err = e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "draft", line)
+}
+
+func TestEditCompletion(t *testing.T) {
+	commands := []string{"/help", "/history", "/model ", "/save "}
+	completer := func(text string) []string {
+		var completions []string
+		for _, command := range commands {
+			if strings.HasPrefix(command, text) {
+				completions = append(completions, command)
+			}
+		}
+		return completions
+	}
+
+	// A single completion replaces the text
+	e, _ := newTestEditor("/m\tllama3.2\r")
+	e.Completer = completer
+	line, err := e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "/model llama3.2", line)
+
+	// Several completions are extended to their common prefix, then listed
+	e, out := newTestEditor("/h\te\t\r")
+	e.Completer = completer
+	line, err = e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "/help", line)
+	assert.Contains(t, out.String(), "/help  /history\r\n")
+}
+
+func TestEditPaste(t *testing.T) {
+	// Newlines and tabs of pasted text are kept, and keys after the paste are handled
+	e, _ := newTestEditor("fix:\x1b[200~func main() {\r\n\tprintln()\r}\x1b[201~\x7f}\r")
+	line, err := e.edit("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "fix:func main() {\n\tprintln()\n}", line)
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	e, _ := newTestEditor("")
+	require.NoError(t, e.SetHistoryFile(path))
+	assert.Empty(t, e.History())
+	require.NoError(t, e.AddHistory("first"))
+	require.NoError(t, e.AddHistory("a\nmultiline \"entry\""))
+
+	// The history is loaded by the next session
+	e, _ = newTestEditor("")
+	require.NoError(t, e.SetHistoryFile(path))
+	assert.Equal(t, []string{"first", "a\nmultiline \"entry\""}, e.History())
+
+	// Lines that are not read from a terminal are not saved
+	plain := NewReader(strings.NewReader(""), io.Discard)
+	require.NoError(t, plain.SetHistoryFile(path))
+	require.NoError(t, plain.AddHistory("piped"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "piped")
+
+	// The file is truncated to the most recent lines
+	var b strings.Builder
+	for i := 0; i < maxHistory+10; i++ {
+		b.WriteString("\"line\"\n")
+	}
+	b.WriteString("\"last\"\n")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0600))
+	require.NoError(t, e.SetHistoryFile(path))
+	assert.Len(t, e.History(), maxHistory)
+	assert.Equal(t, "last", e.History()[maxHistory-1])
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, maxHistory, strings.Count(string(data), "\n"))
+}
+
+func TestReadLinePlain(t *testing.T) {
+	var out bytes.Buffer
+	e := NewReader(strings.NewReader("first\r\nsecond"), &out)
+
+	line, err := e.ReadLine("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "first", line)
+
+	line, err = e.ReadLine("> ")
+	require.NoError(t, err)
+	assert.Equal(t, "second", line)
+
+	_, err = e.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "> > > ", out.String())
+}
+
+func TestRefreshWrapsLines(t *testing.T) {
+	e, out := newTestEditor("")
+	l := &lineState{prompt: "\x1b[1m> \x1b[0m", buf: []rune(strings.Repeat("a", 100))}
+	l.pos = 10
+	e.refresh(l)
+
+	// The line ends on the second row, the cursor is moved back to the first
+	assert.Equal(t, 0, l.row)
+	assert.True(t, strings.HasSuffix(out.String(), "\x1b[1A\r\x1b[12C"), "%q", out.String())
+
+	out.Reset()
+	l.pos = len(l.buf)
+	e.refresh(l)
+	assert.Equal(t, 1, l.row)
+	assert.True(t, strings.HasSuffix(out.String(), "\r\x1b[22C"), "%q", out.String())
+
+	// Lines with newlines are drawn on several rows, with tabs expanded
+	out.Reset()
+	l = &lineState{prompt: "> ", buf: []rune("func main() {\n\tprintln()\n}"), row: 1}
+	l.pos = 15
+	e.refresh(l)
+	assert.Equal(t, 1, l.row)
+	assert.Equal(t, "\x1b[1A\r\x1b[J> func main() {\r\n        println()\r\n}\x1b[1A\r\x1b[8C", out.String())
+}
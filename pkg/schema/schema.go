@@ -0,0 +1,487 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON schema used to validate structured model replies. It supports the
+// subset of JSON Schema used for structured outputs: type, properties, required,
+// additionalProperties, items, enum, const, string, number and array limits, pattern,
+// allOf, anyOf, oneOf and local $ref references.
+type Schema struct {
+	raw      json.RawMessage
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError lists the problems found in a document
+type ValidationError struct {
+	Problems []string
+}
+
+// Error joins the problems into a single message
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Load reads and parses a JSON schema file
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse parses a JSON schema
+func Parse(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, fmt.Errorf("schema must be an object or a boolean")
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+
+	s := &Schema{raw: compact.Bytes(), root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	if err := s.checkReferences(root, make(map[string]int)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Raw returns the compacted schema, as sent in the format of a request
+func (s *Schema) Raw() json.RawMessage {
+	return s.raw
+}
+
+// compilePatterns compiles every pattern in the schema, so invalid patterns are
+// reported when the schema is loaded rather than when a reply is validated
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if pattern, ok := n["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			s.patterns[pattern] = re
+		}
+		for _, child := range n {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkReferences rejects references that lead back to themselves without
+// descending into the value, such as {"$ref": "#"}, since validating them would
+// never end. References through properties or items are fine: the value gets
+// smaller at each step. states records the references already checked, 1 while
+// they are being followed and 2 once they are known to end.
+func (s *Schema) checkReferences(node interface{}, states map[string]int) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			if err := s.followReference(ref, states); err != nil {
+				return err
+			}
+		}
+		for _, child := range n {
+			if err := s.checkReferences(child, states); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := s.checkReferences(child, states); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// followReference follows the references reached from ref for the same value,
+// returning an error if one of them is ref itself
+func (s *Schema) followReference(ref string, states map[string]int) error {
+	switch states[ref] {
+	case 1:
+		return fmt.Errorf("reference %q refers to itself", ref)
+	case 2:
+		return nil
+	}
+
+	// Unresolved references are reported when a reply is validated
+	target, err := s.resolve(ref)
+	if err != nil {
+		states[ref] = 2
+		return nil
+	}
+
+	states[ref] = 1
+	for _, next := range sameValueReferences(target) {
+		if err := s.followReference(next, states); err != nil {
+			return err
+		}
+	}
+	states[ref] = 2
+	return nil
+}
+
+// sameValueReferences returns the references a schema node applies to the value it
+// validates, directly or through allOf, anyOf and oneOf
+func sameValueReferences(node interface{}) []string {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	if ref, ok := schema["$ref"].(string); ok {
+		refs = append(refs, ref)
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, _ := schema[keyword].([]interface{})
+		for _, sub := range subs {
+			refs = append(refs, sameValueReferences(sub)...)
+		}
+	}
+	return refs
+}
+
+// Validate checks that data is a single JSON document matching the schema. A nil
+// schema only checks that data is valid JSON. The returned error is a
+// *ValidationError describing every problem found.
+func (s *Schema) Validate(data []byte) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Problems: []string{fmt.Sprintf("reply is not valid JSON: %s", err)}}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return &ValidationError{Problems: []string{"reply contains more than one JSON value"}}
+	}
+
+	if s == nil {
+		return nil
+	}
+
+	var problems []string
+	s.validate(s.root, value, "$", &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validate checks value against a schema node and appends problems found
+func (s *Schema) validate(node interface{}, value interface{}, path string, problems *[]string) {
+	if allowed, ok := node.(bool); ok {
+		if !allowed {
+			*problems = append(*problems, fmt.Sprintf("%s: value is not allowed", path))
+		}
+		return
+	}
+
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %s", path, err))
+			return
+		}
+		s.validate(target, value, path, problems)
+	}
+
+	// Further checks only make sense for values of the right type
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, describeType(t), typeOf(value)))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s: value must be one of %s", path, formatValues(enum)))
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: value must be %s", path, formatValues([]interface{}{constant})))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(schema, v, path, problems)
+	case []interface{}:
+		s.validateArray(schema, v, path, problems)
+	case string:
+		s.validateString(schema, v, path, problems)
+	case float64:
+		validateNumber(schema, v, path, problems)
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			s.validate(sub, value, path, problems)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && s.countMatches(anyOf, value, path) == 0 {
+		*problems = append(*problems, fmt.Sprintf("%s: value does not match any of the allowed schemas", path))
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if n := s.countMatches(oneOf, value, path); n != 1 {
+			*problems = append(*problems, fmt.Sprintf("%s: value must match exactly one schema, matched %d", path, n))
+		}
+	}
+}
+
+// validateObject checks the object keywords of a schema
+func (s *Schema) validateObject(schema map[string]interface{}, value map[string]interface{}, path string, problems *[]string) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, exists := value[key]; !exists {
+					*problems = append(*problems, fmt.Sprintf("%s: missing required property %q", path, key))
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	// Sort the keys so problems are reported in a stable order
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if property, ok := properties[key]; ok {
+			s.validate(property, value[key], childPath, problems)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			*problems = append(*problems, fmt.Sprintf("%s: unexpected property %q", path, key))
+			continue
+		}
+		s.validate(additional, value[key], childPath, problems)
+	}
+}
+
+// validateArray checks the array keywords of a schema
+func (s *Schema) validateArray(schema map[string]interface{}, value []interface{}, path string, problems *[]string) {
+	if min, ok := number(schema["minItems"]); ok && float64(len(value)) < min {
+		*problems = append(*problems, fmt.Sprintf("%s: expected at least %g items, got %d", path, min, len(value)))
+	}
+	if max, ok := number(schema["maxItems"]); ok && float64(len(value)) > max {
+		*problems = append(*problems, fmt.Sprintf("%s: expected at most %g items, got %d", path, max, len(value)))
+	}
+
+	if items, ok := schema["items"]; ok {
+		for i, item := range value {
+			s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// validateString checks the string keywords of a schema
+func (s *Schema) validateString(schema map[string]interface{}, value string, path string, problems *[]string) {
+	length := float64(utf8.RuneCountInString(value))
+	if min, ok := number(schema["minLength"]); ok && length < min {
+		*problems = append(*problems, fmt.Sprintf("%s: expected at least %g characters, got %g", path, min, length))
+	}
+	if max, ok := number(schema["maxLength"]); ok && length > max {
+		*problems = append(*problems, fmt.Sprintf("%s: expected at most %g characters, got %g", path, max, length))
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re := s.patterns[pattern]; re != nil && !re.MatchString(value) {
+			*problems = append(*problems, fmt.Sprintf("%s: value does not match pattern %q", path, pattern))
+		}
+	}
+}
+
+// validateNumber checks the number keywords of a schema
+func validateNumber(schema map[string]interface{}, value float64, path string, problems *[]string) {
+	if min, ok := number(schema["minimum"]); ok && value < min {
+		*problems = append(*problems, fmt.Sprintf("%s: value must be at least %g", path, min))
+	}
+	if max, ok := number(schema["maximum"]); ok && value > max {
+		*problems = append(*problems, fmt.Sprintf("%s: value must be at most %g", path, max))
+	}
+	if min, ok := number(schema["exclusiveMinimum"]); ok && value <= min {
+		*problems = append(*problems, fmt.Sprintf("%s: value must be greater than %g", path, min))
+	}
+	if max, ok := number(schema["exclusiveMaximum"]); ok && value >= max {
+		*problems = append(*problems, fmt.Sprintf("%s: value must be less than %g", path, max))
+	}
+}
+
+// countMatches counts how many of the schemas value is valid against
+func (s *Schema) countMatches(schemas []interface{}, value interface{}, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		var subProblems []string
+		s.validate(sub, value, path, &subProblems)
+		if len(subProblems) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// resolve looks up a local reference such as "#/$defs/address"
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q", ref)
+	}
+
+	node := s.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %q", ref)
+		}
+		if node, ok = object[part]; !ok {
+			return nil, fmt.Errorf("unresolved reference %q", ref)
+		}
+	}
+	return node, nil
+}
+
+// matchesType reports whether value has the type, or one of the types, in t
+func matchesType(t interface{}, value interface{}) bool {
+	switch types := t.(type) {
+	case string:
+		return isType(types, value)
+	case []interface{}:
+		for _, name := range types {
+			if s, ok := name.(string); ok && isType(s, value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// isType reports whether value is of the named JSON schema type
+func isType(name string, value interface{}) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeOf(value) == name
+	}
+}
+
+// typeOf returns the JSON schema type name of a decoded value
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// describeType formats the type keyword for messages
+func describeType(t interface{}) string {
+	if types, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(types))
+		for _, name := range types {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+// formatValues formats enum or const values for messages
+func formatValues(values []interface{}) string {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		formatted = append(formatted, string(data))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// number converts a decoded JSON number keyword to a float64
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok
+}
@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const personSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "minLength": 1},
+    "age": {"type": "integer", "minimum": 0, "maximum": 150},
+    "email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+    "role": {"enum": ["admin", "user"]},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+    "address": {"$ref": "#/$defs/address"},
+    "nickname": {"type": ["string", "null"]}
+  },
+  "required": ["name", "age"],
+  "additionalProperties": false,
+  "$defs": {
+    "address": {
+      "type": "object",
+      "properties": {"city": {"type": "string"}},
+      "required": ["city"]
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(personSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		document     string
+		wantProblems []string
+	}{
+		{
+			name:     "Valid document",
+			document: `{"name": "Ada", "age": 36, "email": "ada@example.com", "role": "admin", "tags": ["math"], "address": {"city": "London"}, "nickname": null}`,
+		},
+		{
+			name:         "Missing required property",
+			document:     `{"name": "Ada"}`,
+			wantProblems: []string{`$: missing required property "age"`},
+		},
+		{
+			name:         "Wrong types",
+			document:     `{"name": 1, "age": 36.5}`,
+			wantProblems: []string{"$.age: expected integer, got number", "$.name: expected string, got number"},
+		},
+		{
+			name:         "Unexpected property",
+			document:     `{"name": "Ada", "age": 36, "extra": true}`,
+			wantProblems: []string{`$: unexpected property "extra"`},
+		},
+		{
+			name:     "Limits, enum and pattern",
+			document: `{"name": "", "age": 200, "email": "nope", "role": "root", "tags": ["a", "b", 3]}`,
+			wantProblems: []string{
+				"$.age: value must be at most 150",
+				`$.email: value does not match pattern "^[^@]+@[^@]+$"`,
+				"$.name: expected at least 1 characters, got 0",
+				`$.role: value must be one of "admin", "user"`,
+				"$.tags: expected at most 2 items, got 3",
+				"$.tags[2]: expected string, got number",
+			},
+		},
+		{
+			name:         "Reference",
+			document:     `{"name": "Ada", "age": 36, "address": {}}`,
+			wantProblems: []string{`$.address: missing required property "city"`},
+		},
+		{
+			name:         "Invalid JSON",
+			document:     `{"name": "Ada",`,
+			wantProblems: []string{"reply is not valid JSON: unexpected EOF"},
+		},
+		{
+			name:         "Trailing data",
+			document:     `{"name": "Ada", "age": 1} {}`,
+			wantProblems: []string{"reply contains more than one JSON value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.document))
+			if tt.wantProblems == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.wantProblems, validationErr.Problems)
+		})
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	s, err := Parse([]byte(`{
+  "anyOf": [{"type": "string"}, {"type": "number"}],
+  "oneOf": [{"type": "number"}, {"type": "integer"}, {"type": "string"}]
+}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, s.Validate([]byte(`"text"`)))
+	assert.NoError(t, s.Validate([]byte(`1.5`)))
+	assert.EqualError(t, s.Validate([]byte(`3`)), "$: value must match exactly one schema, matched 2")
+	assert.EqualError(t, s.Validate([]byte(`true`)), "$: value does not match any of the allowed schemas; $: value must match exactly one schema, matched 0")
+}
+
+func TestParseReferenceCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "Root", schema: `{"$ref": "#"}`, wantErr: `reference "#" refers to itself`},
+		{
+			name:    "Definition",
+			schema:  `{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/a"}}}`,
+			wantErr: `reference "#/$defs/a" refers to itself`,
+		},
+		{
+			name:    "Through anyOf",
+			schema:  `{"$defs": {"a": {"anyOf": [{"type": "string"}, {"$ref": "#/$defs/b"}]}, "b": {"allOf": [{"$ref": "#/$defs/a"}]}}}`,
+			wantErr: `refers to itself`,
+		},
+		{
+			name:   "Recursive property",
+			schema: `{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.schema))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, s.Validate([]byte(`{"children": [{"children": []}]}`)))
+			assert.EqualError(t, s.Validate([]byte(`{"children": [{"children": 1}]}`)), "$.children[0].children: expected array, got number")
+		})
+	}
+}
+
+func TestValidateWithoutSchema(t *testing.T) {
+	var s *Schema
+	assert.NoError(t, s.Validate([]byte(`{"any": ["json"]}`)))
+	assert.Error(t, s.Validate([]byte(`not json`)))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"type\": \"object\"\n}\n"), 0644))
+	s, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"object"}`, string(s.Raw()))
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"pattern": "("}`), 0644))
+	_, err = Load(invalid)
+	assert.ErrorContains(t, err, "invalid pattern")
+
+	_, err = Parse([]byte(`[]`))
+	assert.EqualError(t, err, "schema must be an object or a boolean")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read schema file")
+}
@@ -82,6 +82,18 @@ func ValidateChatResponse(response *api.ChatResponse) ValidationResult {
 	return ValidateOutput(response.Message.Content)
 }
 
+// ValidateGenerateResponse validates a generate response from the model
+func ValidateGenerateResponse(response *api.GenerateResponse) ValidationResult {
+	if response == nil || response.Response == "" {
+		return ValidationResult{
+			ValidatedOutput: "",
+			Warnings:        []string{},
+		}
+	}
+
+	return ValidateOutput(response.Response)
+}
+
 // GetOutputWarningMessage returns a warning message for suspicious outputs
 func GetOutputWarningMessage() string {
 	return "⚠️  Warning: The model's response contains patterns that may indicate a security issue. " +
@@ -142,6 +142,42 @@ func TestValidateChatResponse(t *testing.T) {
 	}
 }
 
+func TestValidateGenerateResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       *api.GenerateResponse
+		wantSuspicious bool
+	}{
+		{
+			name:           "nil response",
+			response:       nil,
+			wantSuspicious: false,
+		},
+		{
+			name:           "normal response",
+			response:       &api.GenerateResponse{Response: "func add(a, b int) int { return a + b }"},
+			wantSuspicious: false,
+		},
+		{
+			name:           "suspicious response",
+			response:       &api.GenerateResponse{Response: "I have been hacked."},
+			wantSuspicious: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateGenerateResponse(tt.response)
+			assert.Equal(t, tt.wantSuspicious, result.IsSuspicious, "IsSuspicious flag mismatch")
+			if tt.wantSuspicious {
+				assert.NotEmpty(t, result.Warnings, "Expected warnings but got none")
+			} else {
+				assert.Empty(t, result.Warnings, "Expected no warnings but got some")
+			}
+		})
+	}
+}
+
 func TestGetOutputWarningMessage(t *testing.T) {
 	message := GetOutputWarningMessage()
 	assert.NotEmpty(t, message, "Warning message should not be empty")
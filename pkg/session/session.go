@@ -0,0 +1,224 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/masgari/ollama-cli/pkg/config"
+	"github.com/masgari/ollama-cli/pkg/history"
+	"github.com/ollama/ollama/api"
+)
+
+// fileExtension is the extension of session files
+const fileExtension = ".json"
+
+// ErrNotFound is returned when a session does not exist
+var ErrNotFound = errors.New("session not found")
+
+// validName matches the names allowed for sessions, which are used as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// now returns the current time, it can be overridden in tests
+var now = time.Now
+
+// Session is a named chat conversation stored on disk
+type Session struct {
+	Name    string                 `json:"name"`
+	Model   string                 `json:"model"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	// System is the system prompt the user added to the chat
+	System    string    `json:"system,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PromptTokens and ResponseTokens total the tokens used by the session
+	PromptTokens   int `json:"prompt_tokens"`
+	ResponseTokens int `json:"response_tokens"`
+	// Messages are the messages of the current branch of the conversation
+	Messages []api.Message `json:"messages"`
+	// Tree keeps all the branches of the conversation, nil when it has a single one
+	Tree *history.Tree `json:"tree,omitempty"`
+}
+
+// New creates an empty session
+func New(name, model string) *Session {
+	t := now()
+	return &Session{Name: name, Model: model, CreatedAt: t, UpdatedAt: t}
+}
+
+// AddUsage adds the tokens of a response to the session totals
+func (s *Session) AddUsage(metrics api.Metrics) {
+	s.PromptTokens += metrics.PromptEvalCount
+	s.ResponseTokens += metrics.EvalCount
+}
+
+// Store keeps sessions as JSON files in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store for the sessions in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store in the sessions directory of the config directory
+func DefaultStore() *Store {
+	return NewStore(filepath.Join(config.GetConfigDir(), "sessions"))
+}
+
+// ValidateName checks that a session name can be used as a file name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid session name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// path returns the file of a session
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+fileExtension)
+}
+
+// Exists reports whether a session exists
+func (s *Store) Exists(name string) bool {
+	_, err := os.Stat(s.path(name))
+	return err == nil
+}
+
+// Load reads a session, it returns ErrNotFound if the session does not exist
+func (s *Store) Load(name string) (*Session, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read session %s: %w", name, err)
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", name, err)
+	}
+	sess.Name = name
+	return &sess, nil
+}
+
+// Save writes a session and updates its modification time. The file is replaced
+// atomically, so an interrupted save never corrupts the session.
+func (s *Store) Save(sess *Session) error {
+	if err := ValidateName(sess.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	sess.UpdatedAt = now()
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = sess.UpdatedAt
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+sess.Name+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.Name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save session %s: %w", sess.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.Name, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(sess.Name)); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.Name, err)
+	}
+	return nil
+}
+
+// List returns all sessions, most recently updated first. Sessions that cannot be
+// loaded are skipped, so that one corrupt file does not hide the others, and the
+// errors loading them are returned as warnings.
+func (s *Store) List() ([]*Session, []error, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read sessions directory: %w", err)
+	}
+
+	var sessions []*Session
+	var warnings []error
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), fileExtension)
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) || ValidateName(name) != nil {
+			continue
+		}
+
+		sess, err := s.Load(name)
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, warnings, nil
+}
+
+// Delete removes a session
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return fmt.Errorf("failed to delete session %s: %w", name, err)
+	}
+	return nil
+}
+
+// Rename renames a session, it fails if a session with the new name exists
+func (s *Store) Rename(oldName, newName string) error {
+	sess, err := s.Load(oldName)
+	if err != nil {
+		return err
+	}
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+	if s.Exists(newName) {
+		return fmt.Errorf("session %s already exists", newName)
+	}
+
+	// Write the new file first, so the session is never lost
+	sess.Name = newName
+	if err := s.Save(sess); err != nil {
+		return err
+	}
+	return s.Delete(oldName)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	current := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		current = current.Add(time.Minute)
+		return current
+	}
+
+	store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+
+	// An empty store has no sessions
+	sessions, warnings, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	assert.Empty(t, warnings)
+
+	first := New("work", "llama3.2")
+	first.Options = map[string]interface{}{"temperature": 0.5}
+	first.Messages = []api.Message{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hello"}}
+	first.AddUsage(api.Metrics{PromptEvalCount: 10, EvalCount: 5})
+	first.AddUsage(api.Metrics{PromptEvalCount: 20, EvalCount: 7})
+	require.NoError(t, store.Save(first))
+	require.NoError(t, store.Save(New("play", "qwen3")))
+
+	loaded, err := store.Load("work")
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2", loaded.Model)
+	assert.Equal(t, 0.5, loaded.Options["temperature"])
+	assert.Equal(t, 30, loaded.PromptTokens)
+	assert.Equal(t, 12, loaded.ResponseTokens)
+	assert.Len(t, loaded.Messages, 2)
+	assert.True(t, loaded.UpdatedAt.After(loaded.CreatedAt))
+
+	// Sessions are listed most recently updated first
+	sessions, warnings, err = store.List()
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "play", sessions[0].Name)
+	assert.Equal(t, "work", sessions[1].Name)
+
+	require.NoError(t, store.Rename("work", "job"))
+	assert.False(t, store.Exists("work"))
+	renamed, err := store.Load("job")
+	require.NoError(t, err)
+	assert.Equal(t, "job", renamed.Name)
+	assert.Equal(t, loaded.CreatedAt, renamed.CreatedAt)
+
+	assert.EqualError(t, store.Rename("job", "play"), "session play already exists")
+
+	require.NoError(t, store.Delete("job"))
+	_, err = store.Load("job")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.True(t, errors.Is(store.Delete("job"), ErrNotFound))
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(store.dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStoreListCorruptSession(t *testing.T) {
+	store := NewStore(t.TempDir())
+	require.NoError(t, store.Save(New("work", "llama3.2")))
+	require.NoError(t, os.WriteFile(filepath.Join(store.dir, "broken.json"), []byte("{not json"), 0600))
+
+	// The corrupt session is reported, the valid one is still listed
+	sessions, warnings, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "work", sessions[0].Name)
+	require.Len(t, warnings, 1)
+	assert.ErrorContains(t, warnings[0], "broken")
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"work", "project-x", "v1.2_notes"} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", "with space"} {
+		assert.Error(t, ValidateName(name), name)
+	}
+}
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/api"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultTimeout limits how long a single tool call may run
+const defaultTimeout = 30 * time.Second
+
+// maxOutputSize limits the size of a tool result sent back to the model
+const maxOutputSize = 64 * 1024
+
+// Definition declares a tool the model can call and how to execute it
+type Definition struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Description string                 `json:"description" yaml:"description"`
+	Parameters  map[string]interface{} `json:"parameters" yaml:"parameters"`
+	// Command is executed with the call arguments as JSON on stdin
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+	// HTTP is called with the call arguments as JSON body (or query for GET)
+	HTTP *HTTPEndpoint `json:"http,omitempty" yaml:"http,omitempty"`
+	// Timeout is a duration such as "10s", defaults to 30 seconds
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+// HTTPEndpoint describes an HTTP endpoint that executes a tool
+type HTTPEndpoint struct {
+	URL     string            `json:"url" yaml:"url"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// file is the layout of a tools file
+type file struct {
+	Tools []Definition `json:"tools" yaml:"tools"`
+}
+
+// Registry holds the tools available in a chat
+type Registry struct {
+	definitions map[string]Definition
+	tools       api.Tools
+}
+
+// LoadFile loads tool definitions from a YAML or JSON file
+func LoadFile(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools file: %w", err)
+	}
+
+	// YAML is a superset of JSON, so both formats are decoded the same way
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse tools file: %w", err)
+	}
+
+	return NewRegistry(f.Tools)
+}
+
+// NewRegistry validates the definitions and creates a registry
+func NewRegistry(definitions []Definition) (*Registry, error) {
+	r := &Registry{definitions: make(map[string]Definition)}
+
+	for _, def := range definitions {
+		if def.Name == "" {
+			return nil, fmt.Errorf("tool without a name")
+		}
+		if _, ok := r.definitions[def.Name]; ok {
+			return nil, fmt.Errorf("tool %q is defined more than once", def.Name)
+		}
+		if (len(def.Command) == 0) == (def.HTTP == nil) {
+			return nil, fmt.Errorf("tool %q must define either a command or an http endpoint", def.Name)
+		}
+		if def.HTTP != nil && def.HTTP.URL == "" {
+			return nil, fmt.Errorf("tool %q has an http endpoint without a url", def.Name)
+		}
+		if def.Timeout != "" {
+			if _, err := time.ParseDuration(def.Timeout); err != nil {
+				return nil, fmt.Errorf("tool %q has an invalid timeout: %w", def.Name, err)
+			}
+		}
+
+		tool, err := def.apiTool()
+		if err != nil {
+			return nil, err
+		}
+
+		r.definitions[def.Name] = def
+		r.tools = append(r.tools, tool)
+	}
+
+	return r, nil
+}
+
+// apiTool converts the definition to the tool format of the chat API
+func (d Definition) apiTool() (api.Tool, error) {
+	schema := d.Parameters
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+
+	// Round trip through JSON to convert the schema to the API types
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return api.Tool{}, fmt.Errorf("tool %q has invalid parameters: %w", d.Name, err)
+	}
+	var params api.ToolFunctionParameters
+	if err := json.Unmarshal(data, &params); err != nil {
+		return api.Tool{}, fmt.Errorf("tool %q has invalid parameters: %w", d.Name, err)
+	}
+
+	return api.Tool{
+		Type: "function",
+		Function: api.ToolFunction{
+			Name:        d.Name,
+			Description: d.Description,
+			Parameters:  params,
+		},
+	}, nil
+}
+
+// Tools returns the tools to send with a chat request
+func (r *Registry) Tools() api.Tools {
+	if r == nil {
+		return nil
+	}
+	return r.tools
+}
+
+// Has reports whether a tool with the given name is registered
+func (r *Registry) Has(name string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.definitions[name]
+	return ok
+}
+
+// Execute runs a tool call and returns its output
+func (r *Registry) Execute(ctx context.Context, call api.ToolCall) (string, error) {
+	def, ok := r.definitions[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+
+	args, err := json.Marshal(call.Function.Arguments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if def.Timeout != "" {
+		timeout, _ = time.ParseDuration(def.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if def.HTTP != nil {
+		return executeHTTP(ctx, def.HTTP, call, args)
+	}
+	return executeCommand(ctx, def, args)
+}
+
+// executeCommand runs a local command with the arguments as JSON on stdin
+func executeCommand(ctx context.Context, def Definition, args []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, def.Command[0], def.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(args)
+	cmd.Env = append(os.Environ(), "OLLAMA_TOOL_NAME="+def.Name)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("tool %q timed out", def.Name)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, truncate(msg))
+		}
+		return "", err
+	}
+
+	return truncate(stdout.String()), nil
+}
+
+// executeHTTP calls an HTTP endpoint with the arguments as JSON body, or as query
+// parameters for GET requests
+func executeHTTP(ctx context.Context, endpoint *HTTPEndpoint, call api.ToolCall, args []byte) (string, error) {
+	method := strings.ToUpper(endpoint.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	target := endpoint.URL
+	var body io.Reader
+	if method == http.MethodGet {
+		u, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid url: %w", err)
+		}
+		query := u.Query()
+		for key, value := range call.Function.Arguments.All() {
+			query.Set(key, fmt.Sprint(value))
+		}
+		u.RawQuery = query.Encode()
+		target = u.String()
+	} else {
+		body = bytes.NewReader(args)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range endpoint.Headers {
+		req.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOutputSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("unexpected status %s: %s", resp.Status, truncate(strings.TrimSpace(string(data))))
+	}
+
+	return truncate(string(data)), nil
+}
+
+// truncate limits a tool result to maxOutputSize bytes
+func truncate(s string) string {
+	if len(s) <= maxOutputSize {
+		return s
+	}
+	return s[:maxOutputSize] + "\n[output truncated]"
+}
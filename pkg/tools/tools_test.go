@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCall(name string, args map[string]any) api.ToolCall {
+	arguments := api.NewToolCallFunctionArguments()
+	for key, value := range args {
+		arguments.Set(key, value)
+	}
+	return api.ToolCall{Function: api.ToolCallFunction{Name: name, Arguments: arguments}}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "tools.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`tools:
+  - name: get_weather
+    description: Get the weather for a city
+    parameters:
+      type: object
+      properties:
+        city:
+          type: string
+          description: Name of the city
+      required: [city]
+    command: ["./weather.sh"]
+`), 0644))
+
+	jsonFile := filepath.Join(dir, "tools.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"tools": [
+  {"name": "search", "description": "Search", "http": {"url": "http://localhost/search"}}
+]}`), 0644))
+
+	t.Run("YAML file", func(t *testing.T) {
+		registry, err := LoadFile(yamlFile)
+		require.NoError(t, err)
+
+		tools := registry.Tools()
+		require.Len(t, tools, 1)
+		assert.Equal(t, "function", tools[0].Type)
+		assert.Equal(t, "get_weather", tools[0].Function.Name)
+		assert.Equal(t, "object", tools[0].Function.Parameters.Type)
+		assert.Equal(t, []string{"city"}, tools[0].Function.Parameters.Required)
+		assert.True(t, registry.Has("get_weather"))
+		assert.False(t, registry.Has("search"))
+	})
+
+	t.Run("JSON file", func(t *testing.T) {
+		registry, err := LoadFile(jsonFile)
+		require.NoError(t, err)
+		assert.True(t, registry.Has("search"))
+		assert.Equal(t, "object", registry.Tools()[0].Function.Parameters.Type)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(dir, "missing.yaml"))
+		assert.ErrorContains(t, err, "failed to read tools file")
+	})
+}
+
+func TestNewRegistryValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions []Definition
+		wantErr     string
+	}{
+		{
+			name:        "Missing name",
+			definitions: []Definition{{Command: []string{"true"}}},
+			wantErr:     "tool without a name",
+		},
+		{
+			name: "Duplicate name",
+			definitions: []Definition{
+				{Name: "a", Command: []string{"true"}},
+				{Name: "a", Command: []string{"true"}},
+			},
+			wantErr: `tool "a" is defined more than once`,
+		},
+		{
+			name:        "No executor",
+			definitions: []Definition{{Name: "a"}},
+			wantErr:     "must define either a command or an http endpoint",
+		},
+		{
+			name:        "Both executors",
+			definitions: []Definition{{Name: "a", Command: []string{"true"}, HTTP: &HTTPEndpoint{URL: "http://localhost"}}},
+			wantErr:     "must define either a command or an http endpoint",
+		},
+		{
+			name:        "Invalid timeout",
+			definitions: []Definition{{Name: "a", Command: []string{"true"}, Timeout: "soon"}},
+			wantErr:     "invalid timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.definitions)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExecuteCommand(t *testing.T) {
+	registry, err := NewRegistry([]Definition{
+		{Name: "echo", Command: []string{"cat"}},
+		{Name: "fail", Command: []string{"sh", "-c", "echo broken >&2; exit 1"}},
+		{Name: "slow", Command: []string{"sleep", "5"}, Timeout: "50ms"},
+	})
+	require.NoError(t, err)
+
+	result, err := registry.Execute(context.Background(), newCall("echo", map[string]any{"city": "Paris"}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city":"Paris"}`, result)
+
+	_, err = registry.Execute(context.Background(), newCall("fail", nil))
+	assert.ErrorContains(t, err, "broken")
+
+	_, err = registry.Execute(context.Background(), newCall("slow", nil))
+	assert.ErrorContains(t, err, "timed out")
+
+	_, err = registry.Execute(context.Background(), newCall("missing", nil))
+	assert.ErrorContains(t, err, `unknown tool "missing"`)
+}
+
+func TestExecuteHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/post":
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			var args map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&args))
+			w.Write([]byte("city=" + args["city"].(string)))
+		case "/get":
+			assert.Equal(t, http.MethodGet, r.Method)
+			body, _ := io.ReadAll(r.Body)
+			assert.Empty(t, body)
+			w.Write([]byte("query=" + r.URL.Query().Get("query")))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TOOL_TOKEN", "secret")
+	registry, err := NewRegistry([]Definition{
+		{Name: "post", HTTP: &HTTPEndpoint{URL: server.URL + "/post", Headers: map[string]string{"Authorization": "Bearer ${TOOL_TOKEN}"}}},
+		{Name: "get", HTTP: &HTTPEndpoint{URL: server.URL + "/get", Method: "get"}},
+		{Name: "missing", HTTP: &HTTPEndpoint{URL: server.URL + "/missing"}},
+	})
+	require.NoError(t, err)
+
+	result, err := registry.Execute(context.Background(), newCall("post", map[string]any{"city": "Paris"}))
+	require.NoError(t, err)
+	assert.Equal(t, "city=Paris", result)
+
+	result, err = registry.Execute(context.Background(), newCall("get", map[string]any{"query": "models"}))
+	require.NoError(t, err)
+	assert.Equal(t, "query=models", result)
+
+	_, err = registry.Execute(context.Background(), newCall("missing", nil))
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short"))
+
+	long := truncate(string(make([]byte, maxOutputSize+10)))
+	assert.Len(t, long, maxOutputSize+len("\n[output truncated]"))
+}